@@ -0,0 +1,93 @@
+// Package barcode checks the EAN-13 and UPC-A codes printed on products,
+// makes codes for products without one and prints them on label sheets.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalid = errors.New(" Invalid Barcode")
+
+// Normalize checks the check digit of an EAN-13 or UPC-A code and returns
+// it as the 13 digits scanners read either as. A UPC-A code is an EAN-13
+// code starting with 0, so both forms of it find the same product.
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return "", ErrInvalid
+		}
+	}
+	switch len(code) {
+	case 12:
+		code = "0" + code
+	case 13:
+	default:
+		return "", ErrInvalid
+	}
+	if CheckDigit(code[:12]) != code[12] {
+		return "", ErrInvalid
+	}
+	return code, nil
+}
+
+// CheckDigit is the GTIN check digit of digits: weighted 3 and 1
+// alternately from the rightmost, then rounded up to a multiple of ten.
+func CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		weight := 1
+		if (len(digits)-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// InStore makes the code of a product that has none printed. Codes
+// starting with 20 are kept for use inside a store, so they never clash
+// with a manufacturer's.
+func InStore(productID uint) string {
+	digits := fmt.Sprintf("20%010d", productID)
+	return digits + string(CheckDigit(digits))
+}
+
+// EAN-13 digit patterns, one bit a module with 1 for a bar. The left half
+// takes each digit with odd (L) or even (G) parity, and the parities used
+// encode the first digit; the right half is always R.
+var (
+	codesL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	codesG = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	codesR = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+	parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// Modules are the 95 modules of a normalized EAN-13 code from left to
+// right, true for a bar.
+func Modules(code string) []bool {
+	var b strings.Builder
+	b.WriteString("101")
+	first := code[0] - '0'
+	for i := 1; i <= 6; i++ {
+		digit := code[i] - '0'
+		if parity[first][i-1] == 'G' {
+			b.WriteString(codesG[digit])
+		} else {
+			b.WriteString(codesL[digit])
+		}
+	}
+	b.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		b.WriteString(codesR[code[i]-'0'])
+	}
+	b.WriteString("101")
+
+	modules := make([]bool, 0, 95)
+	for _, c := range b.String() {
+		modules = append(modules, c == '1')
+	}
+	return modules
+}
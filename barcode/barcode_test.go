@@ -0,0 +1,85 @@
+package barcode_test
+
+import (
+	"bytes"
+	"fmt"
+	"project_pos_app/barcode"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+		err  error
+	}{
+		{"EAN-13", "4006381333931", "4006381333931", nil},
+		{"UPC-A is read as EAN-13", "036000291452", "0036000291452", nil},
+		{"Spaces around a scan are ignored", " 036000291452\n", "0036000291452", nil},
+		{"Wrong check digit", "4006381333932", "", barcode.ErrInvalid},
+		{"Too short", "12345", "", barcode.ErrInvalid},
+		{"Not digits", "40063813339A1", "", barcode.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := barcode.Normalize(tt.code)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestInStore(t *testing.T) {
+	code := barcode.InStore(42)
+
+	assert.Equal(t, "2000000000428", code)
+	normalized, err := barcode.Normalize(code)
+	assert.NoError(t, err)
+	assert.Equal(t, code, normalized)
+}
+
+func TestModules(t *testing.T) {
+	bits := ""
+	for _, bar := range barcode.Modules("4006381333931") {
+		if bar {
+			bits += "1"
+		} else {
+			bits += "0"
+		}
+	}
+
+	// Start guard, 0 in L, 0 in G (the first digit 4 gives LGLLGG),
+	// then the middle guard and the check digit 1 in R before the end guard.
+	assert.Len(t, bits, 95)
+	assert.Equal(t, "101"+"0001101"+"0100111", bits[:17])
+	assert.Equal(t, "01010", bits[45:50])
+	assert.Equal(t, "1100110"+"101", bits[85:])
+}
+
+func TestWriteSheets(t *testing.T) {
+	labels := make([]barcode.Label, 25)
+	for i := range labels {
+		labels[i] = barcode.Label{Name: fmt.Sprintf("Café (%d)", i), Code: barcode.InStore(uint(i + 1)), Price: "4.50"}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, barcode.WriteSheets(&buf, labels))
+	pdf := buf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(pdf), "/Count 2")
+	assert.Contains(t, string(pdf), `(Caf? \(0\)) Tj`)
+
+	// Every xref entry points at the start of its object.
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllSubmatch(pdf, -1)
+	assert.Len(t, xref, 7)
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+}
@@ -0,0 +1,155 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Label is one sticker on a label sheet: the product's name, its
+// normalized code and its price as printed.
+type Label struct {
+	Name  string
+	Code  string
+	Price string
+}
+
+// The sheet is A4 in points, 3 by 8 labels of 70 by 37 mm with no gaps,
+// the common layout of self-adhesive label paper.
+const (
+	pageWidth   = 595.28
+	pageHeight  = 841.89
+	columns     = 3
+	rows        = 8
+	labelWidth  = pageWidth / columns
+	labelHeight = 104.88
+	marginTop   = (pageHeight - rows*labelHeight) / 2
+	module      = 1.3
+	barHeight   = 42.0
+	quietZone   = 11 * module
+	nameLength  = 34
+)
+
+// WriteSheets writes labels as a PDF of label sheets, filled left to
+// right and top to bottom. Text is set in the Helvetica every PDF reader
+// has, so nothing needs to be embedded; characters outside ASCII print as
+// question marks.
+func WriteSheets(w io.Writer, labels []Label) error {
+	perPage := columns * rows
+	pages := (len(labels) + perPage - 1) / perPage
+	if pages == 0 {
+		pages = 1
+	}
+
+	// Objects 1 to 3 are the catalog, the page tree and the font; each
+	// page then takes two, itself and its content stream.
+	objects := make([]string, 3, 3+2*pages)
+	kids := make([]string, pages)
+	for page := 0; page < pages; page++ {
+		kids[page] = fmt.Sprintf("%d 0 R", 4+2*page)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages)
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+
+	for page := 0; page < pages; page++ {
+		end := min((page+1)*perPage, len(labels))
+		content := pageContent(labels[page*perPage : end])
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 5+2*page),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pageContent draws the labels of one page: the name at the top, the
+// bars with the digits under them, and the price.
+func pageContent(labels []Label) string {
+	var b strings.Builder
+	for i, label := range labels {
+		x := float64(i%columns) * labelWidth
+		top := pageHeight - marginTop - float64(i/columns)*labelHeight
+		left := x + (labelWidth-95*module)/2
+
+		text(&b, 7, x+quietZone, top-14, truncate(label.Name, nameLength))
+
+		b.WriteString("0 g\n")
+		bottom := top - 22 - barHeight
+		for m, bar := range Modules(label.Code) {
+			if !bar {
+				continue
+			}
+			height := barHeight
+			if guard(m) {
+				height += 5
+			}
+			fmt.Fprintf(&b, "%.2f %.2f %.2f %.2f re f\n", left+float64(m)*module, bottom+barHeight-height, module, height)
+		}
+
+		// The first digit sits in the quiet zone, the others under the
+		// halves of the code they are encoded in.
+		digits := bottom - 12
+		text(&b, 9, left-8, digits, label.Code[:1])
+		text(&b, 9, left+5*module, digits, spaced(label.Code[1:7]))
+		text(&b, 9, left+52*module, digits, spaced(label.Code[7:]))
+		text(&b, 8, x+labelWidth-quietZone-float64(len(label.Price))*4.5, top-14, label.Price)
+	}
+	return b.String()
+}
+
+// guard reports whether module m belongs to the start, middle or end
+// guard bars, which are drawn longer.
+func guard(m int) bool {
+	return m < 3 || (m >= 45 && m < 50) || m >= 92
+}
+
+func text(b *strings.Builder, size float64, x, y float64, s string) {
+	fmt.Fprintf(b, "BT /F1 %.0f Tf %.2f %.2f Td (%s) Tj ET\n", size, x, y, escape(s))
+}
+
+func spaced(digits string) string {
+	return strings.Join(strings.Split(digits, ""), " ")
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// escape makes s safe inside a PDF string literal.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 	"project_pos_app/infra"
 	"project_pos_app/model"
@@ -14,25 +15,41 @@ func CronJob(ctx *infra.IntegrationContext) error {
 	c := cron.New(cron.WithLogger(cron.VerbosePrintfLogger(log.New(log.Writer(), "cron: ", log.LstdFlags))))
 
 	// untuk kedepannya pakai websocket atau firebase
-	// Schedule the task to check stock and send notifications if stock < LowStock
+	// Schedule the task to check every product and ingredient against its
+	// reorder point. Sales check the products and ingredients they move as
+	// they go; this catches the rest, such as recipe products whose
+	// ingredients ran low.
 	_, err := c.AddFunc("0 0 * * *", func() {
 		log.Println("Checking product stock levels...")
-		products, err := ctx.Ctl.Revenue.Service.Revenue.GetLowStockProducts(ctx.Cfg.LowStock)
+		notifications, err := ctx.Ctl.Revenue.Service.Product.EvaluateStockAlerts()
 		if err != nil {
-			log.Printf("Error fetching low stock products: %v\n", err)
+			log.Printf("Error evaluating stock alerts: %v\n", err)
+		}
+		for _, notification := range notifications {
+			log.Printf("Stock alert sent: %s\n", notification.Message)
+		}
+
+		notifications, err = ctx.Ctl.Ingredient.Service.Ingredient.EvaluateStockAlerts()
+		if err != nil {
+			log.Printf("Error evaluating ingredient alerts: %v\n", err)
+		}
+		for _, notification := range notifications {
+			log.Printf("Ingredient alert sent: %s\n", notification.Message)
+		}
+
+		drafts, err := ctx.Ctl.Purchase.Service.Purchase.DraftLowStock()
+		if err != nil {
+			log.Printf("Error drafting purchase orders: %v\n", err)
 			return
 		}
-		for _, product := range products {
+		for _, draft := range drafts {
 			data := model.Notification{
-				Title:     "Low Stock Alert",
-				Message:   "Product " + product.Name + " has less than 10 items in stock.",
+				Title:     "Purchase Order Drafted",
+				Message:   fmt.Sprintf("Purchase order #%d has %d low-stock product(s) to review.", draft.ID, len(draft.Lines)),
 				CreatedAt: time.Now(),
 			}
-			err := ctx.Ctl.Notif.Service.Notif.CreateNotification(data)
-			if err != nil {
-				log.Printf("Error sending notification for product %s: %v\n", product.Name, err)
-			} else {
-				log.Printf("Notification for product %s sent successfully.\n", product.Name)
+			if err := ctx.Ctl.Notif.Service.Notif.CreateNotification(data); err != nil {
+				log.Printf("Error sending notification for purchase order %d: %v\n", draft.ID, err)
 			}
 		}
 	})
@@ -66,7 +83,6 @@ func CronJob(ctx *infra.IntegrationContext) error {
 		log.Printf("%v\n", products)
 
 		for _, product := range products {
-			product.ProfitMargin = ctx.Cfg.ProfitMargin
 			err := ctx.Ctl.Revenue.Service.Revenue.SaveProductRevenue(product)
 			if err != nil {
 				log.Printf("Error saving product revenue for product %s: %v\n", product.ProductName, err)
@@ -79,6 +95,64 @@ func CronJob(ctx *infra.IntegrationContext) error {
 		return err
 	}
 
+	// Expire loyalty points past their expiry date
+	_, err = c.AddFunc("0 1 * * *", func() {
+		expired, err := ctx.Ctl.Customer.Service.Loyalty.ExpirePoints()
+		if err != nil {
+			log.Printf("Error expiring loyalty points: %v\n", err)
+			return
+		}
+		log.Printf("Expired loyalty points for %d customers\n", len(expired))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Mark reservations as no-show once their grace period has passed
+	_, err = c.AddFunc("*/5 * * * *", func() {
+		reservations, err := ctx.Ctl.Reservation.Service.Reservation.MarkNoShows()
+		if err != nil {
+			log.Printf("Error marking no-show reservations: %v\n", err)
+			return
+		}
+		for _, reservation := range reservations {
+			err := ctx.Ctl.Notif.Service.Notif.CreateNotification(model.NotifNoShow(reservation))
+			if err != nil {
+				log.Printf("Error sending no-show notification for reservation %d: %v\n", reservation.ID, err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Put everything 86'd during the day back on sale before the next
+	// service
+	_, err = c.AddFunc("0 4 * * *", func() {
+		cleared, err := ctx.Ctl.Menu.Service.Menu.ClearEightySixed()
+		if err != nil {
+			log.Printf("Error clearing 86'd products: %v\n", err)
+			return
+		}
+		log.Printf("Put %d 86'd products back on sale\n", cleared)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Purge products deleted long enough ago that were never sold
+	_, err = c.AddFunc("0 3 * * *", func() {
+		purged, err := ctx.Ctl.Revenue.Service.Product.PurgeProducts()
+		if err != nil {
+			log.Printf("Error purging deleted products: %v\n", err)
+			return
+		}
+		log.Printf("Purged %d deleted products\n", len(purged))
+	})
+	if err != nil {
+		return err
+	}
+
 	// Start the cron scheduler
 	c.Start()
 
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"errors"
+	"project_pos_app/model"
+	"time"
+
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -15,6 +19,19 @@ type Config struct {
 	Redis        Redis
 	ProfitMargin float64
 	LowStock     int
+	Storage      Storage
+	Reservation  Reservation
+	Loyalty      model.LoyaltySettings
+	// ImageMaxMB is the size limit of uploaded images in megabytes, by
+	// kind.
+	ImageMaxMB          map[string]int64
+	WaitlistHistoryDays int
+	ProductPurgeDays    int
+	PurchaseAutoDraft   bool
+	LateGrace           time.Duration
+	// MonthlyHours are the standard hours worked in a month, which turn
+	// an employee's monthly salary into an hourly rate.
+	MonthlyHours float64
 }
 
 type Database struct {
@@ -31,6 +48,37 @@ type Database struct {
 	DBMaxLifeTime  int
 }
 
+// Storage selects where uploaded images are kept: the local directory
+// Dir, an S3-compatible bucket or the legacy CDN upload API.
+type Storage struct {
+	Driver    string
+	Dir       string
+	PublicURL string
+	CDNURL    string
+	S3        S3
+}
+
+// Reservation holds the booking rules: the turnover Buffer kept free
+// between two reservations on a table, the NoShowGrace after which a late
+// party is a no-show, the RefundNotice a cancellation needs to get its
+// deposit back, and the OpeningHour and ClosingHour ("15:04") tables are
+// booked between.
+type Reservation struct {
+	Buffer       time.Duration
+	NoShowGrace  time.Duration
+	RefundNotice time.Duration
+	OpeningHour  string
+	ClosingHour  string
+}
+
+type S3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
 type Redis struct {
 	Url      string
 	Password string
@@ -49,6 +97,31 @@ func SetConfig() (Config, error) {
 	viper.SetDefault("DBUser", "postgres")
 	viper.SetDefault("DBPassword", "admin")
 	viper.SetDefault("DBName", "database")
+	viper.SetDefault("RESERVATION_BUFFER", 15)
+	viper.SetDefault("RESERVATION_NO_SHOW_GRACE", 15)
+	viper.SetDefault("DEPOSIT_REFUND_HOURS", 24)
+	viper.SetDefault("OPENING_HOUR", "10:00")
+	viper.SetDefault("CLOSING_HOUR", "22:00")
+	viper.SetDefault("WAITLIST_HISTORY_DAYS", 30)
+	viper.SetDefault("LOYALTY_EARN_UNIT", 10)
+	viper.SetDefault("LOYALTY_POINT_VALUE", 1)
+	viper.SetDefault("LOYALTY_EXPIRY_DAYS", 365)
+	viper.SetDefault("LOYALTY_SILVER_THRESHOLD", 500)
+	viper.SetDefault("LOYALTY_SILVER_DISCOUNT", 5)
+	viper.SetDefault("LOYALTY_GOLD_THRESHOLD", 2000)
+	viper.SetDefault("LOYALTY_GOLD_DISCOUNT", 10)
+	viper.SetDefault("LOW_STOCK", 10)
+	viper.SetDefault("PURCHASE_AUTO_DRAFT", true)
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_DIR", "media")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("CDN_UPLOAD_URL", "https://cdn-lumoshive-academy.vercel.app/api/v1/upload")
+	viper.SetDefault("PRODUCT_IMAGE_MAX_MB", 5)
+	viper.SetDefault("CATEGORY_ICON_MAX_MB", 1)
+	viper.SetDefault("SUPERADMIN_IMAGE_MAX_MB", 2)
+	viper.SetDefault("PRODUCT_PURGE_DAYS", 30)
+	viper.SetDefault("LATE_GRACE_MINUTES", 5)
+	viper.SetDefault("PAYROLL_MONTHLY_HOURS", 173.33)
 
 	viper.AutomaticEnv()
 
@@ -85,6 +158,54 @@ func SetConfig() (Config, error) {
 			Password: viper.GetString("REDIS_PASSWORD"),
 			Prefix:   viper.GetString("REDIS_PREFIX"),
 		},
+
+		Storage: Storage{
+			Driver:    viper.GetString("STORAGE_DRIVER"),
+			Dir:       viper.GetString("STORAGE_DIR"),
+			PublicURL: viper.GetString("STORAGE_PUBLIC_URL"),
+			CDNURL:    viper.GetString("CDN_UPLOAD_URL"),
+			S3: S3{
+				Endpoint:  viper.GetString("S3_ENDPOINT"),
+				Region:    viper.GetString("S3_REGION"),
+				Bucket:    viper.GetString("S3_BUCKET"),
+				AccessKey: viper.GetString("S3_ACCESS_KEY"),
+				SecretKey: viper.GetString("S3_SECRET_KEY"),
+			},
+		},
+
+		Reservation: Reservation{
+			Buffer:       time.Duration(viper.GetInt("RESERVATION_BUFFER")) * time.Minute,
+			NoShowGrace:  time.Duration(viper.GetInt("RESERVATION_NO_SHOW_GRACE")) * time.Minute,
+			RefundNotice: time.Duration(viper.GetInt("DEPOSIT_REFUND_HOURS")) * time.Hour,
+			OpeningHour:  viper.GetString("OPENING_HOUR"),
+			ClosingHour:  viper.GetString("CLOSING_HOUR"),
+		},
+
+		Loyalty: model.LoyaltySettings{
+			EarnUnit:   viper.GetFloat64("LOYALTY_EARN_UNIT"),
+			PointValue: viper.GetFloat64("LOYALTY_POINT_VALUE"),
+			ExpiryDays: viper.GetInt("LOYALTY_EXPIRY_DAYS"),
+			Tiers: []model.LoyaltyTier{
+				{Name: model.TierSilver, Threshold: viper.GetInt("LOYALTY_SILVER_THRESHOLD"), Discount: viper.GetFloat64("LOYALTY_SILVER_DISCOUNT")},
+				{Name: model.TierGold, Threshold: viper.GetInt("LOYALTY_GOLD_THRESHOLD"), Discount: viper.GetFloat64("LOYALTY_GOLD_DISCOUNT")},
+			},
+		},
+
+		ImageMaxMB: map[string]int64{
+			model.ImageProduct:    viper.GetInt64("PRODUCT_IMAGE_MAX_MB"),
+			model.ImageCategory:   viper.GetInt64("CATEGORY_ICON_MAX_MB"),
+			model.ImageSuperadmin: viper.GetInt64("SUPERADMIN_IMAGE_MAX_MB"),
+		},
+
+		WaitlistHistoryDays: viper.GetInt("WAITLIST_HISTORY_DAYS"),
+		ProductPurgeDays:    viper.GetInt("PRODUCT_PURGE_DAYS"),
+		PurchaseAutoDraft:   viper.GetBool("PURCHASE_AUTO_DRAFT"),
+		LateGrace:           time.Duration(viper.GetInt("LATE_GRACE_MINUTES")) * time.Minute,
+		MonthlyHours:        viper.GetFloat64("PAYROLL_MONTHLY_HOURS"),
+	}
+
+	if config.MonthlyHours <= 0 {
+		return config, errors.New("PAYROLL_MONTHLY_HOURS must be more than 0")
 	}
 
 	return config, nil
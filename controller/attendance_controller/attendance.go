@@ -0,0 +1,263 @@
+package attendancecontroller
+
+import (
+	"bytes"
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+type ControllerAttendance struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerAttendance(service *service.AllService, log *zap.Logger) ControllerAttendance {
+	return ControllerAttendance{Service: service, Log: log}
+}
+
+// @Summary Clock In
+// @Description Start the logged-in employee's time on the clock, for their rostered shift when there is one
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Success 201 {object} helper.Response{data=model.TimeEntry} "Clock In Success"
+// @Failure 404 {object} helper.Response "Not an employee"
+// @Failure 409 {object} helper.Response "Already clocked in"
+// @Router  /attendance/clock-in [post]
+func (ctrl *ControllerAttendance) ClockIn(ctx *gin.Context) {
+	data, err := ctrl.Service.Attendance.ClockIn(ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Clock In success", data)
+}
+
+// @Summary Clock Out
+// @Description End the logged-in employee's time on the clock, and their break if one is running
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=model.TimeEntry} "Clock Out Success"
+// @Failure 409 {object} helper.Response "Not clocked in"
+// @Router  /attendance/clock-out [post]
+func (ctrl *ControllerAttendance) ClockOut(ctx *gin.Context) {
+	data, err := ctrl.Service.Attendance.ClockOut(ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Clock Out success", data)
+}
+
+// @Summary Start Break
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=model.TimeEntry} "Start Break Success"
+// @Failure 409 {object} helper.Response "Not clocked in or already on break"
+// @Router  /attendance/break/start [post]
+func (ctrl *ControllerAttendance) StartBreak(ctx *gin.Context) {
+	data, err := ctrl.Service.Attendance.StartBreak(ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Start Break success", data)
+}
+
+// @Summary End Break
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=model.TimeEntry} "End Break Success"
+// @Failure 409 {object} helper.Response "Not clocked in or not on break"
+// @Router  /attendance/break/end [post]
+func (ctrl *ControllerAttendance) EndBreak(ctx *gin.Context) {
+	data, err := ctrl.Service.Attendance.EndBreak(ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "End Break success", data)
+}
+
+// @Summary Get Roster
+// @Description The shifts of the week, Monday to Sunday, a date falls in
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Param week query string false "Any date of the week (YYYY-MM-DD), this week by default"
+// @Success 200 {object} helper.Response{data=model.Roster} "Get Roster Success"
+// @Failure 400 {object} helper.Response "Invalid date"
+// @Router  /attendance/roster [get]
+func (ctrl *ControllerAttendance) Roster(ctx *gin.Context) {
+	data, err := ctrl.Service.Attendance.Roster(ctx.Query("week"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Roster success", data)
+}
+
+// @Summary Assign Shifts
+// @Description Put employees on the roster. Managers only.
+// @Tags Attendance
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormRoster true "Shifts"
+// @Success 201 {object} helper.Response{data=[]model.Shift} "Assign Shifts Success"
+// @Failure 403 {object} helper.Response "Only managers can change the roster"
+// @Failure 409 {object} helper.Response "Shift overlaps another"
+// @Router  /attendance/roster [post]
+func (ctrl *ControllerAttendance) AssignShifts(ctx *gin.Context) {
+	var form model.FormRoster
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Attendance.AssignShifts(form, ctx.GetUint("userID"), ctx.GetString("role"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Assign Shifts success", data)
+}
+
+// @Summary Remove Shift
+// @Description Take a shift off the roster, unless it was clocked in for. Managers only.
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Shift ID"
+// @Success 200 {object} helper.Response "Remove Shift Success"
+// @Failure 403 {object} helper.Response "Only managers can change the roster"
+// @Failure 404 {object} helper.Response "Shift not found"
+// @Failure 409 {object} helper.Response "Shift already clocked in for"
+// @Router  /attendance/roster/{id} [delete]
+func (ctrl *ControllerAttendance) RemoveShift(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	if err := ctrl.Service.Attendance.RemoveShift(uint(id), ctx.GetString("role")); err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Remove Shift success", nil)
+}
+
+// @Summary Attendance Report
+// @Description Each shift scheduled against actual, with lateness and leaving early beyond LATE_GRACE_MINUTES, and absences from the roster
+// @Tags Attendance
+// @Produce  json
+// @Security Authentication
+// @Param from query string false "From date (YYYY-MM-DD), this week by default"
+// @Param to query string false "To date (YYYY-MM-DD)"
+// @Param employee_id query int false "Employee ID"
+// @Success 200 {object} helper.Response{data=[]model.AttendanceRecord} "Get Attendance Success"
+// @Failure 400 {object} helper.Response "Invalid date"
+// @Router  /attendance/report [get]
+func (ctrl *ControllerAttendance) Report(ctx *gin.Context) {
+	employeeID, _ := strconv.Atoi(ctx.Query("employee_id"))
+	data, err := ctrl.Service.Attendance.Report(ctx.Query("from"), ctx.Query("to"), uint(employeeID))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Attendance success", data)
+}
+
+// @Summary Payroll Export
+// @Description Hours worked per employee, less breaks, at the hourly rate of their monthly salary over PAYROLL_MONTHLY_HOURS, as an Excel file
+// @Tags Attendance
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Authentication
+// @Param from query string false "From date (YYYY-MM-DD), this week by default"
+// @Param to query string false "To date (YYYY-MM-DD)"
+// @Success 200 {file} file "Payroll Excel file"
+// @Failure 400 {object} helper.Response "Invalid date"
+// @Router  /attendance/payroll [get]
+func (ctrl *ControllerAttendance) Payroll(ctx *gin.Context) {
+	reports, err := ctrl.Service.Attendance.Payroll(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		helper.Responses(ctx, attendanceErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			ctrl.Log.Error("Failed to close excel", zap.Error(err))
+		}
+	}()
+	val := reflect.ValueOf(model.PayrollExcel{})
+	var data [][]interface{} = [][]interface{}{}
+	var header []interface{}
+	for i := 0; i < val.NumField(); i++ {
+		header = append(header, val.Type().Field(i).Name)
+	}
+	data = append(data, header)
+	for _, report := range reports {
+		var row []interface{}
+		val := reflect.ValueOf(report)
+		for i := 0; i < val.NumField(); i++ {
+			row = append(row, val.Field(i).Interface())
+		}
+		data = append(data, row)
+	}
+	for i, rowData := range data {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		if err := f.SetSheetRow("Sheet1", cell, &rowData); err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	filename := "Payroll_Report.xlsx"
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
+
+func attendanceErrorStatus(err error) int {
+	switch err.Error() {
+	case " Not An Employee", " Employee Not Found", " Shift Not Found":
+		return http.StatusNotFound
+	case " Invalid Date":
+		return http.StatusBadRequest
+	case " Only Managers Can Change The Roster":
+		return http.StatusForbidden
+	case " Already Clocked In", " Not Clocked In", " Already On Break", " Not On Break",
+		" Shift Overlaps Another", " Shift Already Clocked In For":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,81 @@
+package bundlecontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerBundle struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerBundle(service *service.AllService, log *zap.Logger) ControllerBundle {
+	return ControllerBundle{Service: service, Log: log}
+}
+
+// @Summary Get Product Bundle
+// @Description Components of a bundle product, its choice groups and how many can be made from stock
+// @Tags Bundle
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Success 200 {object} helper.Response{data=model.Bundle} "Get Bundle Success"
+// @Failure 404 {object} helper.Response "Product not found"
+// @Router  /product/{id}/bundle [get]
+func (ctrl *ControllerBundle) GetBundle(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Bundle.GetBundle(uint(id))
+	if err != nil {
+		helper.Responses(ctx, bundleErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Bundle success", data)
+}
+
+// @Summary Save Product Bundle
+// @Description Replace a bundle's components. Items sharing a group are options the customer picks one of when ordering; selling the bundle deducts the components' stock. An empty list makes it a single product again.
+// @Tags Bundle
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param request body model.FormBundle true "Bundle"
+// @Success 200 {object} helper.Response{data=model.Bundle} "Save Bundle Success"
+// @Failure 400 {object} helper.Response "Invalid request, nested bundle, component of a bundle or product with a recipe"
+// @Failure 404 {object} helper.Response "Product not found"
+// @Router  /product/{id}/bundle [put]
+func (ctrl *ControllerBundle) SaveBundle(ctx *gin.Context) {
+	var form model.FormBundle
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Bundle.SaveBundle(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, bundleErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Save Bundle success", data)
+}
+
+func bundleErrorStatus(err error) int {
+	switch err.Error() {
+	case " Product Not Found":
+		return http.StatusNotFound
+	case " A Bundle Cannot Contain Itself Or Another Bundle", " A Product With A Recipe Cannot Be A Bundle", " A Component Of A Bundle Cannot Be A Bundle":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"project_pos_app/model"
 	"project_pos_app/service"
 	"strconv"
-	"sync"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -103,8 +102,10 @@ func (cc *CategoryController) GetCategoryByID(c *gin.Context) {
 // @Param name formData string true "Category Name"
 // @Param description formData string true "Category Description"
 // @Param icon formData file true "Category Icon"
+// @Param points_multiplier formData number false "Loyalty points multiplier" default(1)
+// @Param parent_id formData int false "Parent category ID, for a subcategory"
 // @Success 201 {object} model.SuccessResponse{data=model.Category} "Category created successfully"
-// @Failure 400 {object} model.ErrorResponse "Invalid category data"
+// @Failure 400 {object} model.ErrorResponse "Invalid category data or parent"
 // @Failure 500 {object} model.ErrorResponse "Failed to create category"
 // @Router /category [post]
 func (cc *CategoryController) CreateCategory(c *gin.Context) {
@@ -126,12 +127,11 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// Menggunakan goroutine untuk upload gambar
-	var wg sync.WaitGroup
-	responses, err := helper.Upload(&wg, files)
+	// Menyimpan icon ke storage
+	icon, err := cc.service.Media.UploadImage(c.Request.Context(), model.ImageCategory, files[0])
 	if err != nil {
 		cc.log.Error("Failed to upload icon", zap.Error(err))
-		helper.Responses(c, http.StatusInternalServerError, "Failed to upload icon", nil)
+		helper.Responses(c, helper.UploadStatus(err), "Failed to upload icon:"+err.Error(), nil)
 		return
 	}
 
@@ -139,15 +139,23 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 	name := c.PostForm("name")
 	description := c.PostForm("description")
 	category := model.Category{
-		IconURL:     responses[0].Data.Url,
-		Name:        name,
-		Description: description,
+		IconURL:      icon.Medium,
+		IconVariants: *icon,
+		Name:         name,
+		Description:  description,
+	}
+	if multiplier, err := strconv.ParseFloat(c.PostForm("points_multiplier"), 64); err == nil && multiplier > 0 {
+		category.PointsMultiplier = multiplier
+	}
+	if parentID, err := strconv.ParseUint(c.PostForm("parent_id"), 10, 0); err == nil && parentID > 0 {
+		parent := uint(parentID)
+		category.ParentID = &parent
 	}
 
 	// Membuat category di database
 	if err := cc.service.Category.CreateCategory(&category); err != nil {
 		cc.log.Error("Failed to create category", zap.Error(err))
-		helper.Responses(c, http.StatusInternalServerError, "Failed to create category", nil)
+		helper.Responses(c, categoryErrorStatus(err), "Failed to create category:"+err.Error(), nil)
 		return
 	}
 
@@ -167,8 +175,10 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 // @Param name formData string false "Category Name"
 // @Param description formData string false "Category Description"
 // @Param icon formData file false "Category Icon"
+// @Param points_multiplier formData number false "Loyalty points multiplier"
+// @Param parent_id formData int false "Parent category ID, 0 to move it to the top level"
 // @Success 200 {object} model.SuccessResponse{data=model.Category} "Category updated successfully"
-// @Failure 400 {object} model.ErrorResponse "Invalid category ID or data"
+// @Failure 400 {object} model.ErrorResponse "Invalid category ID, data or parent"
 // @Failure 404 {object} model.ErrorResponse "Category not found"
 // @Failure 500 {object} model.ErrorResponse "Failed to update category"
 // @Router /category/{id} [put]
@@ -199,15 +209,15 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	// Update file if provided
 	files := form.File["icon"]
 	if len(files) > 0 {
-		var wg sync.WaitGroup
-		responses, err := helper.Upload(&wg, files)
+		icon, err := cc.service.Media.UploadImage(c.Request.Context(), model.ImageCategory, files[0])
 		if err != nil {
 			cc.log.Error("Failed to upload icon", zap.Error(err))
-			helper.Responses(c, http.StatusInternalServerError, "Failed to upload icon", nil)
+			helper.Responses(c, helper.UploadStatus(err), "Failed to upload icon:"+err.Error(), nil)
 			return
 		}
-		category.IconURL = responses[0].Data.Url
-		cc.log.Info("Icon updated successfully", zap.String("iconURL", responses[0].Data.Url))
+		category.IconURL = icon.Medium
+		category.IconVariants = *icon
+		cc.log.Info("Icon updated successfully", zap.String("iconURL", icon.Medium))
 	}
 
 	// Update other fields if provided
@@ -217,14 +227,129 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	if description := c.PostForm("description"); description != "" {
 		category.Description = description
 	}
+	if multiplier, err := strconv.ParseFloat(c.PostForm("points_multiplier"), 64); err == nil && multiplier > 0 {
+		category.PointsMultiplier = multiplier
+	}
+	if value, ok := c.GetPostForm("parent_id"); ok {
+		parentID, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			helper.Responses(c, http.StatusBadRequest, "Invalid parent category ID", nil)
+			return
+		}
+		category.ParentID = nil
+		if parentID > 0 {
+			parent := uint(parentID)
+			category.ParentID = &parent
+		}
+	}
 
 	// Save updates to database
 	if err := cc.service.Category.UpdateCategory(category.ID, category); err != nil {
 		cc.log.Error("Failed to update category", zap.Error(err))
-		helper.Responses(c, http.StatusInternalServerError, "Failed to update category", nil)
+		helper.Responses(c, categoryErrorStatus(err), "Failed to update category:"+err.Error(), nil)
 		return
 	}
 
 	cc.log.Info("Category updated successfully", zap.String("categoryName", category.Name))
 	helper.Responses(c, http.StatusOK, "Category updated successfully", category)
 }
+
+// GetCategoryTree godoc
+// @Summary Get the category tree
+// @Description Get every category with its subcategories nested under it, each level in display order
+// @Tags Categories
+// @Produce json
+// @Security Authentication
+// @Success 200 {object} model.SuccessResponse{data=[]model.Category} "Category tree retrieved successfully"
+// @Failure 500 {object} model.ErrorResponse "Failed to fetch categories"
+// @Router /category/tree [get]
+func (cc *CategoryController) GetCategoryTree(c *gin.Context) {
+	tree, err := cc.service.Category.CategoryTree()
+	if err != nil {
+		cc.log.Error("Failed to fetch category tree", zap.Error(err))
+		helper.Responses(c, http.StatusInternalServerError, "Failed to fetch categories", nil)
+		return
+	}
+	helper.Responses(c, http.StatusOK, "Category tree retrieved successfully", tree)
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Soft delete a category. One that products or subcategories still belong to is only deleted when reassign_to names the category to move them to.
+// @Tags Categories
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Category ID"
+// @Param reassign_to query int false "Category to move the products and subcategories to"
+// @Success 200 {object} model.SuccessResponse "Category deleted successfully"
+// @Failure 400 {object} model.ErrorResponse "Invalid category ID or category to reassign to"
+// @Failure 404 {object} model.ErrorResponse "Category not found"
+// @Failure 409 {object} model.ErrorResponse "Category still has products or subcategories"
+// @Router /category/{id} [delete]
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		cc.log.Error("Invalid category ID", zap.Error(err))
+		helper.Responses(c, http.StatusBadRequest, "Invalid category ID", nil)
+		return
+	}
+
+	var reassignTo *uint
+	if value := c.Query("reassign_to"); value != "" {
+		target, err := strconv.ParseUint(value, 10, 0)
+		if err != nil || target < 1 {
+			helper.Responses(c, http.StatusBadRequest, "Invalid category to reassign to", nil)
+			return
+		}
+		category := uint(target)
+		reassignTo = &category
+	}
+
+	if err := cc.service.Category.DeleteCategory(uint(id), reassignTo); err != nil {
+		cc.log.Error("Failed to delete category", zap.Error(err))
+		helper.Responses(c, categoryErrorStatus(err), "Failed to delete category:"+err.Error(), nil)
+		return
+	}
+
+	cc.log.Info("Category deleted successfully", zap.Int("categoryID", id))
+	helper.Responses(c, http.StatusOK, "Category deleted successfully", nil)
+}
+
+// ReorderCategories godoc
+// @Summary Reorder categories
+// @Description Set the display order of the subcategories of parent_id, or of the top level categories when it is empty. ids must list each of them once.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param request body model.FormCategoryReorder true "New order"
+// @Success 200 {object} model.SuccessResponse "Categories reordered successfully"
+// @Failure 400 {object} model.ErrorResponse "Ids do not match the sibling categories"
+// @Router /category/reorder [put]
+func (cc *CategoryController) ReorderCategories(c *gin.Context) {
+	var form model.FormCategoryReorder
+	if err := c.ShouldBindJSON(&form); err != nil {
+		helper.Responses(c, http.StatusBadRequest, "Invalid Request", nil)
+		return
+	}
+
+	if err := cc.service.Category.ReorderCategories(form); err != nil {
+		cc.log.Error("Failed to reorder categories", zap.Error(err))
+		helper.Responses(c, categoryErrorStatus(err), "Failed to reorder categories:"+err.Error(), nil)
+		return
+	}
+	helper.Responses(c, http.StatusOK, "Categories reordered successfully", nil)
+}
+
+func categoryErrorStatus(err error) int {
+	switch err.Error() {
+	case " Category Not Found":
+		return http.StatusNotFound
+	case " Invalid Parent Category", " Invalid Category To Reassign To", " Reorder Must List Every Sibling Category Once":
+		return http.StatusBadRequest
+	case " Category Still Has Products Or Subcategories":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,14 +1,23 @@
 package controller
 
 import (
+	attendancecontroller "project_pos_app/controller/attendance_controller"
 	authcontroller "project_pos_app/controller/auth_controller"
+	bundlecontroller "project_pos_app/controller/bundle_controller"
 	categorycontroller "project_pos_app/controller/category_controller"
+	customercontroller "project_pos_app/controller/customer_controller"
 	dashboardcontroller "project_pos_app/controller/dashboard_controller"
+	drawercontroller "project_pos_app/controller/drawer_controller"
+	ingredientcontroller "project_pos_app/controller/ingredient_controller"
+	menucontroller "project_pos_app/controller/menu_controller"
 	notifcontroller "project_pos_app/controller/notif_controller"
 	productcontroller "project_pos_app/controller/product_controller"
+	purchasecontroller "project_pos_app/controller/purchase_controller"
 	reservationcontroller "project_pos_app/controller/reservation_controller"
 	revenuecontroller "project_pos_app/controller/revenue_controller"
+	stocktakecontroller "project_pos_app/controller/stocktake_controller"
 	superadmincontroller "project_pos_app/controller/superadmin_controller"
+	waitlistcontroller "project_pos_app/controller/waitlist_controller"
 
 	// productcontroller "project_pos_app/controller/product_controller"
 	ordercontroller "project_pos_app/controller/order_controller"
@@ -28,6 +37,15 @@ type AllController struct {
 	Category    categorycontroller.CategoryController
 	Reservation reservationcontroller.ControllerReservation
 	Dashboard   dashboardcontroller.ControllerDashboard
+	Waitlist    waitlistcontroller.ControllerWaitlist
+	Customer    customercontroller.ControllerCustomer
+	Purchase    purchasecontroller.ControllerPurchase
+	Ingredient  ingredientcontroller.ControllerIngredient
+	Stocktake   stocktakecontroller.ControllerStocktake
+	Menu        menucontroller.ControllerMenu
+	Bundle      bundlecontroller.ControllerBundle
+	Drawer      drawercontroller.ControllerDrawer
+	Attendance  attendancecontroller.ControllerAttendance
 }
 
 func NewAllController(service *service.AllService, log *zap.Logger, cfg *database.Cache) AllController {
@@ -41,5 +59,14 @@ func NewAllController(service *service.AllService, log *zap.Logger, cfg *databas
 		Category:    *categorycontroller.NewCategoryController(service, log),
 		Reservation: reservationcontroller.NewControllerReservation(service, log),
 		Dashboard:   dashboardcontroller.NewControllerDashboard(service, log),
+		Waitlist:    waitlistcontroller.NewControllerWaitlist(service, log),
+		Customer:    customercontroller.NewControllerCustomer(service, log),
+		Purchase:    purchasecontroller.NewControllerPurchase(service, log),
+		Ingredient:  ingredientcontroller.NewControllerIngredient(service, log),
+		Stocktake:   stocktakecontroller.NewControllerStocktake(service, log),
+		Menu:        menucontroller.NewControllerMenu(service, log),
+		Bundle:      bundlecontroller.NewControllerBundle(service, log),
+		Drawer:      drawercontroller.NewControllerDrawer(service, log),
+		Attendance:  attendancecontroller.NewControllerAttendance(service, log),
 	}
 }
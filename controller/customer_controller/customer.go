@@ -0,0 +1,102 @@
+package customercontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerCustomer struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerCustomer(service *service.AllService, log *zap.Logger) ControllerCustomer {
+	return ControllerCustomer{Service: service, Log: log}
+}
+
+// @Summary Get All Customers
+// @Description Customers matching a name, phone number or email, paginated like the product listing
+// @Tags Customer
+// @Produce  json
+// @Security Authentication
+// @Param search query string false "Name, phone number or email"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.Customer} "Get Customers Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /customers [get]
+func (ctrl *ControllerCustomer) GetAll(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	data, total, totalPages, err := ctrl.Service.Customer.GetAll(strings.TrimSpace(ctx.Query("search")), page, limit)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Customer Profile
+// @Description Customer with lifetime spend, visit count, last visit and favourite products from completed orders
+// @Tags Customer
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Customer ID"
+// @Success 200 {object} helper.Response{data=model.CustomerProfile} "Get Customer Success"
+// @Failure 404 {object} helper.Response "Customer not found"
+// @Router  /customers/{id} [get]
+func (ctrl *ControllerCustomer) GetProfile(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Customer.GetProfile(uint(id))
+	if err != nil {
+		helper.Responses(ctx, customerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Customer success", data)
+}
+
+// @Summary Get Customer Orders
+// @Description Order history of a customer, newest first
+// @Tags Customer
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Customer ID"
+// @Success 200 {object} helper.Response{data=[]model.OrderResponse} "Get Customer Orders Success"
+// @Failure 404 {object} helper.Response "Customer not found"
+// @Router  /customers/{id}/orders [get]
+func (ctrl *ControllerCustomer) GetOrders(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Customer.GetOrders(uint(id))
+	if err != nil {
+		helper.Responses(ctx, customerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Customer Orders success", data)
+}
+
+func customerErrorStatus(err error) int {
+	if err.Error() == " Customer Not Found" {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
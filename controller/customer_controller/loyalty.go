@@ -0,0 +1,62 @@
+package customercontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get Customer Points
+// @Description Points balance, tier and full points ledger of a customer
+// @Tags Customer
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Customer ID"
+// @Success 200 {object} helper.Response{data=model.LoyaltyAccount} "Get Points Success"
+// @Failure 404 {object} helper.Response "Customer not found"
+// @Router  /customers/{id}/points [get]
+func (ctrl *ControllerCustomer) GetPoints(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Loyalty.GetAccount(uint(id))
+	if err != nil {
+		helper.Responses(ctx, customerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Points success", data)
+}
+
+// @Summary Adjust Customer Points
+// @Description Add (positive) or remove (negative) points by hand, with a reason kept in the ledger
+// @Tags Customer
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Customer ID"
+// @Param request body model.FormPointsAdjust true "Adjustment"
+// @Success 201 {object} helper.Response{data=model.LoyaltyLedger} "Adjust Points Success"
+// @Failure 400 {object} helper.Response "Invalid request or insufficient points"
+// @Router  /customers/{id}/points/adjust [post]
+func (ctrl *ControllerCustomer) AdjustPoints(ctx *gin.Context) {
+	var form model.FormPointsAdjust
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Loyalty.Adjust(uint(id), form)
+	if err != nil {
+		status := customerErrorStatus(err)
+		if status == http.StatusInternalServerError && err.Error() != " Internal Server Error" {
+			status = http.StatusBadRequest
+		}
+		helper.Responses(ctx, status, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Adjust Points success", data)
+}
@@ -149,3 +149,96 @@ func (ctrl *ControllerDashboard) GetReport(ctx *gin.Context) {
 	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
 	// helper.Responses(ctx, http.StatusOK, "Get Report success", nil)
 }
+
+// @Summary Get Staff Sales
+// @Description Sales per staff member, average ticket, items per order, voids and discounts, from the orders they took and served
+// @Tags Dashboard
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param from query string false "From date (YYYY-MM-DD), this month by default"
+// @Param to query string false "To date (YYYY-MM-DD)"
+// @Success 200 {object} helper.Response{data=[]model.StaffSales} "Get Staff Sales Success"
+// @Failure 400 {object} helper.Response "Invalid date"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /api/dashboard/staff [get]
+func (ctrl *ControllerDashboard) GetStaffSales(ctx *gin.Context) {
+	data, err := ctrl.Service.Dashboard.GetStaffSales(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		helper.Responses(ctx, staffSalesErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Staff Sales success", data)
+}
+
+// @Summary Staff Sales Report
+// @Description The staff sales of GET /api/dashboard/staff as an Excel file
+// @Tags Dashboard
+// @Accept  json
+// @Security Authentication
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param from query string false "From date (YYYY-MM-DD), this month by default"
+// @Param to query string false "To date (YYYY-MM-DD)"
+// @Success 200 {file} file "Staff_Sales_Report.xlsx"
+// @Failure 400 {object} helper.Response "Invalid date"
+// @Failure 500 {object} helper.Response "server error"
+// @Router /api/dashboard/staff/report [get]
+func (ctrl *ControllerDashboard) GetStaffSalesReport(ctx *gin.Context) {
+	staff, err := ctrl.Service.Dashboard.GetStaffSales(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		helper.Responses(ctx, staffSalesErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			ctrl.Log.Error("Failed to close excel", zap.Error(err))
+		}
+	}()
+	val := reflect.ValueOf(model.StaffSales{})
+	var data [][]interface{} = [][]interface{}{}
+	header := []interface{}{"No"}
+	for i := 0; i < val.NumField(); i++ {
+		header = append(header, val.Type().Field(i).Name)
+	}
+	data = append(data, header)
+	for i, member := range staff {
+		row := []interface{}{i + 1}
+		val := reflect.ValueOf(member)
+		for i := 0; i < val.NumField(); i++ {
+			row = append(row, val.Field(i).Interface())
+		}
+		data = append(data, row)
+	}
+	for i, rowData := range data {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		if err := f.SetSheetRow("Sheet1", cell, &rowData); err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", "attachment; filename=Staff_Sales_Report.xlsx")
+	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
+
+func staffSalesErrorStatus(err error) int {
+	if err.Error() == " Invalid Date" {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,190 @@
+package drawercontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerDrawer struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerDrawer(service *service.AllService, log *zap.Logger) ControllerDrawer {
+	return ControllerDrawer{Service: service, Log: log}
+}
+
+// @Summary Get All Drawer Sessions
+// @Description Till shifts, newest first, paginated like the product listing
+// @Tags Drawer
+// @Produce  json
+// @Security Authentication
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.DrawerSession} "Get Drawer Sessions Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /drawer/sessions [get]
+func (ctrl *ControllerDrawer) GetAll(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	data, total, totalPages, err := ctrl.Service.Drawer.GetAll(page, limit)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Current Drawer (X Report)
+// @Description The open session's paid in and out, sales and expected amount per tender, and its orders still to settle, without closing it
+// @Tags Drawer
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=model.DrawerReport} "Get X Report Success"
+// @Failure 404 {object} helper.Response "No open drawer session"
+// @Router  /drawer/current [get]
+func (ctrl *ControllerDrawer) Current(ctx *gin.Context) {
+	data, err := ctrl.Service.Drawer.Current()
+	if err != nil {
+		status := drawerErrorStatus(err)
+		if status == http.StatusConflict {
+			status = http.StatusNotFound
+		}
+		helper.Responses(ctx, status, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get X Report success", data)
+}
+
+// @Summary Get Drawer Session Report
+// @Description The Z report a session was closed with, or an X report while it is open
+// @Tags Drawer
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Drawer Session ID"
+// @Success 200 {object} helper.Response{data=model.DrawerReport} "Get Report Success"
+// @Failure 404 {object} helper.Response "Drawer session not found"
+// @Router  /drawer/sessions/{id}/report [get]
+func (ctrl *ControllerDrawer) Report(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Drawer.Report(uint(id))
+	if err != nil {
+		helper.Responses(ctx, drawerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Report success", data)
+}
+
+// @Summary Open Drawer
+// @Description Start a till shift with the float in the drawer. Orders taken and settled while it is open belong to it.
+// @Tags Drawer
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormOpenDrawer true "Opening float"
+// @Success 201 {object} helper.Response{data=model.DrawerSession} "Open Drawer Success"
+// @Failure 409 {object} helper.Response "A drawer session is already open"
+// @Router  /drawer/open [post]
+func (ctrl *ControllerDrawer) Open(ctx *gin.Context) {
+	var form model.FormOpenDrawer
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Drawer.Open(form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, drawerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Open Drawer success", data)
+}
+
+// @Summary Record Paid In Or Out
+// @Description Cash put into or taken out of the open drawer other than for an order
+// @Tags Drawer
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormDrawerMovement true "paid_in or paid_out"
+// @Success 201 {object} helper.Response{data=model.DrawerMovement} "Record Movement Success"
+// @Failure 409 {object} helper.Response "No open drawer session"
+// @Router  /drawer/movements [post]
+func (ctrl *ControllerDrawer) Move(ctx *gin.Context) {
+	var form model.FormDrawerMovement
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Drawer.Move(form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, drawerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Record Movement success", data)
+}
+
+// @Summary Close Drawer (Z Report)
+// @Description Count the drawer and close the open session, returning expected against counted for each tender. Tenders not counted are taken to match.
+// @Tags Drawer
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormCloseDrawer true "Counted amounts"
+// @Success 200 {object} helper.Response{data=model.DrawerReport} "Close Drawer Success"
+// @Failure 409 {object} helper.Response "No open drawer session, or orders still to settle"
+// @Router  /drawer/close [post]
+func (ctrl *ControllerDrawer) Close(ctx *gin.Context) {
+	var form model.FormCloseDrawer
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Drawer.Close(form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, drawerErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Close Drawer success", data)
+}
+
+func pagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func drawerErrorStatus(err error) int {
+	switch err.Error() {
+	case " Drawer Session Not Found":
+		return http.StatusNotFound
+	case " No Open Drawer Session", " A Drawer Session Is Already Open", " Drawer Has Unsettled Orders":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
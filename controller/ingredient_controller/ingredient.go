@@ -0,0 +1,250 @@
+package ingredientcontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerIngredient struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerIngredient(service *service.AllService, log *zap.Logger) ControllerIngredient {
+	return ControllerIngredient{Service: service, Log: log}
+}
+
+// @Summary Get All Ingredients
+// @Description Ingredients matching a name with their stock, paginated like the product listing
+// @Tags Ingredient
+// @Produce  json
+// @Security Authentication
+// @Param search query string false "Name"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.Ingredient} "Get Ingredients Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /ingredients [get]
+func (ctrl *ControllerIngredient) GetAll(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	data, total, totalPages, err := ctrl.Service.Ingredient.GetAll(strings.TrimSpace(ctx.Query("search")), page, limit)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Ingredient
+// @Tags Ingredient
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Ingredient ID"
+// @Success 200 {object} helper.Response{data=model.Ingredient} "Get Ingredient Success"
+// @Failure 404 {object} helper.Response "Ingredient not found"
+// @Router  /ingredients/{id} [get]
+func (ctrl *ControllerIngredient) Get(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Ingredient.Get(uint(id))
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Ingredient success", data)
+}
+
+// @Summary Create Ingredient
+// @Description Add a raw ingredient stocked in mg, g, kg, ml, l or pcs; stock starts at zero and is restocked through its ledger
+// @Tags Ingredient
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormIngredient true "Ingredient"
+// @Success 201 {object} helper.Response{data=model.Ingredient} "Create Ingredient Success"
+// @Failure 400 {object} helper.Response "Invalid request or unknown unit"
+// @Router  /ingredients [post]
+func (ctrl *ControllerIngredient) Create(ctx *gin.Context) {
+	var form model.FormIngredient
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Ingredient.Create(form)
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Create Ingredient success", data)
+}
+
+// @Summary Update Ingredient
+// @Description Change an ingredient's name, cost per unit and reorder level; its unit cannot change
+// @Tags Ingredient
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Ingredient ID"
+// @Param request body model.FormIngredient true "Ingredient"
+// @Success 200 {object} helper.Response{data=model.Ingredient} "Update Ingredient Success"
+// @Failure 404 {object} helper.Response "Ingredient not found"
+// @Router  /ingredients/{id} [put]
+func (ctrl *ControllerIngredient) Update(ctx *gin.Context) {
+	var form model.FormIngredient
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Ingredient.Update(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Update Ingredient success", data)
+}
+
+// @Summary Get Ingredient Stock History
+// @Description Stock movements of an ingredient, newest first, with the balance after each
+// @Tags Ingredient
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Ingredient ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.IngredientMovement} "Get Stock History Success"
+// @Failure 404 {object} helper.Response "Ingredient not found"
+// @Router  /ingredients/{id}/stock-history [get]
+func (ctrl *ControllerIngredient) GetStockHistory(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	page, limit := pagination(ctx)
+	data, total, totalPages, err := ctrl.Service.Ingredient.StockHistory(uint(id), page, limit)
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Record Ingredient Stock Movement
+// @Description Restock, adjust or write off an ingredient, with a reason; products made with it are recounted
+// @Tags Ingredient
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Ingredient ID"
+// @Param request body model.FormIngredientMovement true "Stock movement"
+// @Success 201 {object} helper.Response{data=model.IngredientMovement} "Record Stock Movement Success"
+// @Failure 400 {object} helper.Response "Invalid movement or insufficient stock"
+// @Router  /ingredients/{id}/stock [post]
+func (ctrl *ControllerIngredient) RecordStockMovement(ctx *gin.Context) {
+	var form model.FormIngredientMovement
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Ingredient.RecordMovement(uint(id), form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Record Stock Movement success", data)
+}
+
+// @Summary Get Product Recipe
+// @Description Ingredients that go into one unit of a product, its food cost and how many can be made from stock
+// @Tags Ingredient
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Success 200 {object} helper.Response{data=model.Recipe} "Get Recipe Success"
+// @Failure 404 {object} helper.Response "Product not found"
+// @Router  /product/{id}/recipe [get]
+func (ctrl *ControllerIngredient) GetRecipe(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Ingredient.GetRecipe(uint(id))
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Recipe success", data)
+}
+
+// @Summary Save Product Recipe
+// @Description Replace a product's recipe; selling it then deducts the ingredients instead of the product's own stock. An empty list removes the recipe.
+// @Tags Ingredient
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param request body model.FormRecipe true "Recipe"
+// @Success 200 {object} helper.Response{data=model.Recipe} "Save Recipe Success"
+// @Failure 400 {object} helper.Response "Invalid request or incompatible unit"
+// @Failure 404 {object} helper.Response "Product or ingredient not found"
+// @Router  /product/{id}/recipe [put]
+func (ctrl *ControllerIngredient) SaveRecipe(ctx *gin.Context) {
+	var form model.FormRecipe
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Ingredient.SaveRecipe(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, ingredientErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Save Recipe success", data)
+}
+
+func pagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func ingredientErrorStatus(err error) int {
+	switch err.Error() {
+	case " Ingredient Not Found", " Product Not Found":
+		return http.StatusNotFound
+	case " Incompatible Unit", " Insufficient Stock", " Bad Request", " A Bundle Cannot Have A Recipe":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
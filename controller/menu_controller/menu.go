@@ -0,0 +1,189 @@
+package menucontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerMenu struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerMenu(service *service.AllService, log *zap.Logger) ControllerMenu {
+	return ControllerMenu{Service: service, Log: log}
+}
+
+// @Summary Get All Menus
+// @Description Every menu with its schedules, categories and products
+// @Tags Menu
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=[]model.Menu} "Get Menus Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /menu [get]
+func (ctrl *ControllerMenu) GetAll(ctx *gin.Context) {
+	data, err := ctrl.Service.Menu.GetAll()
+	if err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Menus success", data)
+}
+
+// @Summary Get Current Menu
+// @Description The menus open now and the products that can be sold now: on sale, not 86'd, and on an open menu or on none
+// @Tags Menu
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=model.CurrentMenu} "Get Current Menu Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /menu/current [get]
+func (ctrl *ControllerMenu) Current(ctx *gin.Context) {
+	data, err := ctrl.Service.Menu.Current(time.Now())
+	if err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Current Menu success", data)
+}
+
+// @Summary Get Menu
+// @Tags Menu
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Menu ID"
+// @Success 200 {object} helper.Response{data=model.Menu} "Get Menu Success"
+// @Failure 404 {object} helper.Response "Menu not found"
+// @Router  /menu/{id} [get]
+func (ctrl *ControllerMenu) Get(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Menu.Get(uint(id))
+	if err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Menu success", data)
+}
+
+// @Summary Create Menu
+// @Description A named menu sold during its schedules, each a start and end time ("15:04") on the given days (sun to sat), or every day. An end before the start runs past midnight.
+// @Tags Menu
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormMenu true "Menu"
+// @Success 201 {object} helper.Response{data=model.Menu} "Create Menu Success"
+// @Failure 400 {object} helper.Response "Invalid request or unknown category or product"
+// @Router  /menu [post]
+func (ctrl *ControllerMenu) Create(ctx *gin.Context) {
+	var form model.FormMenu
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Menu.Create(form)
+	if err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Create Menu success", data)
+}
+
+// @Summary Update Menu
+// @Description Replace a menu's name, schedules, categories and products
+// @Tags Menu
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Menu ID"
+// @Param request body model.FormMenu true "Menu"
+// @Success 200 {object} helper.Response{data=model.Menu} "Update Menu Success"
+// @Failure 400 {object} helper.Response "Invalid request or unknown category or product"
+// @Failure 404 {object} helper.Response "Menu not found"
+// @Router  /menu/{id} [put]
+func (ctrl *ControllerMenu) Update(ctx *gin.Context) {
+	var form model.FormMenu
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Menu.Update(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Update Menu success", data)
+}
+
+// @Summary Delete Menu
+// @Description Remove a menu. Its products stay on their other menus, or are sold at any time when on none.
+// @Tags Menu
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Menu ID"
+// @Success 200 {object} helper.Response "Delete Menu Success"
+// @Failure 404 {object} helper.Response "Menu not found"
+// @Router  /menu/{id} [delete]
+func (ctrl *ControllerMenu) Delete(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	if err := ctrl.Service.Menu.Delete(uint(id)); err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Delete Menu success", nil)
+}
+
+// @Summary 86 Product
+// @Description Take a product off sale for the rest of the service because it ran out, or put it back. Everything 86'd comes back on sale overnight.
+// @Tags Menu
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param productId path int true "Product ID"
+// @Param request body model.FormEightySix true "86 status"
+// @Success 200 {object} helper.Response "86 Product Success"
+// @Failure 404 {object} helper.Response "Product not found"
+// @Router  /menu/86/{productId} [put]
+func (ctrl *ControllerMenu) EightySix(ctx *gin.Context) {
+	var form model.FormEightySix
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("productId"))
+	if err := ctrl.Service.Menu.EightySix(uint(id), *form.EightySixed); err != nil {
+		helper.Responses(ctx, menuErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "86 Product success", nil)
+}
+
+func menuErrorStatus(err error) int {
+	switch err.Error() {
+	case " Menu Not Found", " Product Not Found":
+		return http.StatusNotFound
+	case " Menu Lists An Unknown Category Or Product":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
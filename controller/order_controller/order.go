@@ -56,7 +56,7 @@ func (oc *orderController) GetAllOrder(c *gin.Context) {
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order with associated products
+// @Description Create a new order with associated products, each given by product_id or by a scanned EAN-13 or UPC-A barcode. Items that are 86'd or outside their menu's schedule are rejected unless a manager sets override_menu.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -64,6 +64,7 @@ func (oc *orderController) GetAllOrder(c *gin.Context) {
 // @Param order body model.Order true "Order payload"
 // @Success 201 {object} model.SuccessResponse "Order successfully created"
 // @Failure 400 {object} model.ErrorResponse "Failed to create order"
+// @Failure 403 {object} model.ErrorResponse "Only managers can override the menu"
 // @Failure 500 {object} model.ErrorResponse "Invalid input"
 // @Router /order [post]
 func (oc *orderController) CreateOrder(c *gin.Context) {
@@ -74,9 +75,15 @@ func (oc *orderController) CreateOrder(c *gin.Context) {
 		helper.Responses(c, http.StatusInternalServerError, "Invalid Input: "+err.Error(), nil)
 		return
 	}
+	order.ActorID = c.GetUint("userID")
+	order.ActorRole = c.GetString("role")
 
 	if err := oc.service.Order.CreateOrder(&order); err != nil {
-		helper.Responses(c, http.StatusBadRequest, "failed to create order: "+err.Error(), nil)
+		status := http.StatusBadRequest
+		if err.Error() == " Only Managers Can Override The Menu" {
+			status = http.StatusForbidden
+		}
+		helper.Responses(c, status, "failed to create order: "+err.Error(), nil)
 		return
 	}
 
@@ -85,7 +92,7 @@ func (oc *orderController) CreateOrder(c *gin.Context) {
 
 // UpdateOrder godoc
 // @Summary Update an existing order
-// @Description Update the details of an order by its ID
+// @Description Update the details of an order by its ID. Items added that are 86'd or outside their menu's schedule are rejected unless a manager sets override_menu.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -94,6 +101,7 @@ func (oc *orderController) CreateOrder(c *gin.Context) {
 // @Param order body model.Order true "Updated order payload"
 // @Success 200 {object} model.SuccessResponse "Order successfully updated"
 // @Failure 400 {object} model.ErrorResponse "Failed to update order"
+// @Failure 403 {object} model.ErrorResponse "Only managers can override the menu"
 // @Failure 500 {object} model.ErrorResponse "Invalid input"
 // @Router /order/{id} [put]
 func (oc *orderController) UpdateOrder(c *gin.Context) {
@@ -105,9 +113,15 @@ func (oc *orderController) UpdateOrder(c *gin.Context) {
 		helper.Responses(c, http.StatusInternalServerError, "Invalid Input: "+err.Error(), nil)
 		return
 	}
+	order.ActorID = c.GetUint("userID")
+	order.ActorRole = c.GetString("role")
 
 	if err := oc.service.Order.UpdateOrder(id, &order); err != nil {
-		helper.Responses(c, http.StatusBadRequest, "failed to update order: "+err.Error(), nil)
+		status := http.StatusBadRequest
+		if err.Error() == " Only Managers Can Override The Menu" {
+			status = http.StatusForbidden
+		}
+		helper.Responses(c, status, "failed to update order: "+err.Error(), nil)
 		return
 	}
 
@@ -1,12 +1,19 @@
 package productcontroller
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
+	"path/filepath"
+	"project_pos_app/barcode"
 	"project_pos_app/helper"
 	"project_pos_app/model"
+	productrepository "project_pos_app/repository/product"
+	stockrepository "project_pos_app/repository/stock_repository"
 	"project_pos_app/service"
+	productservice "project_pos_app/service/product_service"
 	"strconv"
-	"sync"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,28 +33,68 @@ func NewProductController(service *service.AllService, log *zap.Logger) *Product
 
 // GetAllCategory godoc
 // @Summary Get all products
-// @Description Get a list of products with optional pagination
+// @Description Get a list of products, searched, filtered and sorted, with optional pagination
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Security Authentication
+// @Param search query string false "Search in name and item ID"
+// @Param category_id query int false "Category ID"
+// @Param status query string false "Product status"
+// @Param min_price query number false "Lowest price"
+// @Param max_price query number false "Highest price"
+// @Param stock query string false "Stock status" Enums(in stock, limited stock, out of stock)
+// @Param sort query string false "Sort field" Enums(name, price, created_at, popularity)
+// @Param order query string false "Sort order" Enums(asc, desc)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
 // @Success 200 {object} model.SuccessResponse{data=[]model.Product} "List of products retrieved successfully"
+// @Failure 400 {object} model.ErrorResponse "Invalid filter"
 // @Failure 500 {object} model.ErrorResponse "Failed to fetch products"
 // @Router /product [get]
 func (pc *ProductController) GetAllProducts(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	filter, err := productFilter(c)
+	if err != nil {
+		pc.log.Error("Invalid product filter", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	pc.listProducts(c, filter)
+}
 
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		limit = 10
+// GetCategoryProducts godoc
+// @Summary Get the products of a category
+// @Description Get the products of one category, searched, filtered and sorted like the product list
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param category_id query int true "Category ID"
+// @Param search query string false "Search in name and item ID"
+// @Param sort query string false "Sort field" Enums(name, price, created_at, popularity)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} model.SuccessResponse{data=[]model.Product} "List of products retrieved successfully"
+// @Failure 400 {object} model.ErrorResponse "Invalid category_id"
+// @Failure 500 {object} model.ErrorResponse "Failed to fetch products"
+// @Router /category/products [get]
+func (pc *ProductController) GetCategoryProducts(c *gin.Context) {
+	filter, err := productFilter(c)
+	if err != nil {
+		pc.log.Error("Invalid product filter", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	if filter.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_id"})
+		return
+	}
+	pc.listProducts(c, filter)
+}
 
-	products, total, totalPages, err := pc.service.Product.ShowAllProduct(page, limit)
+func (pc *ProductController) listProducts(c *gin.Context, filter model.ProductFilter) {
+	products, total, totalPages, err := pc.service.Product.ShowAllProduct(filter)
 	if err != nil {
 		pc.log.Error("Failed to fetch products", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
@@ -59,10 +106,62 @@ func (pc *ProductController) GetAllProducts(c *gin.Context) {
 		"data":        products,
 		"total":       total,
 		"totalPages":  totalPages,
-		"currentPage": page,
+		"currentPage": filter.Page,
 	})
 }
 
+// productFilter reads the product listing's query parameters.
+func productFilter(c *gin.Context) (model.ProductFilter, error) {
+	filter := model.ProductFilter{
+		Search: c.Query("search"),
+		Status: c.Query("status"),
+		Stock:  c.Query("stock"),
+		Sort:   c.Query("sort"),
+		Order:  c.Query("order"),
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	filter.Page, filter.Limit = page, limit
+
+	if value := c.Query("category_id"); value != "" {
+		categoryID, err := strconv.Atoi(value)
+		if err != nil || categoryID < 1 {
+			return filter, errors.New("Invalid category_id")
+		}
+		filter.CategoryID = uint(categoryID)
+	}
+	for param, bound := range map[string]**float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if value := c.Query(param); value != "" {
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil || price < 0 {
+				return filter, errors.New("Invalid " + param)
+			}
+			*bound = &price
+		}
+	}
+	switch filter.Stock {
+	case "", model.StockIn, model.StockLimited, model.StockOut:
+	default:
+		return filter, errors.New("Invalid stock")
+	}
+	switch filter.Sort {
+	case "", "name", "price", "created_at", "popularity":
+	default:
+		return filter, errors.New("Invalid sort")
+	}
+	if filter.Order != "" && !strings.EqualFold(filter.Order, "asc") && !strings.EqualFold(filter.Order, "desc") {
+		return filter, errors.New("Invalid order")
+	}
+	return filter, nil
+}
+
 // GetProductByID godoc
 // @Summary Get product by ID
 // @Description Retrieve a single product by its ID
@@ -104,10 +203,15 @@ func (pc *ProductController) GetProductByID(c *gin.Context) {
 // @Param name formData string true "Product Name"
 // @Param description formData string true "Product Description"
 // @Param price formData float64 true "Product Price"
-// @Param stock formData int true "Product Stock"
+// @Param qty formData int false "Opening stock, posted to the stock ledger as a restock"
+// @Param cost_price formData float64 false "Cost price, kept up to date by goods receipts"
+// @Param supplier_id formData int false "Preferred supplier for low-stock purchase orders"
+// @Param reorder_qty formData int false "Quantity to order when low on stock"
+// @Param reorder_point formData int false "Stock level below which the product is low; defaults to LOW_STOCK"
 // @Param image formData file true "Product Image"
 // @Success 201 {object} model.SuccessResponse{data=model.Product} "Product created successfully"
 // @Failure 400 {object} model.ErrorResponse "Invalid product data"
+// @Failure 409 {object} model.ErrorResponse "Another product has this item_id"
 // @Failure 500 {object} model.ErrorResponse "Failed to create product"
 // @Router /product [post]
 func (pc *ProductController) CreateProduct(c *gin.Context) {
@@ -129,52 +233,84 @@ func (pc *ProductController) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Menggunakan goroutine untuk upload gambar
-	var wg sync.WaitGroup
-	responses, err := helper.Upload(&wg, files)
+	// Menyimpan gambar ke storage
+	image, err := pc.service.Media.UploadImage(c.Request.Context(), model.ImageProduct, files[0])
 	if err != nil {
 		pc.log.Error("Failed to upload image", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		c.JSON(helper.UploadStatus(err), gin.H{"error": "Failed to upload image:" + err.Error()})
 		return
 	}
-
-	// Menangani jika ada respons dari upload gambar
-	if len(responses) > 0 {
-		pc.log.Info("Image uploaded successfully", zap.String("imageURL", responses[0].Data.Url))
-	}
+	pc.log.Info("Image uploaded successfully", zap.String("imageURL", image.Medium))
 
 	// Menangani data lainnya dari form
 	name := c.PostForm("name")
 	itemID := c.PostForm("item_id")
-	stock := c.PostForm("stock")
 	categoryID, err := strconv.Atoi(c.DefaultPostForm("category_id", "0"))
 	if err != nil {
 		pc.log.Error("Invalid category_id", zap.String("category_id", c.PostForm("category_id")), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_id"})
 		return
 	}
+	qty, err := strconv.Atoi(c.DefaultPostForm("qty", "0"))
+	if err != nil || qty < 0 {
+		pc.log.Error("Invalid qty value", zap.String("qty", c.PostForm("qty")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid qty value"})
+		return
+	}
 	price, err := strconv.ParseFloat(c.PostForm("price"), 64)
 	if err != nil {
 		pc.log.Error("Invalid price value", zap.String("price", c.PostForm("price")), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price value"})
 		return
 	}
+	costPrice, err := strconv.ParseFloat(c.DefaultPostForm("cost_price", "0"), 64)
+	if err != nil || costPrice < 0 {
+		pc.log.Error("Invalid cost_price value", zap.String("cost_price", c.PostForm("cost_price")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cost_price value"})
+		return
+	}
+	supplierID, err := strconv.Atoi(c.DefaultPostForm("supplier_id", "0"))
+	if err != nil || supplierID < 0 {
+		pc.log.Error("Invalid supplier_id", zap.String("supplier_id", c.PostForm("supplier_id")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier_id"})
+		return
+	}
+	reorderQty, err := strconv.Atoi(c.DefaultPostForm("reorder_qty", "0"))
+	if err != nil || reorderQty < 0 {
+		pc.log.Error("Invalid reorder_qty value", zap.String("reorder_qty", c.PostForm("reorder_qty")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reorder_qty value"})
+		return
+	}
+	reorderPoint, err := strconv.Atoi(c.DefaultPostForm("reorder_point", "0"))
+	if err != nil || reorderPoint < 0 {
+		pc.log.Error("Invalid reorder_point value", zap.String("reorder_point", c.PostForm("reorder_point")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reorder_point value"})
+		return
+	}
 	status := c.DefaultPostForm("status", "available")
 
 	product := model.Product{
-		Name:       name,
-		ItemID:     itemID,
-		Stock:      stock,
-		CategoryID: uint(categoryID),
-		Qty:        1,
-		Price:      price,
-		Status:     status,
-		ImageURL:   responses[0].Data.Url,
+		Name:          name,
+		ItemID:        itemID,
+		CategoryID:    uint(categoryID),
+		Qty:           qty,
+		Price:         price,
+		CostPrice:     costPrice,
+		SupplierID:    uint(supplierID),
+		ReorderQty:    reorderQty,
+		ReorderPoint:  reorderPoint,
+		Status:        status,
+		ImageURL:      image.Medium,
+		ImageVariants: *image,
 	}
 
 	// Membuat produk di database
-	if err := pc.service.Product.CreateProduct(&product); err != nil {
+	if err := pc.service.Product.CreateProduct(&product, c.GetUint("userID")); err != nil {
 		pc.log.Error("Failed to create product", zap.Error(err))
+		if errors.Is(err, productrepository.ErrItemIDTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another product has this item_id"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
@@ -195,11 +331,11 @@ func (pc *ProductController) CreateProduct(c *gin.Context) {
 // @Param name formData string false "Product Name"
 // @Param description formData string false "Product Description"
 // @Param price formData float64 false "Product Price"
-// @Param stock formData int false "Product Stock"
 // @Param image formData file false "Product Image"
 // @Success 200 {object} model.SuccessResponse{data=model.Product} "Product updated successfully"
 // @Failure 400 {object} model.ErrorResponse "Invalid product ID or data"
 // @Failure 404 {object} model.ErrorResponse "Product not found"
+// @Failure 409 {object} model.ErrorResponse "Another product has this item_id"
 // @Failure 500 {object} model.ErrorResponse "Failed to update product"
 // @Router /product/{id} [put]
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
@@ -219,20 +355,22 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 
 	files := c.Request.MultipartForm.File["image_url"]
 	if len(files) > 0 {
-		var wg sync.WaitGroup
-		responses, err := helper.Upload(&wg, files)
+		image, err := pc.service.Media.UploadImage(c.Request.Context(), model.ImageProduct, files[0])
 		if err != nil {
 			pc.log.Error("Failed to upload image", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+			c.JSON(helper.UploadStatus(err), gin.H{"error": "Failed to upload image:" + err.Error()})
 			return
 		}
-		if len(responses) > 0 {
-			product.ImageURL = responses[0].Data.Url
-		}
+		product.ImageURL = image.Medium
+		product.ImageVariants = *image
 	}
 
 	if err := pc.service.Product.UpdateProduct(uint(id), &product); err != nil {
 		pc.log.Error("Failed to update product", zap.Error(err))
+		if errors.Is(err, productrepository.ErrItemIDTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another product has this item_id"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
@@ -243,7 +381,7 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 
 // DeleteProduct godoc
 // @Summary Delete a product
-// @Description Remove a product by its ID
+// @Description Move a product to the trash. It can no longer be ordered but stays in past orders and reports, and can be restored.
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -264,6 +402,10 @@ func (pc *ProductController) DeleteProduct(c *gin.Context) {
 
 	if err := pc.service.Product.DeleteProduct((id)); err != nil {
 		pc.log.Error("Failed to delete product", zap.Error(err))
+		if errors.Is(err, productrepository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
@@ -271,3 +413,406 @@ func (pc *ProductController) DeleteProduct(c *gin.Context) {
 	pc.log.Info("Product deleted successfully", zap.Uint("id", uint(id)))
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
+
+// GetTrash godoc
+// @Summary Get deleted products
+// @Description Deleted products, most recently deleted first. Those never sold, bought or counted are purged after PRODUCT_PURGE_DAYS.
+// @Tags Products
+// @Produce json
+// @Security Authentication
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} model.SuccessResponse{data=[]model.Product} "Deleted products retrieved successfully"
+// @Failure 500 {object} model.ErrorResponse "Failed to fetch deleted products"
+// @Router /product/trash [get]
+func (pc *ProductController) GetTrash(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	products, total, totalPages, err := pc.service.Product.TrashProducts(page, limit)
+	if err != nil {
+		pc.log.Error("Failed to fetch deleted products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        products,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description Take a product out of the trash so it can be ordered again
+// @Tags Products
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.SuccessResponse "Product restored successfully"
+// @Failure 400 {object} model.ErrorResponse "Invalid product ID"
+// @Failure 404 {object} model.ErrorResponse "Product not in the trash"
+// @Failure 409 {object} model.ErrorResponse "Another product has its item_id"
+// @Router /product/{id}/restore [post]
+func (pc *ProductController) RestoreProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		pc.log.Error("Invalid product ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := pc.service.Product.RestoreProduct(uint(id)); err != nil {
+		switch {
+		case errors.Is(err, productrepository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not in the trash"})
+		case errors.Is(err, productrepository.ErrItemIDTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Another product has its item_id"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product"})
+		}
+		return
+	}
+
+	pc.log.Info("Product restored successfully", zap.Int("id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "Product restored successfully"})
+}
+
+// ImportProducts godoc
+// @Summary Import products
+// @Description Create or update products in bulk from a CSV or XLSX file, matched by id and then by item_id. The category is given by category_id, or else by its path of names such as "Drinks / Coffee", and barcodes are separated by spaces. The first row names the columns: id, item_id, name, category_id, category, price, cost_price, qty, status, supplier_id, reorder_qty, reorder_point, image_url, barcodes. A blank cell leaves the field unchanged. Nothing is written when any row has an error; a dry run only reports what would happen.
+// @Tags Products
+// @Accept multipart/form-data
+// @Produce json
+// @Security Authentication
+// @Param file formData file true "CSV or XLSX file"
+// @Param format query string false "File format, from the file name by default" Enums(csv, xlsx)
+// @Param dry_run query bool false "Only validate the file"
+// @Success 200 {object} model.ProductImportResult "Products imported, or dry run result"
+// @Failure 400 {object} model.ErrorResponse "Invalid file"
+// @Failure 422 {object} model.ProductImportResult "Rows with errors; nothing imported"
+// @Router /product/import [post]
+func (pc *ProductController) ImportProducts(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		pc.log.Error("Missing import file", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	reader, err := file.Open()
+	if err != nil {
+		pc.log.Error("Failed to open import file", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+	defer reader.Close()
+	table, err := helper.ReadTable(reader, format)
+	if err != nil {
+		pc.log.Error("Failed to read import file", zap.String("format", format), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file, expected CSV or XLSX"})
+		return
+	}
+
+	result, err := pc.service.Product.ImportProducts(table, dryRun, c.GetUint("userID"))
+	if err != nil {
+		pc.log.Error("Failed to import products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products"})
+		return
+	}
+	if len(result.Errors) > 0 && !dryRun {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	pc.log.Info("Imported products", zap.Bool("dryRun", dryRun), zap.Int("created", result.Created), zap.Int("updated", result.Updated))
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Download every product as a CSV or XLSX file in the layout POST /product/import reads
+// @Tags Products
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Authentication
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Success 200 {file} file "products.csv or products.xlsx"
+// @Failure 400 {object} model.ErrorResponse "Unknown format"
+// @Failure 500 {object} model.ErrorResponse "Failed to export products"
+// @Router /product/export [get]
+func (pc *ProductController) ExportProducts(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", helper.FormatCSV))
+	contentTypes := map[string]string{
+		helper.FormatCSV:  "text/csv",
+		helper.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown format"})
+		return
+	}
+
+	table, err := pc.service.Product.ExportProducts()
+	if err != nil {
+		pc.log.Error("Failed to export products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export products"})
+		return
+	}
+	var buf bytes.Buffer
+	if err := helper.WriteTable(&buf, format, table); err != nil {
+		pc.log.Error("Failed to write export file", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export products"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=products."+format)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// LookupBarcode godoc
+// @Summary Find a product by barcode
+// @Description Find the product a scanned EAN-13 or UPC-A code is printed on
+// @Tags Products
+// @Produce json
+// @Security Authentication
+// @Param barcode query string true "EAN-13 or UPC-A code"
+// @Success 200 {object} model.SuccessResponse{data=model.Product} "Product found"
+// @Failure 400 {object} model.ErrorResponse "Invalid barcode"
+// @Failure 404 {object} model.ErrorResponse "Unknown barcode"
+// @Router /product/lookup [get]
+func (pc *ProductController) LookupBarcode(c *gin.Context) {
+	product, err := pc.service.Product.LookupBarcode(c.Query("barcode"))
+	if err != nil {
+		pc.log.Error("Failed to look up barcode", zap.String("barcode", c.Query("barcode")), zap.Error(err))
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// AddBarcode godoc
+// @Summary Add a barcode to a product
+// @Description Add an EAN-13 or UPC-A code to a product. The check digit is verified, and a code can only be on one product.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param request body model.FormBarcode true "Barcode"
+// @Success 201 {object} model.SuccessResponse{data=model.ProductBarcode} "Barcode added"
+// @Failure 400 {object} model.ErrorResponse "Invalid barcode"
+// @Failure 404 {object} model.ErrorResponse "Product not found"
+// @Failure 409 {object} model.ErrorResponse "Barcode already in use"
+// @Router /product/{id}/barcodes [post]
+func (pc *ProductController) AddBarcode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		pc.log.Error("Invalid product ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var form model.FormBarcode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid barcode"})
+		return
+	}
+
+	productBarcode, err := pc.service.Product.AddBarcode(uint(id), form.Code)
+	if err != nil {
+		pc.log.Error("Failed to add barcode", zap.Int("id", id), zap.Error(err))
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusCreated, productBarcode)
+}
+
+// RemoveBarcode godoc
+// @Summary Remove a barcode from a product
+// @Tags Products
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param code path string true "EAN-13 or UPC-A code"
+// @Success 200 {object} model.SuccessResponse "Barcode removed"
+// @Failure 400 {object} model.ErrorResponse "Invalid barcode"
+// @Failure 404 {object} model.ErrorResponse "The product has no such barcode"
+// @Router /product/{id}/barcodes/{code} [delete]
+func (pc *ProductController) RemoveBarcode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		pc.log.Error("Invalid product ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := pc.service.Product.RemoveBarcode(uint(id), c.Param("code")); err != nil {
+		pc.log.Error("Failed to remove barcode", zap.Int("id", id), zap.Error(err))
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Barcode removed successfully"})
+}
+
+// PrintBarcodeLabels godoc
+// @Summary Print barcode labels
+// @Description Download A4 sheets of 3 by 8 barcode labels with name and price. Without product_ids, labels are made for every product that has no barcode yet. Products without one are given an in-store code starting with 20.
+// @Tags Products
+// @Accept json
+// @Produce application/pdf
+// @Security Authentication
+// @Param request body model.FormBarcodeLabels false "Products and copies of each"
+// @Success 200 {file} file "labels.pdf"
+// @Failure 400 {object} model.ErrorResponse "Invalid request"
+// @Failure 404 {object} model.ErrorResponse "Product not found"
+// @Router /product/labels [post]
+func (pc *ProductController) PrintBarcodeLabels(c *gin.Context) {
+	var form model.FormBarcodeLabels
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	labels, err := pc.service.Product.BarcodeLabels(form)
+	if err != nil {
+		pc.log.Error("Failed to prepare barcode labels", zap.Error(err))
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+	var buf bytes.Buffer
+	if err := barcode.WriteSheets(&buf, labels); err != nil {
+		pc.log.Error("Failed to write barcode labels", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to print labels"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=labels.pdf")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// GetStockHistory godoc
+// @Summary Get product stock history
+// @Description Stock movements of a product, newest first, with the balance after each
+// @Tags Products
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} model.SuccessResponse{data=[]model.StockMovement} "Stock history retrieved successfully"
+// @Failure 404 {object} model.ErrorResponse "Product not found"
+// @Router /product/{id}/stock-history [get]
+func (pc *ProductController) GetStockHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		pc.log.Error("Invalid product ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	movements, total, totalPages, err := pc.service.Product.StockHistory(uint(id), page, limit)
+	if err != nil {
+		pc.log.Error("Failed to fetch stock history", zap.Error(err))
+		c.JSON(stockErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        movements,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// RecordStockMovement godoc
+// @Summary Record a stock movement
+// @Description Restock, adjust, write off as waste or transfer stock of a product, with a reason
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Product ID"
+// @Param movement body model.FormStockMovement true "Stock movement"
+// @Success 201 {object} model.SuccessResponse{data=model.StockMovement} "Stock movement recorded successfully"
+// @Failure 400 {object} model.ErrorResponse "Invalid movement or insufficient stock"
+// @Failure 404 {object} model.ErrorResponse "Product not found"
+// @Router /product/{id}/stock [post]
+func (pc *ProductController) RecordStockMovement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		pc.log.Error("Invalid product ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var form model.FormStockMovement
+	if err := c.ShouldBindJSON(&form); err != nil {
+		pc.log.Error("Invalid stock movement", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock movement: " + err.Error()})
+		return
+	}
+
+	movement, err := pc.service.Product.RecordMovement(uint(id), form, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": strings.TrimSpace(err.Error())})
+		return
+	}
+
+	pc.log.Info("Stock movement recorded", zap.Uint("id", uint(id)), zap.String("type", movement.Type))
+	c.JSON(http.StatusCreated, gin.H{"message": "Stock movement recorded successfully", "movement": movement})
+}
+
+func barcodeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, barcode.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, productrepository.ErrProductNotFound), errors.Is(err, productrepository.ErrUnknownBarcode):
+		return http.StatusNotFound
+	case errors.Is(err, productrepository.ErrBarcodeTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func stockErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, stockrepository.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, stockrepository.ErrInsufficientStock), errors.Is(err, productservice.ErrRecipeStock), errors.Is(err, productservice.ErrBundleStock):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
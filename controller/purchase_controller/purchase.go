@@ -0,0 +1,188 @@
+package purchasecontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get All Purchase Orders
+// @Description Purchase orders with their supplier and lines, newest first, paginated like the product listing
+// @Tags Purchase Order
+// @Produce  json
+// @Security Authentication
+// @Param status query string false "draft, ordered, partial, received or canceled"
+// @Param supplier_id query int false "Supplier ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.PurchaseOrder} "Get Purchase Orders Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /purchase-orders [get]
+func (ctrl *ControllerPurchase) GetPurchaseOrders(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	supplierID, _ := strconv.Atoi(ctx.Query("supplier_id"))
+	filter := model.PurchaseFilter{
+		Status:     ctx.Query("status"),
+		SupplierID: uint(max(supplierID, 0)),
+		Page:       page,
+		Limit:      limit,
+	}
+	data, total, totalPages, err := ctrl.Service.Purchase.GetAll(filter)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Purchase Order
+// @Description Purchase order with its supplier, lines and goods receipts
+// @Tags Purchase Order
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} helper.Response{data=model.PurchaseOrder} "Get Purchase Order Success"
+// @Failure 404 {object} helper.Response "Purchase order not found"
+// @Router  /purchase-orders/{id} [get]
+func (ctrl *ControllerPurchase) GetPurchaseOrder(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Purchase.Get(uint(id))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Purchase Order success", data)
+}
+
+// @Summary Create Purchase Order
+// @Description Draft a purchase order with line items at their expected unit cost
+// @Tags Purchase Order
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormPurchaseOrder true "Purchase order"
+// @Success 201 {object} helper.Response{data=model.PurchaseOrder} "Create Purchase Order Success"
+// @Failure 400 {object} helper.Response "Invalid request"
+// @Failure 404 {object} helper.Response "Supplier or product not found"
+// @Router  /purchase-orders [post]
+func (ctrl *ControllerPurchase) CreatePurchaseOrder(ctx *gin.Context) {
+	var form model.FormPurchaseOrder
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Purchase.Create(form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Create Purchase Order success", data)
+}
+
+// @Summary Update Purchase Order
+// @Description Replace the supplier, notes, expected date and lines of a draft
+// @Tags Purchase Order
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Purchase Order ID"
+// @Param request body model.FormPurchaseOrder true "Purchase order"
+// @Success 200 {object} helper.Response{data=model.PurchaseOrder} "Update Purchase Order Success"
+// @Failure 409 {object} helper.Response "Purchase order is no longer a draft"
+// @Router  /purchase-orders/{id} [put]
+func (ctrl *ControllerPurchase) UpdatePurchaseOrder(ctx *gin.Context) {
+	var form model.FormPurchaseOrder
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Purchase.Update(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Update Purchase Order success", data)
+}
+
+// @Summary Submit Purchase Order
+// @Description Mark a draft as ordered from the supplier
+// @Tags Purchase Order
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} helper.Response{data=model.PurchaseOrder} "Submit Purchase Order Success"
+// @Failure 409 {object} helper.Response "Purchase order is not a draft"
+// @Router  /purchase-orders/{id}/submit [post]
+func (ctrl *ControllerPurchase) SubmitPurchaseOrder(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Purchase.Submit(uint(id))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Submit Purchase Order success", data)
+}
+
+// @Summary Cancel Purchase Order
+// @Description Close a purchase order that is not fully received; goods already received stay in stock
+// @Tags Purchase Order
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} helper.Response{data=model.PurchaseOrder} "Cancel Purchase Order Success"
+// @Failure 409 {object} helper.Response "Purchase order is already received or canceled"
+// @Router  /purchase-orders/{id}/cancel [post]
+func (ctrl *ControllerPurchase) CancelPurchaseOrder(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Purchase.Cancel(uint(id))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Cancel Purchase Order success", data)
+}
+
+// @Summary Receive Goods
+// @Description Record a full or partial delivery against an ordered purchase order; each line is restocked and updates the product's cost price
+// @Tags Purchase Order
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Purchase Order ID"
+// @Param request body model.FormGoodsReceipt true "Goods receipt"
+// @Success 201 {object} helper.Response{data=model.PurchaseOrder} "Receive Goods Success"
+// @Failure 409 {object} helper.Response "Purchase order not ordered or qty exceeds what is outstanding"
+// @Router  /purchase-orders/{id}/receive [post]
+func (ctrl *ControllerPurchase) ReceiveGoods(ctx *gin.Context) {
+	var form model.FormGoodsReceipt
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Purchase.Receive(uint(id), form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Receive Goods success", data)
+}
@@ -0,0 +1,143 @@
+package purchasecontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerPurchase struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerPurchase(service *service.AllService, log *zap.Logger) ControllerPurchase {
+	return ControllerPurchase{Service: service, Log: log}
+}
+
+// @Summary Get All Suppliers
+// @Description Suppliers matching a name, contact, phone number or email, paginated like the product listing
+// @Tags Supplier
+// @Produce  json
+// @Security Authentication
+// @Param search query string false "Name, contact, phone number or email"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.Supplier} "Get Suppliers Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /suppliers [get]
+func (ctrl *ControllerPurchase) GetSuppliers(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	data, total, totalPages, err := ctrl.Service.Supplier.GetAll(strings.TrimSpace(ctx.Query("search")), page, limit)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Supplier
+// @Tags Supplier
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} helper.Response{data=model.Supplier} "Get Supplier Success"
+// @Failure 404 {object} helper.Response "Supplier not found"
+// @Router  /suppliers/{id} [get]
+func (ctrl *ControllerPurchase) GetSupplier(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Supplier.Get(uint(id))
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Supplier success", data)
+}
+
+// @Summary Create Supplier
+// @Tags Supplier
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormSupplier true "Supplier"
+// @Success 201 {object} helper.Response{data=model.Supplier} "Create Supplier Success"
+// @Failure 400 {object} helper.Response "Invalid request"
+// @Router  /suppliers [post]
+func (ctrl *ControllerPurchase) CreateSupplier(ctx *gin.Context) {
+	var form model.FormSupplier
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Supplier.Create(form)
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Create Supplier success", data)
+}
+
+// @Summary Update Supplier
+// @Tags Supplier
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Supplier ID"
+// @Param request body model.FormSupplier true "Supplier"
+// @Success 200 {object} helper.Response{data=model.Supplier} "Update Supplier Success"
+// @Failure 404 {object} helper.Response "Supplier not found"
+// @Router  /suppliers/{id} [put]
+func (ctrl *ControllerPurchase) UpdateSupplier(ctx *gin.Context) {
+	var form model.FormSupplier
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Supplier.Update(uint(id), form)
+	if err != nil {
+		helper.Responses(ctx, purchaseErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Update Supplier success", data)
+}
+
+func pagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func purchaseErrorStatus(err error) int {
+	switch err.Error() {
+	case " Supplier Not Found", " Purchase Order Not Found", " Purchase Order Line Not Found", " Product Not Found":
+		return http.StatusNotFound
+	case " Invalid Purchase Order Status", " Received Qty Exceeds Outstanding Qty":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"project_pos_app/model"
 	"project_pos_app/service"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -21,23 +22,120 @@ func NewControllerReservation(service *service.AllService, log *zap.Logger) Cont
 }
 
 // @Summary Get All Reservation
-// @Description Endpoint For All Reservation
+// @Description Reservations filtered by date range, status and name, phone or email, sorted and paginated like the product listing. Defaults to today.
 // @Tags Reservation
 // @Accept  json
 // @Produce  json
 // @Security Authentication
-// @Success 200 {object} helper.Response{data=[]model.Reservation} "Get Summary Success"
+// @Param date query string false "Single day (YYYY-MM-DD), shorthand for start and end"
+// @Param start query string false "First day (YYYY-MM-DD)"
+// @Param end query string false "Last day (YYYY-MM-DD)"
+// @Param status query string false "Comma separated statuses"
+// @Param search query string false "Name, phone number or email"
+// @Param sort query string false "date, name, pax, table, status or createdAt" default(date)
+// @Param order query string false "asc or desc" default(asc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} object{data=[]model.Reservation,total=int,totalPages=int,currentPage=int} "Get Reservations Success"
+// @Failure 400 {object} helper.Response "Invalid query"
 // @Failure 500 {object} helper.Response "server error"
 // @Router  /reservation [get]
 func (ctrl *ControllerReservation) GetAll(ctx *gin.Context) {
-	date := ctx.Query("date")
-	data, err := ctrl.Service.Reservation.GetAll(date)
+	filter := reservationFilter(ctx)
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err == nil {
+		filter.Page = page
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err == nil {
+		filter.Limit = limit
+	}
+	data, total, totalPages, err := ctrl.Service.Reservation.GetAll(&filter)
 	if err != nil {
-		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		status := http.StatusInternalServerError
+		if err.Error() == " Bad Request" {
+			status = http.StatusBadRequest
+		}
+		helper.Responses(ctx, status, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": filter.Page,
+	})
+}
+
+// @Summary Export Reservations Calendar
+// @Description iCalendar (.ics) file of the reservations in a date range, next 30 days by default. Accepts the same filters as the listing.
+// @Tags Reservation
+// @Produce  text/calendar
+// @Security Authentication
+// @Param start query string false "First day (YYYY-MM-DD)"
+// @Param end query string false "Last day (YYYY-MM-DD)"
+// @Param status query string false "Comma separated statuses"
+// @Success 200 {file} file "reservations.ics"
+// @Failure 400 {object} helper.Response "Invalid query"
+// @Router  /reservation/export.ics [get]
+func (ctrl *ControllerReservation) ExportCalendar(ctx *gin.Context) {
+	filter := reservationFilter(ctx)
+	data, err := ctrl.Service.Reservation.ExportCalendar(&filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == " Bad Request" {
+			status = http.StatusBadRequest
+		}
+		helper.Responses(ctx, status, err.Error(), nil)
 		ctx.Abort()
 		return
 	}
-	helper.Responses(ctx, http.StatusOK, "Get Reservations success", data)
+	ctx.Header("Content-Disposition", "attachment; filename=reservations.ics")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+func reservationFilter(ctx *gin.Context) model.ReservationFilter {
+	filter := model.ReservationFilter{
+		StartDate: ctx.Query("start"),
+		EndDate:   ctx.Query("end"),
+		Search:    strings.TrimSpace(ctx.Query("search")),
+		Sort:      ctx.Query("sort"),
+		Order:     strings.ToLower(ctx.Query("order")),
+	}
+	if date := ctx.Query("date"); date != "" {
+		filter.StartDate, filter.EndDate = date, date
+	}
+	for _, status := range strings.Split(ctx.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Status = append(filter.Status, status)
+		}
+	}
+	return filter
+}
+
+// @Summary Get Reservation Availability
+// @Description Free time slots per table that can seat the party, with table combinations when no single table fits
+// @Tags Reservation
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param date query string false "Date (YYYY-MM-DD), defaults to today"
+// @Param pax query int true "Party size"
+// @Param duration query int false "Duration in minutes, defaults by party size"
+// @Success 200 {object} helper.Response{data=model.ReservationAvailability} "Get Availability Success"
+// @Failure 400 {object} helper.Response "Invalid query"
+// @Router  /reservation/availability [get]
+func (ctrl *ControllerReservation) GetAvailability(ctx *gin.Context) {
+	pax, _ := strconv.Atoi(ctx.Query("pax"))
+	duration, _ := strconv.Atoi(ctx.Query("duration"))
+	data, err := ctrl.Service.Reservation.GetAvailability(ctx.Query("date"), pax, duration)
+	if err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Availability success", data)
 }
 
 // @Summary Get Detail Reservation
@@ -98,6 +196,7 @@ func (ctrl *ControllerReservation) Create(ctx *gin.Context) {
 // @Security Authentication
 // @Success 201 {string} helper.Response{data=model.Reservation} "Reservation successfully updated"
 // @Failure 400 {object} helper.Response "Invalid form data"
+// @Failure 409 {object} helper.Response "Reservation status changed meanwhile"
 // @Failure 500 {object} helper.Response "Internal Server Error"
 // @Router /reservation [put]
 func (ctrl *ControllerReservation) Edit(ctx *gin.Context) {
@@ -113,9 +212,119 @@ func (ctrl *ControllerReservation) Edit(ctx *gin.Context) {
 	}
 	err = ctrl.Service.Reservation.Edit(&reservation, form)
 	if err != nil {
-		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		helper.Responses(ctx, statusChangeStatus(err), err.Error(), nil)
 		ctx.Abort()
 		return
 	}
 	helper.Responses(ctx, http.StatusOK, "Edit Reservation success", reservation)
 }
+
+// @Summary Seat Reservation
+// @Description Seat the guests of a reservation, occupy the table and open a draft order credited with the deposit
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} helper.Response{data=model.Reservation} "Reservation seated"
+// @Failure 400 {object} helper.Response "Invalid status transition or table occupied"
+// @Failure 409 {object} helper.Response "Reservation status changed meanwhile"
+// @Router /reservation/{id}/seat [post]
+func (ctrl *ControllerReservation) Seat(ctx *gin.Context) {
+	var reservation model.Reservation
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	reservation.ID = uint(id)
+	err := ctrl.Service.Reservation.Seat(&reservation)
+	if err != nil {
+		helper.Responses(ctx, statusChangeStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Seat Reservation success", reservation)
+}
+
+// @Summary Cancel Reservation
+// @Description Cancel a pending or confirmed reservation
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} helper.Response{data=model.Reservation} "Reservation cancelled"
+// @Failure 400 {object} helper.Response "Invalid status transition"
+// @Failure 409 {object} helper.Response "Reservation status changed meanwhile"
+// @Router /reservation/{id}/cancel [post]
+func (ctrl *ControllerReservation) Cancel(ctx *gin.Context) {
+	var reservation model.Reservation
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	reservation.ID = uint(id)
+	err := ctrl.Service.Reservation.Cancel(&reservation)
+	if err != nil {
+		helper.Responses(ctx, statusChangeStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Cancel Reservation success", reservation)
+}
+
+// @Summary Mark Reservation No-Show
+// @Description Mark a confirmed reservation as no-show
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} helper.Response{data=model.Reservation} "Reservation marked as no-show"
+// @Failure 400 {object} helper.Response "Invalid status transition"
+// @Failure 409 {object} helper.Response "Reservation status changed meanwhile"
+// @Router /reservation/{id}/no-show [post]
+func (ctrl *ControllerReservation) NoShow(ctx *gin.Context) {
+	var reservation model.Reservation
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	reservation.ID = uint(id)
+	err := ctrl.Service.Reservation.NoShow(&reservation)
+	if err != nil {
+		helper.Responses(ctx, statusChangeStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "No-Show Reservation success", reservation)
+}
+
+// @Summary Pay Reservation Deposit
+// @Description Record the deposit of a reservation as paid with one of the payment methods
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Security Authentication
+// @Param id path int true "Reservation ID"
+// @Param deposit body model.FormDeposit true "Deposit payment"
+// @Success 200 {object} helper.Response{data=model.Reservation} "Deposit paid"
+// @Failure 400 {object} helper.Response "Invalid request or deposit already settled"
+// @Router /reservation/{id}/deposit [post]
+func (ctrl *ControllerReservation) PayDeposit(ctx *gin.Context) {
+	var reservation model.Reservation
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	reservation.ID = uint(id)
+	var form model.FormDeposit
+	err := ctx.ShouldBindJSON(&form)
+	if err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	err = ctrl.Service.Reservation.PayDeposit(&reservation, form)
+	if err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Pay Deposit success", reservation)
+}
+
+func statusChangeStatus(err error) int {
+	if err.Error() == " Reservation Status Changed" {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
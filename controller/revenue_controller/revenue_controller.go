@@ -80,3 +80,24 @@ func (ctrl *RevenueController) GetProductRevenues(ctx *gin.Context) {
 
 	helper.Responses(ctx, http.StatusOK, "Fetch product revenues successfully", data)
 }
+
+// GetForfeitedDeposits godoc
+// @Summary Fetch forfeited deposits
+// @Description Get reservation deposits kept from late cancellations and no-shows, grouped by month, reported apart from order revenue
+// @Tags Revenue
+// @Produce json
+// @Security Authentication
+// @Success 200 {object} model.SuccessResponse{data=[]model.MonthlyRevenue} "Fetch forfeited deposits successfully"
+// @Failure 500 {object} model.ErrorResponse "Failed to fetch forfeited deposits"
+// @Router /revenue/deposits [get]
+func (ctrl *RevenueController) GetForfeitedDeposits(ctx *gin.Context) {
+	data, err := ctrl.Service.Revenue.FetchForfeitedDeposits()
+	if err != nil {
+		ctrl.Log.Error("Failed to fetch forfeited deposits", zap.Error(err))
+		helper.Responses(ctx, http.StatusInternalServerError, "Failed to fetch forfeited deposits: "+err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+
+	helper.Responses(ctx, http.StatusOK, "Fetch forfeited deposits successfully", data)
+}
@@ -0,0 +1,256 @@
+package stocktakecontroller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+type ControllerStocktake struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerStocktake(service *service.AllService, log *zap.Logger) ControllerStocktake {
+	return ControllerStocktake{Service: service, Log: log}
+}
+
+// @Summary Get All Stocktakes
+// @Description Stocktakes, newest first, paginated like the product listing
+// @Tags Stocktake
+// @Produce  json
+// @Security Authentication
+// @Param status query string false "open, committed or canceled"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} helper.Response{data=[]model.Stocktake} "Get Stocktakes Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /stocktakes [get]
+func (ctrl *ControllerStocktake) GetAll(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	data, total, totalPages, err := ctrl.Service.Stocktake.GetAll(ctx.Query("status"), page, limit)
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": page,
+	})
+}
+
+// @Summary Get Stocktake
+// @Description A stocktake with every line's system qty, counted qty and variance
+// @Tags Stocktake
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} helper.Response{data=model.Stocktake} "Get Stocktake Success"
+// @Failure 404 {object} helper.Response "Stocktake not found"
+// @Router  /stocktakes/{id} [get]
+func (ctrl *ControllerStocktake) Get(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Stocktake.Get(uint(id))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Stocktake success", data)
+}
+
+// @Summary Open Stocktake
+// @Description Start counting the products of the given categories, or all products, snapshotting their system qty
+// @Tags Stocktake
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.FormStocktake true "Stocktake"
+// @Success 201 {object} helper.Response{data=model.Stocktake} "Open Stocktake Success"
+// @Failure 400 {object} helper.Response "No products to count"
+// @Failure 409 {object} helper.Response "Products already in an open stocktake"
+// @Router  /stocktakes [post]
+func (ctrl *ControllerStocktake) Open(ctx *gin.Context) {
+	var form model.FormStocktake
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	data, err := ctrl.Service.Stocktake.Open(form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Open Stocktake success", data)
+}
+
+// @Summary Enter Stocktake Counts
+// @Description Counted quantities from one device. A device counting a product again replaces its earlier count; counts from different devices add up.
+// @Tags Stocktake
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Stocktake ID"
+// @Param request body model.FormStocktakeCount true "Counts"
+// @Success 200 {object} helper.Response{data=model.Stocktake} "Save Counts Success"
+// @Failure 400 {object} helper.Response "Product not in stocktake"
+// @Failure 409 {object} helper.Response "Stocktake is not open"
+// @Router  /stocktakes/{id}/counts [post]
+func (ctrl *ControllerStocktake) Count(ctx *gin.Context) {
+	var form model.FormStocktakeCount
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Stocktake.Count(uint(id), form, ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Save Counts success", data)
+}
+
+// @Summary Commit Stocktake
+// @Description Close the stocktake and post the variance of every counted product as a stock adjustment
+// @Tags Stocktake
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} helper.Response{data=model.Stocktake} "Commit Stocktake Success"
+// @Failure 409 {object} helper.Response "Stocktake is not open"
+// @Router  /stocktakes/{id}/commit [post]
+func (ctrl *ControllerStocktake) Commit(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Stocktake.Commit(uint(id), ctx.GetUint("userID"))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Commit Stocktake success", data)
+}
+
+// @Summary Cancel Stocktake
+// @Description Discard an open stocktake without touching stock
+// @Tags Stocktake
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} helper.Response{data=model.Stocktake} "Cancel Stocktake Success"
+// @Failure 409 {object} helper.Response "Stocktake is not open"
+// @Router  /stocktakes/{id}/cancel [post]
+func (ctrl *ControllerStocktake) Cancel(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	data, err := ctrl.Service.Stocktake.Cancel(uint(id))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Cancel Stocktake success", data)
+}
+
+// @Summary Stocktake Variance Report
+// @Description The stocktake's lines with their variances as an Excel attachment
+// @Tags Stocktake
+// @Security Authentication
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "Stocktake ID"
+// @Success 200 {file} file "Stocktake_Report.xlsx"
+// @Failure 404 {object} helper.Response "Stocktake not found"
+// @Router  /stocktakes/{id}/report [get]
+func (ctrl *ControllerStocktake) Report(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	reports, err := ctrl.Service.Stocktake.Report(uint(id))
+	if err != nil {
+		helper.Responses(ctx, stocktakeErrorStatus(err), err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			ctrl.Log.Error("Failed to close excel", zap.Error(err))
+		}
+	}()
+	val := reflect.ValueOf(model.StocktakeReportExcel{})
+	var data [][]interface{} = [][]interface{}{}
+	var header []interface{}
+	for i := 0; i < val.NumField(); i++ {
+		header = append(header, val.Type().Field(i).Name)
+	}
+	data = append(data, header)
+	for _, report := range reports {
+		var row []interface{}
+		val := reflect.ValueOf(report)
+		for i := 0; i < val.NumField(); i++ {
+			row = append(row, val.Field(i).Interface())
+		}
+		data = append(data, row)
+	}
+	for i, rowData := range data {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		if err := f.SetSheetRow("Sheet1", cell, &rowData); err != nil {
+			ctrl.Log.Error("Failed to excelize", zap.Error(err))
+			helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	filename := fmt.Sprintf("Stocktake_%d_Report.xlsx", id)
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
+
+func pagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func stocktakeErrorStatus(err error) int {
+	switch err.Error() {
+	case " Stocktake Not Found":
+		return http.StatusNotFound
+	case " Product Not In Stocktake", " No Products To Count":
+		return http.StatusBadRequest
+	case " Stocktake Is Not Open", " Products Already In An Open Stocktake":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
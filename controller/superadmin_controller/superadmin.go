@@ -7,7 +7,6 @@ import (
 	"project_pos_app/service"
 	"project_pos_app/utils"
 	"strconv"
-	"sync"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -99,18 +98,10 @@ func (sc *superadminController) UpdateSuperadmin(c *gin.Context) {
 		return
 	}
 
-	for _, file := range files {
-		if file.Size > 5*1024*1024 {
-			helper.Responses(c, http.StatusBadRequest, "Image size exceeds 5MB", nil)
-			return
-		}
-	}
-
-	var wg sync.WaitGroup
-	responses, err := helper.Upload(&wg, files)
+	image, err := sc.service.Media.UploadImage(c.Request.Context(), model.ImageSuperadmin, files[0])
 	if err != nil {
 		sc.log.Error("Failed to upload images", zap.Error(err))
-		helper.Responses(c, http.StatusInternalServerError, "Failed to upload images: "+err.Error(), nil)
+		helper.Responses(c, helper.UploadStatus(err), "Failed to upload images:"+err.Error(), nil)
 		return
 	}
 
@@ -129,9 +120,10 @@ func (sc *superadminController) UpdateSuperadmin(c *gin.Context) {
 			Email:    SuperadminInput.Email,
 			Password: SuperadminInput.ConfirmPassword,
 		},
-		FullName: SuperadminInput.FullName,
-		Address:  SuperadminInput.Address,
-		Image:    responses[0].Data.Url,
+		FullName:      SuperadminInput.FullName,
+		Address:       SuperadminInput.Address,
+		Image:         image.Medium,
+		ImageVariants: *image,
 	}
 
 	// Update Superadmin di database
@@ -0,0 +1,157 @@
+package waitlistcontroller
+
+import (
+	"net/http"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ControllerWaitlist struct {
+	Service *service.AllService
+	Log     *zap.Logger
+}
+
+func NewControllerWaitlist(service *service.AllService, log *zap.Logger) ControllerWaitlist {
+	return ControllerWaitlist{Service: service, Log: log}
+}
+
+// @Summary Get Waitlist
+// @Description Parties still waiting for a table, first come first
+// @Tags Waitlist
+// @Produce  json
+// @Security Authentication
+// @Success 200 {object} helper.Response{data=[]model.Waitlist} "Get Waitlist Success"
+// @Failure 500 {object} helper.Response "server error"
+// @Router  /waitlist [get]
+func (ctrl *ControllerWaitlist) GetQueue(ctx *gin.Context) {
+	data, err := ctrl.Service.Waitlist.GetQueue()
+	if err != nil {
+		helper.Responses(ctx, http.StatusInternalServerError, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Get Waitlist success", data)
+}
+
+// @Summary Quote Wait Time
+// @Description Estimated wait for a party joining now, from table occupancy and the average dining duration
+// @Tags Waitlist
+// @Produce  json
+// @Security Authentication
+// @Param pax query int true "Party size"
+// @Success 200 {object} helper.Response{data=model.WaitQuote} "Quote Success"
+// @Failure 400 {object} helper.Response "Invalid party size"
+// @Router  /waitlist/quote [get]
+func (ctrl *ControllerWaitlist) Quote(ctx *gin.Context) {
+	pax, _ := strconv.Atoi(ctx.Query("pax"))
+	data, err := ctrl.Service.Waitlist.Quote(pax)
+	if err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Quote Wait success", data)
+}
+
+// @Summary Add Party To Waitlist
+// @Description Queue a walk-in party and quote its wait
+// @Tags Waitlist
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param request body model.Waitlist true "Party"
+// @Success 201 {object} helper.Response{data=model.Waitlist} "Add Party Success"
+// @Failure 400 {object} helper.Response "Invalid request"
+// @Router  /waitlist [post]
+func (ctrl *ControllerWaitlist) Add(ctx *gin.Context) {
+	var entry model.Waitlist
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+		ctx.Abort()
+		return
+	}
+	if err := ctrl.Service.Waitlist.Add(&entry); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusCreated, "Add Party success", entry)
+}
+
+// @Summary Call Party
+// @Description Tell a waiting party their table is nearly ready
+// @Tags Waitlist
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Waitlist ID"
+// @Success 200 {object} helper.Response{data=model.Waitlist} "Call Party Success"
+// @Failure 400 {object} helper.Response "Invalid transition"
+// @Router  /waitlist/{id}/call [post]
+func (ctrl *ControllerWaitlist) Call(ctx *gin.Context) {
+	var entry model.Waitlist
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	entry.ID = uint(id)
+	if err := ctrl.Service.Waitlist.Call(&entry); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Call Party success", entry)
+}
+
+// @Summary Seat Party
+// @Description Seat a waiting party at the given table, or the smallest free table that fits, and open its order
+// @Tags Waitlist
+// @Accept  json
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Waitlist ID"
+// @Param request body model.FormWaitlistSeat false "Table"
+// @Success 200 {object} helper.Response{data=model.Waitlist} "Seat Party Success"
+// @Failure 400 {object} helper.Response "Table unavailable, reserved or invalid transition"
+// @Router  /waitlist/{id}/seat [post]
+func (ctrl *ControllerWaitlist) Seat(ctx *gin.Context) {
+	var entry model.Waitlist
+	var form model.FormWaitlistSeat
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&form); err != nil {
+			helper.Responses(ctx, http.StatusBadRequest, "Invalid Request", nil)
+			ctx.Abort()
+			return
+		}
+	}
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	entry.ID = uint(id)
+	if err := ctrl.Service.Waitlist.Seat(&entry, form); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Seat Party success", entry)
+}
+
+// @Summary Remove Party
+// @Description Take a party off the waitlist
+// @Tags Waitlist
+// @Produce  json
+// @Security Authentication
+// @Param id path int true "Waitlist ID"
+// @Success 200 {object} helper.Response{data=model.Waitlist} "Remove Party Success"
+// @Failure 400 {object} helper.Response "Invalid transition"
+// @Router  /waitlist/{id} [delete]
+func (ctrl *ControllerWaitlist) Remove(ctx *gin.Context) {
+	var entry model.Waitlist
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	entry.ID = uint(id)
+	if err := ctrl.Service.Waitlist.Remove(&entry); err != nil {
+		helper.Responses(ctx, http.StatusBadRequest, err.Error(), nil)
+		ctx.Abort()
+		return
+	}
+	helper.Responses(ctx, http.StatusOK, "Remove Party success", entry)
+}
@@ -39,6 +39,54 @@ func Migration(db *gorm.DB) error {
 		{"access_permission", model.AccessPermission{}},
 		{"session", model.Session{}},
 		{"employee", model.Employee{}},
+		{"reservation_duration", model.Reservation{}},
+		{"table_capacity", model.Table{}},
+		{"reservation_order", model.Reservation{}},
+		{"order_reservation", model.Order{}},
+		{"reservation_deposit", model.Reservation{}},
+		{"reservation_date_index", model.Reservation{}},
+		{"waitlist", model.Waitlist{}},
+		{"customer", model.Customer{}},
+		{"order_customer", model.Order{}},
+		{"reservation_customer", model.Reservation{}},
+		{"customer_loyalty", model.Customer{}},
+		{"loyalty_ledger", model.LoyaltyLedger{}},
+		{"order_loyalty", model.Order{}},
+		{"category_points_multiplier", model.Category{}},
+		{"stock_movement", model.StockMovement{}},
+		{"supplier", model.Supplier{}},
+		{"purchase_order", model.PurchaseOrder{}},
+		{"purchase_order_line", model.PurchaseOrderLine{}},
+		{"goods_receipt", model.GoodsReceipt{}},
+		{"goods_receipt_line", model.GoodsReceiptLine{}},
+		{"product_cost_price", model.Product{}},
+		{"stock_movement_cost", model.StockMovement{}},
+		{"ingredient", model.Ingredient{}},
+		{"recipe_item", model.RecipeItem{}},
+		{"ingredient_movement", model.IngredientMovement{}},
+		{"revenue_product_food_cost", model.ProductRevenue{}},
+		{"product_reorder_point", model.Product{}},
+		{"stock_alert", model.StockAlert{}},
+		{"stocktake", model.Stocktake{}},
+		{"stocktake_line", model.StocktakeLine{}},
+		{"stocktake_count", model.StocktakeCount{}},
+		{"category_hierarchy", model.Category{}},
+		{"menu", model.Menu{}},
+		{"menu_schedule", model.MenuSchedule{}},
+		{"menu_category", model.MenuCategory{}},
+		{"menu_product", model.MenuProduct{}},
+		{"product_eighty_sixed", model.Product{}},
+		{"product_barcode", model.ProductBarcode{}},
+		{"bundle_item", model.BundleItem{}},
+		{"order_bundle_item", model.OrderBundleItem{}},
+		{"drawer_session", model.DrawerSession{}},
+		{"drawer_movement", model.DrawerMovement{}},
+		{"drawer_tender", model.DrawerTender{}},
+		{"order_drawer_session", model.Order{}},
+		{"shift", model.Shift{}},
+		{"time_entry", model.TimeEntry{}},
+		{"time_break", model.TimeBreak{}},
+		{"order_staff", model.Order{}},
 	}
 
 	for _, migration := range allModel {
@@ -66,5 +114,62 @@ func Migration(db *gorm.DB) error {
 		log.Printf("Migration '%s' applied successfully.", migration.name)
 	}
 
+	// Define migrations AutoMigrate cannot express, such as extensions and
+	// expression indexes
+	allSQL := []struct {
+		name string
+		sql  []string
+	}{
+		{"product_search_index", []string{
+			`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+			`CREATE INDEX IF NOT EXISTS idx_products_search ON products USING gin (to_tsvector('simple', name || ' ' || item_id))`,
+			`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_products_item_id_trgm ON products USING gin (item_id gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_products_price ON products (price)`,
+			`CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at)`,
+			`CREATE INDEX IF NOT EXISTS idx_order_products_product_id ON order_products (product_id)`,
+		}},
+		{"product_item_id_unique", []string{
+			// Products sharing an item_id keep it on the oldest; the
+			// others get their id appended so they can be told apart
+			`UPDATE products p SET item_id = p.item_id || '-' || p.id
+				WHERE p.deleted_at IS NULL AND p.item_id <> ''
+				AND EXISTS (SELECT 1 FROM products q WHERE q.item_id = p.item_id AND q.deleted_at IS NULL AND q.id < p.id)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_products_item_id_live ON products (item_id) WHERE deleted_at IS NULL AND item_id <> ''`,
+		}},
+		{"drawer_session_one_open", []string{
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_drawer_sessions_open ON drawer_sessions (status) WHERE status = 'open'`,
+		}},
+	}
+
+	for _, migration := range allSQL {
+		var count int64
+		err := db.Raw("SELECT COUNT(1) FROM migrations WHERE name = ?", migration.name).Scan(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to check migration status for %s: %w", migration.name, err)
+		}
+
+		if count > 0 {
+			log.Printf("Migration '%s' already applied, skipping.", migration.name)
+			continue
+		}
+
+		// Run migration and record it as applied
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range migration.sql {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec("INSERT INTO migrations (name) VALUES (?)", migration.name).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", migration.name, err)
+		}
+
+		log.Printf("Migration '%s' applied successfully.", migration.name)
+	}
+
 	return nil
 }
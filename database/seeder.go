@@ -76,6 +76,7 @@ func dataSeeds() []interface{} {
 		model.RevenueSeedOrder(),
 		model.RevenueSeedProduct(),
 		model.SeedProducts(),
+		model.SeedStockMovements(),
 		model.SeedOrderProducts(),
 		model.SeedOrders(),
 		model.SeedTables(),
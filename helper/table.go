@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New(" Unknown File Format")
+
+// ReadTable reads the rows of a CSV file, or of the first sheet of an XLSX
+// file. A byte order mark left by spreadsheet programs is dropped.
+func ReadTable(r io.Reader, format string) ([][]string, error) {
+	var rows [][]string
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		rows = records
+	case FormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		records, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+		rows = records
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
+
+// WriteTable writes rows as CSV, or as the first sheet of an XLSX file.
+// Every cell is written as text, so values read back exactly as written.
+func WriteTable(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case FormatXLSX:
+		f := excelize.NewFile()
+		defer f.Close()
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return err
+			}
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			if err := f.SetSheetRow("Sheet1", cell, &values); err != nil {
+				return err
+			}
+		}
+		return f.Write(w)
+	default:
+		return ErrUnknownFormat
+	}
+}
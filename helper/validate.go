@@ -1,24 +1,45 @@
 package helper
 
 import (
+	"io"
 	"mime/multipart"
-	"strings"
+	"net/http"
+	"project_pos_app/imaging"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// ImageFile accepts an uploaded file whose content, not just its name, is
+// an image of one of the supported types.
 func ImageFile(fl validator.FieldLevel) bool {
 	file, ok := fl.Field().Interface().(*multipart.FileHeader)
 	if !ok {
 		return false
 	}
 
-	allowedExtensions := []string{".jpg", ".jpeg", ".png", ".gif"}
-	for _, ext := range allowedExtensions {
-		if strings.HasSuffix(strings.ToLower(file.Filename), ext) {
-			return true
-		}
+	f, err := file.Open()
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
 	}
+	_, err = imaging.Sniff(head[:n])
+	return err == nil
+}
 
-	return false
+// UploadStatus is the status code to answer a failed image upload with.
+func UploadStatus(err error) int {
+	switch err.Error() {
+	case " Unsupported Image Type":
+		return http.StatusUnsupportedMediaType
+	case " Image Too Large":
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,85 @@
+// Package imaging checks, cleans up and resizes uploaded images.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New(" Unsupported Image Type")
+	ErrTooManyPixels   = errors.New(" Image Dimensions Too Large")
+)
+
+// MaxPixels bounds the decoded size of an image, so that a small file
+// cannot expand into gigabytes of memory.
+const MaxPixels = 40_000_000
+
+// Types are the content types accepted for upload, sniffed from the
+// file's first bytes rather than trusted from its name.
+var Types = map[string]func(io.Reader) (image.Image, error){
+	"image/jpeg": jpeg.Decode,
+	"image/png":  png.Decode,
+	"image/gif":  gif.Decode,
+	"image/webp": webp.Decode,
+}
+
+// Sniff returns the content type of data, or ErrUnsupportedType when it
+// is not an image of one of Types.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := Types[contentType]; !ok {
+		return "", ErrUnsupportedType
+	}
+	return contentType, nil
+}
+
+// Decode decodes an image of one of Types, turned upright according to
+// its EXIF orientation. Nothing but the pixels is kept, so re-encoding
+// the result drops EXIF data such as the location a photo was taken at.
+func Decode(data []byte) (image.Image, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil && config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, err := Types[contentType](bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if contentType == "image/jpeg" {
+		img = Orient(img, Orientation(data))
+	}
+	return img, nil
+}
+
+// Fit scales img down to fit within size x size pixels, keeping its
+// aspect ratio. Smaller images are returned as they are.
+func Fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+	if width >= height {
+		width, height = size, max(1, height*size/width)
+	} else {
+		width, height = max(1, width*size/height), size
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Rect, img, bounds, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,140 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"project_pos_app/imaging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+// photo draws something with smooth gradients, hard edges and sensor
+// noise, like the product photos uploaded.
+func photo(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	noise := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r := 200 * x / width
+			g := 128 + int(100*math.Sin(float64(x+y)/7))
+			b := 200 * y / height
+			if (x/24+y/24)%2 == 0 {
+				b = 200 - b
+			}
+			n := noise.Intn(16) - 8
+			img.Set(x, y, color.RGBA{clamp(r + n), clamp(g + n), clamp(b + n), 255})
+		}
+	}
+	return img
+}
+
+func clamp(v int) uint8 {
+	return uint8(max(0, min(255, v)))
+}
+
+// withOrientation adds an EXIF segment with the given orientation to a JPEG.
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	segment := append(append([]byte("Exif\x00\x00"), tiff...), entry...)
+	segment = append(segment, 0, 0, 0, 0)
+
+	app1 := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestDecode(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			src.Set(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	// A red block in the top left corner, to see where it ends up.
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			src.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, src, &jpeg.Options{Quality: 95}))
+
+	t.Run("Turns photos upright", func(t *testing.T) {
+		data := withOrientation(buf.Bytes(), 6)
+		assert.Equal(t, 6, imaging.Orientation(data))
+
+		img, err := imaging.Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+		r, g, _, _ := img.At(17, 2).RGBA()
+		assert.Greater(t, r>>8, uint32(200))
+		assert.Less(t, g>>8, uint32(60))
+	})
+
+	t.Run("Upright without EXIF", func(t *testing.T) {
+		assert.Equal(t, 1, imaging.Orientation(buf.Bytes()))
+		img, err := imaging.Decode(buf.Bytes())
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+	})
+
+	t.Run("Sniffs the content, not the name", func(t *testing.T) {
+		_, err := imaging.Decode([]byte("<?php echo 'not an image'; ?>"))
+		assert.ErrorIs(t, err, imaging.ErrUnsupportedType)
+
+		contentType, err := imaging.Sniff(buf.Bytes())
+		assert.NoError(t, err)
+		assert.Equal(t, "image/jpeg", contentType)
+	})
+}
+
+func TestFit(t *testing.T) {
+	img := imaging.Fit(photo(1200, 800), 300)
+	assert.Equal(t, image.Rect(0, 0, 300, 200), img.Bounds())
+
+	small := photo(100, 50)
+	assert.Same(t, small, imaging.Fit(small, 300))
+}
+
+func TestEncodeWebP(t *testing.T) {
+	for _, size := range [][2]int{{1, 1}, {17, 33}, {320, 200}} {
+		src := photo(size[0], size[1])
+		src.Set(0, 0, color.RGBA{})
+		var buf bytes.Buffer
+		assert.NoError(t, imaging.EncodeWebP(&buf, src))
+
+		decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+		if !assert.NoError(t, err, "size %v", size) {
+			continue
+		}
+		assert.Equal(t, src.Bounds(), decoded.Bounds())
+		for y := 0; y < size[1]; y++ {
+			for x := 0; x < size[0]; x++ {
+				if !assert.Equal(t, color.NRGBAModel.Convert(src.At(x, y)), color.NRGBAModel.Convert(decoded.At(x, y)), "size %v at %d,%d", size, x, y) {
+					return
+				}
+			}
+		}
+	}
+
+	t.Run("Wider than WebP allows", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := imaging.EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 16385, 1)))
+		assert.ErrorIs(t, err, imaging.ErrWebPTooLarge)
+	})
+}
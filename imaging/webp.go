@@ -0,0 +1,24 @@
+package imaging
+
+import (
+	"errors"
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+var ErrWebPTooLarge = errors.New(" Image Too Large For WebP")
+
+// maxWebPSide is the largest width or height a WebP image can have.
+const maxWebPSide = 16384
+
+// EncodeWebP writes img to w as a lossless WebP, keeping any
+// transparency.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if bounds.Dx() > maxWebPSide || bounds.Dy() > maxWebPSide {
+		return ErrWebPTooLarge
+	}
+	return nativewebp.Encode(w, img, nil)
+}
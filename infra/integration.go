@@ -8,6 +8,7 @@ import (
 	"project_pos_app/middleware"
 	"project_pos_app/repository"
 	"project_pos_app/service"
+	"project_pos_app/storage"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -45,9 +46,14 @@ func NewIntegrateContext() (*IntegrationContext, error) {
 
 	rdb := database.NewCache(config, 60*60)
 
-	repo := repository.NewAllRepo(db, log)
+	repo := repository.NewAllRepo(db, log, config.Loyalty)
 
-	service := service.NewAllService(repo, log)
+	store, err := storage.New(config.Storage)
+	if err != nil {
+		return errorHandler(err)
+	}
+
+	service := service.NewAllService(repo, log, store, config)
 
 	middleware := middleware.NewMiddleware(service, log)
 
@@ -34,6 +34,10 @@ func (ac *AccessController) AccessMiddleware() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
+		if len(access) > 0 {
+			ctx.Set("userID", uint(access[0].UserID))
+			ctx.Set("role", access[0].Role)
+		}
 
 		isSuperAdmin := false
 		for _, perm := range access {
@@ -86,6 +90,9 @@ func (ac *AccessController) SuperAdminOnly() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
+		if len(access) > 0 {
+			ctx.Set("userID", uint(access[0].UserID))
+		}
 
 		if access[0].Role != "super_admin" {
 			helper.Responses(ctx, http.StatusForbidden, "super admin only", nil)
@@ -167,3 +167,11 @@ func (m *MockDB) FindLowStockProducts(threshold int) ([]model.Product, error) {
 	}
 	return nil, args.Error(1)
 }
+
+func (m *MockDB) GetForfeitedDeposits() (map[string]float64, error) {
+	args := m.Called()
+	if deposits := args.Get(0); deposits != nil {
+		return deposits.(map[string]float64), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Shift is a stint of work a manager put an employee on the roster for.
+// An EndAt on the next day is an overnight shift.
+type Shift struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID   uint      `gorm:"index" json:"employeeId"`
+	EmployeeName string    `gorm:"-" json:"employeeName,omitempty"`
+	StartAt      time.Time `gorm:"index" json:"startAt"`
+	EndAt        time.Time `json:"endAt"`
+	Note         string    `json:"note,omitempty"`
+	AssignedBy   uint      `json:"assignedBy,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// TimeEntry is an employee's time on the clock, from clocking in to
+// clocking out. ShiftID is the rostered shift it was for, if any.
+type TimeEntry struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	EmployeeID uint        `gorm:"index" json:"employeeId"`
+	ShiftID    uint        `gorm:"index" json:"shiftId,omitempty"`
+	ClockIn    time.Time   `gorm:"index" json:"clockIn"`
+	ClockOut   *time.Time  `json:"clockOut,omitempty"`
+	Breaks     []TimeBreak `gorm:"foreignKey:EntryID" json:"breaks,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+}
+
+// TimeBreak is an unpaid break taken while clocked in.
+type TimeBreak struct {
+	ID      uint       `gorm:"primaryKey" json:"id"`
+	EntryID uint       `gorm:"index" json:"entryId"`
+	StartAt time.Time  `json:"startAt"`
+	EndAt   *time.Time `json:"endAt,omitempty"`
+}
+
+// BreakTime is how long the entry's breaks took, counting one still
+// running up to now.
+func (e TimeEntry) BreakTime(now time.Time) time.Duration {
+	var total time.Duration
+	for _, b := range e.Breaks {
+		end := now
+		if b.EndAt != nil {
+			end = *b.EndAt
+		}
+		total += end.Sub(b.StartAt)
+	}
+	return total
+}
+
+// Worked is the time on the clock less breaks, up to now while still
+// clocked in.
+func (e TimeEntry) Worked(now time.Time) time.Duration {
+	end := now
+	if e.ClockOut != nil {
+		end = *e.ClockOut
+	}
+	return max(end.Sub(e.ClockIn)-e.BreakTime(now), 0)
+}
+
+// DefaultShift is the employee's usual shift on the day of t, in t's
+// location. Only the times of day of ShiftStartTiming and ShiftEndTiming
+// count, kept as wall clock in UTC like reservation dates. ok is false for
+// an employee without one.
+func (e Employee) DefaultShift(t time.Time) (start, end time.Time, ok bool) {
+	if e.ShiftStartTiming.IsZero() || e.ShiftEndTiming.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	from, to := e.ShiftStartTiming.UTC(), e.ShiftEndTiming.UTC()
+	start = time.Date(t.Year(), t.Month(), t.Day(), from.Hour(), from.Minute(), 0, 0, t.Location())
+	end = time.Date(t.Year(), t.Month(), t.Day(), to.Hour(), to.Minute(), 0, 0, t.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true
+}
+
+// AttendanceRecord is one shift of an employee, scheduled against actual:
+// a time entry with the shift it was for, rostered or else the employee's
+// usual one, or a rostered shift nobody clocked in for.
+type AttendanceRecord struct {
+	EmployeeID     uint       `json:"employeeId"`
+	Name           string     `json:"name"`
+	Date           string     `json:"date"`
+	Rostered       bool       `json:"rostered"`
+	ScheduledStart *time.Time `json:"scheduledStart,omitempty"`
+	ScheduledEnd   *time.Time `json:"scheduledEnd,omitempty"`
+	ClockIn        *time.Time `json:"clockIn,omitempty"`
+	ClockOut       *time.Time `json:"clockOut,omitempty"`
+	BreakMinutes   int        `json:"breakMinutes"`
+	WorkedHours    float64    `json:"workedHours"`
+	LateMinutes    int        `json:"lateMinutes"`
+	Late           bool       `json:"late"`
+	EarlyMinutes   int        `json:"earlyMinutes"`
+	LeftEarly      bool       `json:"leftEarly"`
+	Absent         bool       `json:"absent"`
+}
+
+// NewAttendanceRecord compares entry, nil for an absence, with the shift
+// scheduled from start to end, zero when there was none. Arriving or
+// leaving within grace of the schedule is on time.
+func NewAttendanceRecord(employee Employee, start, end time.Time, entry *TimeEntry, grace time.Duration, now time.Time) AttendanceRecord {
+	record := AttendanceRecord{EmployeeID: employee.ID, Name: employee.Name}
+	if !start.IsZero() {
+		record.ScheduledStart, record.ScheduledEnd = &start, &end
+		record.Date = start.Format("2006-01-02")
+	}
+	if entry == nil {
+		record.Absent = true
+		return record
+	}
+
+	clockIn := entry.ClockIn
+	record.ClockIn, record.ClockOut = &clockIn, entry.ClockOut
+	record.Date = clockIn.Format("2006-01-02")
+	record.BreakMinutes = int(entry.BreakTime(now).Minutes())
+	record.WorkedHours = math.Round(entry.Worked(now).Hours()*100) / 100
+	if start.IsZero() {
+		return record
+	}
+	if late := clockIn.Sub(start); late > 0 {
+		record.LateMinutes = int(late.Minutes())
+		record.Late = late > grace
+	}
+	if entry.ClockOut != nil {
+		if early := end.Sub(*entry.ClockOut); early > 0 {
+			record.EarlyMinutes = int(early.Minutes())
+			record.LeftEarly = early > grace
+		}
+	}
+	return record
+}
+
+// Roster is the shifts of the week starting on Monday WeekStart.
+type Roster struct {
+	WeekStart string  `json:"weekStart"`
+	WeekEnd   string  `json:"weekEnd"`
+	Shifts    []Shift `json:"shifts"`
+}
+
+// FormRoster puts employees on the roster. Each shift is on Date from
+// Start to End, "15:04" in local time; an End before Start runs past
+// midnight.
+type FormRoster struct {
+	Shifts []FormShift `json:"shifts" binding:"required,min=1,dive"`
+}
+
+type FormShift struct {
+	EmployeeID uint   `json:"employeeId" binding:"required"`
+	Date       string `json:"date" binding:"required,datetime=2006-01-02"`
+	Start      string `json:"start" binding:"required,datetime=15:04"`
+	End        string `json:"end" binding:"required,datetime=15:04"`
+	Note       string `json:"note"`
+}
+
+// PayrollExcel is an employee's pay for the period: hours worked on
+// completed entries at HourlyRate, their monthly Salary spread over the
+// standard hours of a month.
+type PayrollExcel struct {
+	No         int
+	EmployeeID uint
+	Name       string
+	Salary     float64
+	HourlyRate float64
+	Shifts     int
+	Hours      float64
+	Pay        float64
+}
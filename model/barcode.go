@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ProductBarcode is a code scanned at the counter to find a product, kept
+// as the 13 digits of its EAN-13 form. A product can have several, such
+// as the codes of different suppliers.
+type ProductBarcode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"index" json:"product_id"`
+	Code      string    `gorm:"size:13;uniqueIndex" json:"code"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type FormBarcode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// FormBarcodeLabels picks the products to print labels for, every product
+// without a barcode when none are given, and how many of each.
+type FormBarcodeLabels struct {
+	ProductIDs []uint `json:"product_ids"`
+	Copies     int    `json:"copies" binding:"omitempty,min=1,max=100"`
+}
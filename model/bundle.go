@@ -0,0 +1,85 @@
+package model
+
+import "math"
+
+// BundleItem is a component of a bundle product, such as the fries of a
+// burger meal, sold at the bundle's price. Items sharing a GroupName are
+// the options of a choice group ("any drink") of which the customer picks
+// one; an item without one always comes with the bundle. Qty is per
+// bundle sold.
+type BundleItem struct {
+	ID        uint     `gorm:"primaryKey" json:"id"`
+	BundleID  uint     `gorm:"index" json:"bundleId"`
+	GroupName string   `json:"group,omitempty"`
+	ProductID uint     `gorm:"index" json:"productId"`
+	Product   *Product `json:"product,omitempty"`
+	Qty       int      `json:"qty"`
+}
+
+// Bundle is a bundle product's components, with how many of it can be
+// made from the components in stock: the fewest of any fixed item, and
+// for a choice group the most of any of its options.
+type Bundle struct {
+	ProductID uint         `json:"productId"`
+	Price     float64      `json:"price"`
+	Items     []BundleItem `json:"items"`
+	Available int          `json:"available"`
+}
+
+// FormBundle replaces a product's components. An empty list makes it a
+// single product again.
+type FormBundle struct {
+	Items []FormBundleItem `json:"items" binding:"dive"`
+}
+
+type FormBundleItem struct {
+	ProductID uint   `json:"productId" binding:"required"`
+	Group     string `json:"group"`
+	Qty       int    `json:"qty" binding:"required,gt=0"`
+}
+
+// OrderBundleItem is a component sold on an order as part of a bundle,
+// with the choice made for it. Qty is the total sold, and Revenue the part
+// of the bundle's price it is credited with.
+type OrderBundleItem struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	OrderID   uint    `gorm:"index" json:"order_id"`
+	BundleID  uint    `json:"bundle_id"`
+	GroupName string  `json:"group,omitempty"`
+	ProductID uint    `gorm:"index" json:"product_id"`
+	Qty       int     `json:"qty"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// AllocateRevenue shares revenue out over the components of a bundle in
+// proportion to what they sell for on their own, or to their quantities
+// when none has a price. Shares are rounded to cents, the last taking the
+// remainder, so they always add up to revenue.
+func AllocateRevenue(revenue float64, items []OrderBundleItem, prices map[uint]float64) {
+	weights := make([]float64, len(items))
+	var total float64
+	for i, item := range items {
+		weights[i] = float64(item.Qty) * prices[item.ProductID]
+		total += weights[i]
+	}
+	if total == 0 {
+		for i, item := range items {
+			weights[i] = float64(item.Qty)
+			total += weights[i]
+		}
+	}
+
+	left := revenue
+	for i := range items {
+		if i == len(items)-1 {
+			items[i].Revenue = math.Round(left*100) / 100
+			break
+		}
+		share := 0.0
+		if total > 0 {
+			share = math.Round(revenue*weights[i]/total*100) / 100
+		}
+		items[i].Revenue = share
+		left -= share
+	}
+}
@@ -5,14 +5,30 @@ import (
 	"time"
 )
 
+// Category groups products on the menu. A category with a ParentID is a
+// subcategory of it, such as Coffee under Drinks; siblings are listed by
+// Position.
 type Category struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	IconURL     string     `json:"icon_url"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `gorm:"index" json:"deleted_at"`
+	ID               uint          `gorm:"primaryKey" json:"id"`
+	ParentID         *uint         `gorm:"index" json:"parent_id"`
+	Position         int           `gorm:"default:0" json:"position"`
+	IconURL          string        `json:"icon_url"`
+	IconVariants     ImageVariants `gorm:"serializer:json" json:"icon_variants"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	PointsMultiplier float64       `gorm:"default:1" json:"points_multiplier"`
+	ProductCount     int64         `gorm:"->;-:migration" json:"product_count"`
+	Children         []Category    `gorm:"-" json:"children,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	DeletedAt        *time.Time    `gorm:"index" json:"deleted_at"`
+}
+
+// FormCategoryReorder lists the subcategories of ParentID, or the top
+// level categories when it is empty, in their new order.
+type FormCategoryReorder struct {
+	ParentID *uint  `json:"parent_id"`
+	IDs      []uint `json:"ids" binding:"required,min=1"`
 }
 
 func SeedCategories() []Category {
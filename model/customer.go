@@ -0,0 +1,61 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Customer is a guest known by phone number or email. Both are unique when
+// set, so the same guest is not recorded twice.
+type Customer struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `json:"name"`
+	PhoneNumber    string    `gorm:"index:idx_customers_phone_number,unique,where:phone_number <> ''" json:"phoneNumber,omitempty"`
+	Email          string    `gorm:"index:idx_customers_email,unique,where:email <> ''" json:"email,omitempty"`
+	Points         int       `json:"points"`
+	LifetimePoints int       `json:"lifetimePoints"`
+	Tier           string    `gorm:"default:'Member'" json:"tier"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// CustomerProfile is a customer with the figures from their completed orders.
+type CustomerProfile struct {
+	Customer
+	LifetimeSpend     float64            `json:"lifetimeSpend"`
+	VisitCount        int                `json:"visitCount"`
+	LastVisit         *time.Time         `json:"lastVisit,omitempty"`
+	FavouriteProducts []FavouriteProduct `json:"favouriteProducts" gorm:"-"`
+}
+
+type FavouriteProduct struct {
+	ProductID uint   `json:"productId"`
+	Name      string `json:"name"`
+	Qty       int    `json:"qty"`
+}
+
+// Normalize trims the name and brings the phone number and email to the
+// form they are stored and matched in.
+func (c *Customer) Normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.PhoneNumber = NormalizePhone(c.PhoneNumber)
+	c.Email = strings.ToLower(strings.TrimSpace(c.Email))
+}
+
+// Identifiable reports whether the customer has a phone number or email to
+// be matched by.
+func (c Customer) Identifiable() bool {
+	return c.PhoneNumber != "" || c.Email != ""
+}
+
+// NormalizePhone keeps the digits of a phone number and a leading plus sign.
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	var b strings.Builder
+	for i, r := range phone {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -16,3 +16,21 @@ type ReportExcel struct {
 	Status       string
 	CreatedAt    string
 }
+
+// StaffSales is how a staff member did over a period. Orders taken are the
+// ones they created; the rest count the orders they served, settled for
+// sales and discounts or voided.
+type StaffSales struct {
+	UserID           uint    `json:"userId"`
+	Name             string  `json:"name"`
+	OrdersTaken      int     `json:"ordersTaken"`
+	OrdersServed     int     `json:"ordersServed"`
+	Sales            float64 `json:"sales"`
+	AverageTicket    float64 `json:"averageTicket"`
+	Items            int     `json:"items"`
+	ItemsPerOrder    float64 `json:"itemsPerOrder"`
+	Voids            int     `json:"voids"`
+	VoidedAmount     float64 `json:"voidedAmount"`
+	Discounts        float64 `json:"discounts"`
+	DiscountedOrders int     `json:"discountedOrders"`
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// DrawerSession is a till shift, from a cashier opening the drawer with a
+// float to counting it at close. Orders taken while it is open belong to
+// it, and so do the payments settled meanwhile. The expected and counted
+// amounts are filled in at close.
+type DrawerSession struct {
+	ID           uint             `gorm:"primaryKey" json:"id"`
+	Status       string           `gorm:"default:open;index" json:"status"`
+	OpeningFloat float64          `json:"openingFloat"`
+	PaidIn       float64          `json:"paidIn"`
+	PaidOut      float64          `json:"paidOut"`
+	ExpectedCash float64          `json:"expectedCash"`
+	CountedCash  float64          `json:"countedCash"`
+	Variance     float64          `json:"variance"`
+	Note         string           `json:"note,omitempty"`
+	OpenedBy     uint             `json:"openedBy,omitempty"`
+	ClosedBy     uint             `json:"closedBy,omitempty"`
+	ClosedAt     *time.Time       `json:"closedAt,omitempty"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	UpdatedAt    time.Time        `json:"updatedAt"`
+	Movements    []DrawerMovement `gorm:"foreignKey:SessionID" json:"movements,omitempty"`
+	Tenders      []DrawerTender   `gorm:"foreignKey:SessionID" json:"tenders,omitempty"`
+}
+
+const (
+	DrawerOpen   = "open"
+	DrawerClosed = "closed"
+
+	DrawerPaidIn  = "paid_in"
+	DrawerPaidOut = "paid_out"
+
+	// CashTender is the name of the payment method kept in the drawer.
+	CashTender = "Cash"
+)
+
+// DrawerMovement is cash put into or taken out of the drawer other than
+// for an order, such as change brought from the bank or a supplier paid on
+// delivery.
+type DrawerMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index" json:"sessionId"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Reason    string    `json:"reason,omitempty"`
+	ActorID   uint      `json:"actorId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DrawerTender is what a session took in one payment method. Expected is
+// its sales, plus the float and paid in less paid out for cash; Variance is
+// how far what was counted is from it.
+type DrawerTender struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	SessionID     uint    `gorm:"index" json:"sessionId"`
+	PaymentMethod uint    `json:"paymentMethod"`
+	Name          string  `json:"name"`
+	Orders        int     `json:"orders"`
+	Sales         float64 `json:"sales"`
+	Expected      float64 `json:"expected"`
+	Counted       float64 `json:"counted"`
+	Variance      float64 `json:"variance"`
+}
+
+// DrawerReport is an X report of the open session, a reading taken
+// mid-shift that changes nothing, or the Z report a session was closed
+// with.
+type DrawerReport struct {
+	Type        string        `json:"type"`
+	Session     DrawerSession `json:"session"`
+	OpenOrders  int64         `json:"openOrders"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+}
+
+const (
+	DrawerReportX = "X"
+	DrawerReportZ = "Z"
+)
+
+type FormOpenDrawer struct {
+	OpeningFloat *float64 `json:"openingFloat" binding:"required,min=0"`
+	Note         string   `json:"note"`
+}
+
+type FormDrawerMovement struct {
+	Type   string  `json:"type" binding:"required,oneof=paid_in paid_out"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+// FormCloseDrawer is the cash counted at close, and optionally what was
+// counted of other tenders, such as card terminal totals. A tender that is
+// not counted is taken to match what was expected.
+type FormCloseDrawer struct {
+	CountedCash *float64          `json:"countedCash" binding:"required,min=0"`
+	Counts      []FormDrawerCount `json:"counts" binding:"dive"`
+	Note        string            `json:"note"`
+}
+
+type FormDrawerCount struct {
+	PaymentMethod uint     `json:"paymentMethod" binding:"required"`
+	Counted       *float64 `json:"counted" binding:"required,min=0"`
+}
+
+// Expect works out what the drawer should hold of each tender, from the
+// sales in tenders and the session's float and paid in and out.
+func (s *DrawerSession) Expect(tenders []DrawerTender) {
+	s.ExpectedCash = roundCents(s.OpeningFloat + s.PaidIn - s.PaidOut)
+	for i := range tenders {
+		tenders[i].Expected = roundCents(tenders[i].Sales)
+		if strings.EqualFold(tenders[i].Name, CashTender) {
+			tenders[i].Expected = roundCents(tenders[i].Expected + s.ExpectedCash)
+			s.ExpectedCash = tenders[i].Expected
+		}
+	}
+	s.Tenders = tenders
+}
+
+// Count records what was counted against what Expect worked out. counted
+// is keyed by payment method; cash is the drawer's count.
+func (s *DrawerSession) Count(cash float64, counted map[uint]float64) {
+	s.CountedCash = roundCents(cash)
+	s.Variance = roundCents(s.CountedCash - s.ExpectedCash)
+	for i, tender := range s.Tenders {
+		amount, ok := counted[tender.PaymentMethod]
+		switch {
+		case strings.EqualFold(tender.Name, CashTender):
+			amount = cash
+		case !ok:
+			amount = tender.Expected
+		}
+		s.Tenders[i].Counted = roundCents(amount)
+		s.Tenders[i].Variance = roundCents(amount - tender.Expected)
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
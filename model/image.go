@@ -0,0 +1,18 @@
+package model
+
+// ImageVariants are the URLs of the copies kept of an uploaded image:
+// a small thumbnail for lists, a medium copy for screens and the full
+// size original. All of them have had their EXIF data removed.
+type ImageVariants struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Original  string `json:"original"`
+}
+
+// Image kinds name the storage folder of each kind of upload, and its
+// size limit.
+const (
+	ImageProduct    = "products"
+	ImageCategory   = "categories"
+	ImageSuperadmin = "superadmins"
+)
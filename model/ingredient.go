@@ -0,0 +1,110 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Ingredient is a raw material kept in stock in its Unit of measure.
+// Stock only changes through the ingredient_movements ledger, and an
+// alert is raised once it falls below ReorderLevel.
+type Ingredient struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name"`
+	Unit         string    `json:"unit"`
+	Stock        float64   `json:"stock"`
+	CostPerUnit  float64   `json:"costPerUnit"`
+	ReorderLevel float64   `json:"reorderLevel"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// RecipeItem is how much of an ingredient goes into one unit of a
+// product, in the ingredient's unit.
+type RecipeItem struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	ProductID    uint        `gorm:"index" json:"productId"`
+	IngredientID uint        `gorm:"index" json:"ingredientId"`
+	Ingredient   *Ingredient `json:"ingredient,omitempty"`
+	Qty          float64     `json:"qty"`
+}
+
+// IngredientMovement is one change to an ingredient's stock, like
+// StockMovement is for products. Sales and voids name the product sold.
+type IngredientMovement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	IngredientID uint      `gorm:"index" json:"ingredientId"`
+	Type         string    `json:"type"`
+	Qty          float64   `json:"qty"`
+	Balance      float64   `json:"balance"`
+	Reason       string    `json:"reason,omitempty"`
+	ActorID      uint      `json:"actorId,omitempty"`
+	OrderID      uint      `gorm:"index" json:"orderId,omitempty"`
+	ProductID    uint      `json:"productId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Recipe is a product's bill of materials, with what one unit costs to
+// make and how many can be made from the ingredients in stock.
+type Recipe struct {
+	ProductID uint         `json:"productId"`
+	Items     []RecipeItem `json:"items"`
+	FoodCost  float64      `json:"foodCost"`
+	Available int          `json:"available"`
+}
+
+type FormIngredient struct {
+	Name         string  `json:"name" binding:"required"`
+	Unit         string  `json:"unit" binding:"required"`
+	CostPerUnit  float64 `json:"costPerUnit" binding:"min=0"`
+	ReorderLevel float64 `json:"reorderLevel" binding:"min=0"`
+}
+
+type FormIngredientMovement struct {
+	Type   string  `json:"type" binding:"required,oneof=restock adjustment waste"`
+	Qty    float64 `json:"qty" binding:"required"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+// FormRecipe replaces a product's recipe. Quantities may be given in any
+// unit convertible to the ingredient's, such as grams of an ingredient
+// stocked in kilograms.
+type FormRecipe struct {
+	Items []FormRecipeItem `json:"items" binding:"dive"`
+}
+
+type FormRecipeItem struct {
+	IngredientID uint    `json:"ingredientId" binding:"required"`
+	Qty          float64 `json:"qty" binding:"required,gt=0"`
+	Unit         string  `json:"unit"`
+}
+
+// Units maps each unit of measure to its base unit and the factor to
+// convert to it.
+var Units = map[string]struct {
+	Base   string
+	Factor float64
+}{
+	"mg":  {"g", 0.001},
+	"g":   {"g", 1},
+	"kg":  {"g", 1000},
+	"ml":  {"ml", 1},
+	"l":   {"ml", 1000},
+	"pcs": {"pcs", 1},
+}
+
+var ErrUnitMismatch = errors.New(" Incompatible Unit")
+
+// ConvertUnit converts qty from one unit to another of the same kind. An
+// empty from means qty is already in the target unit.
+func ConvertUnit(qty float64, from, to string) (float64, error) {
+	if from == "" || from == to {
+		return qty, nil
+	}
+	source, ok := Units[from]
+	target, ok2 := Units[to]
+	if !ok || !ok2 || source.Base != target.Base {
+		return 0, ErrUnitMismatch
+	}
+	return qty * source.Factor / target.Factor, nil
+}
@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// LoyaltyLedger is one movement of a customer's points. Earn and positive
+// adjust entries are lots: Remaining is what is left of them after
+// redemptions, and it expires at ExpiresAt. Redemptions, expiries and
+// negative adjustments use up the oldest lots first.
+type LoyaltyLedger struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CustomerID uint       `gorm:"index" json:"customerId"`
+	OrderID    uint       `json:"orderId,omitempty"`
+	Type       string     `json:"type"`
+	Points     int        `json:"points"`
+	Remaining  int        `json:"remaining,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+const (
+	LedgerEarn   = "earn"
+	LedgerRedeem = "redeem"
+	LedgerExpire = "expire"
+	LedgerAdjust = "adjust"
+)
+
+const (
+	TierMember = "Member"
+	TierSilver = "Silver"
+	TierGold   = "Gold"
+)
+
+// LoyaltyTier is reached once a customer has earned Threshold points in
+// total, and takes Discount percent off their orders.
+type LoyaltyTier struct {
+	Name      string  `json:"name"`
+	Threshold int     `json:"threshold"`
+	Discount  float64 `json:"discount"`
+}
+
+// LoyaltySettings are the earn and redemption rules. A customer earns one
+// point per EarnUnit spent, multiplied per category, and a redeemed point
+// is worth PointValue. Tiers are ordered from the lowest threshold.
+type LoyaltySettings struct {
+	EarnUnit   float64
+	PointValue float64
+	ExpiryDays int
+	Tiers      []LoyaltyTier
+}
+
+// TierFor returns the highest tier reached with the given lifetime points.
+func (s LoyaltySettings) TierFor(lifetimePoints int) LoyaltyTier {
+	tier := LoyaltyTier{Name: TierMember}
+	for _, t := range s.Tiers {
+		if lifetimePoints >= t.Threshold {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// Discount returns the order discount, in percent, of the named tier.
+func (s LoyaltySettings) Discount(tier string) float64 {
+	for _, t := range s.Tiers {
+		if t.Name == tier {
+			return t.Discount
+		}
+	}
+	return 0
+}
+
+// LoyaltyLine is an order line as far as earning points is concerned.
+type LoyaltyLine struct {
+	Subtotal   float64
+	CategoryID uint
+}
+
+// LoyaltyAccount is a customer's points balance, tier and ledger.
+type LoyaltyAccount struct {
+	CustomerID     uint            `json:"customerId"`
+	Points         int             `json:"points"`
+	LifetimePoints int             `json:"lifetimePoints"`
+	Tier           string          `json:"tier"`
+	Ledger         []LoyaltyLedger `json:"ledger"`
+}
+
+type FormPointsAdjust struct {
+	Points int    `json:"points" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
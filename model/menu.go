@@ -0,0 +1,195 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Menu is a named selection of categories and products, such as a
+// breakfast or a dinner menu, sold during its schedules. A category on a
+// menu brings its subcategories along. Products on no menu are sold at
+// any time.
+type Menu struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `json:"name"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	Schedules   []MenuSchedule `json:"schedules"`
+	CategoryIDs []uint         `gorm:"-" json:"categoryIds"`
+	ProductIDs  []uint         `gorm:"-" json:"productIds"`
+}
+
+// MenuSchedule is a time of day, "15:04" in local time, during which a
+// menu is sold on Days, or on every day when Days is empty. An End before
+// Start runs past midnight.
+type MenuSchedule struct {
+	ID     uint     `gorm:"primaryKey" json:"id"`
+	MenuID uint     `gorm:"index" json:"menuId"`
+	Days   []string `gorm:"serializer:json" json:"days"`
+	Start  string   `json:"start"`
+	End    string   `json:"end"`
+}
+
+// MenuCategory and MenuProduct assign categories and products to menus.
+type MenuCategory struct {
+	MenuID     uint `gorm:"primaryKey" json:"menuId"`
+	CategoryID uint `gorm:"primaryKey;index" json:"categoryId"`
+}
+
+type MenuProduct struct {
+	MenuID    uint `gorm:"primaryKey" json:"menuId"`
+	ProductID uint `gorm:"primaryKey;index" json:"productId"`
+}
+
+// Weekdays are the day names schedules use, indexed by time.Weekday.
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Covers reports whether t falls within the schedule. The part of an
+// overnight schedule after midnight belongs to the day it started on.
+func (s MenuSchedule) Covers(t time.Time) bool {
+	start, err := time.Parse("15:04", s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", s.End)
+	if err != nil {
+		return false
+	}
+	clock := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from < to {
+		return clock >= from && clock < to && s.on(t.Weekday())
+	}
+	// Overnight, or around the clock when start and end are the same.
+	return (clock >= from && s.on(t.Weekday())) || (clock < to && s.on((t.Weekday()+6)%7))
+}
+
+func (s MenuSchedule) on(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if strings.EqualFold(d, Weekdays[day]) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAt reports whether the menu is sold at t. An active menu without
+// schedules is sold all day.
+func (m Menu) OpenAt(t time.Time) bool {
+	if !m.Active {
+		return false
+	}
+	if len(m.Schedules) == 0 {
+		return true
+	}
+	for _, schedule := range m.Schedules {
+		if schedule.Covers(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// MenuBoard tells which products are on sale when, from every menu and
+// the category hierarchy.
+type MenuBoard struct {
+	menus      []Menu
+	byProduct  map[uint][]int
+	byCategory map[uint][]int
+	parents    map[uint]uint
+}
+
+func NewMenuBoard(menus []Menu, categories []Category) *MenuBoard {
+	board := &MenuBoard{
+		menus:      menus,
+		byProduct:  make(map[uint][]int),
+		byCategory: make(map[uint][]int),
+		parents:    make(map[uint]uint, len(categories)),
+	}
+	for i, menu := range menus {
+		for _, id := range menu.ProductIDs {
+			board.byProduct[id] = append(board.byProduct[id], i)
+		}
+		for _, id := range menu.CategoryIDs {
+			board.byCategory[id] = append(board.byCategory[id], i)
+		}
+	}
+	for _, category := range categories {
+		if category.ParentID != nil {
+			board.parents[category.ID] = *category.ParentID
+		}
+	}
+	return board
+}
+
+// OnSale reports whether product is on a menu open at t, or on no menu
+// at all. Being on an inactive menu keeps it off sale.
+func (b *MenuBoard) OnSale(product Product, t time.Time) bool {
+	menus := b.menusOf(product)
+	if len(menus) == 0 {
+		return true
+	}
+	for _, i := range menus {
+		if b.menus[i].OpenAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// menusOf lists the menus product is on, directly or through its category
+// or one above it.
+func (b *MenuBoard) menusOf(product Product) []int {
+	menus := append([]int{}, b.byProduct[product.ID]...)
+	seen := make(map[uint]bool)
+	for id := product.CategoryID; id != 0 && !seen[id]; id = b.parents[id] {
+		seen[id] = true
+		menus = append(menus, b.byCategory[id]...)
+	}
+	return menus
+}
+
+// Open returns the menus open at t.
+func (b *MenuBoard) Open(t time.Time) []Menu {
+	open := []Menu{}
+	for _, menu := range b.menus {
+		if menu.OpenAt(t) {
+			open = append(open, menu)
+		}
+	}
+	return open
+}
+
+type FormMenu struct {
+	Name        string             `json:"name" binding:"required"`
+	Active      *bool              `json:"active"`
+	Schedules   []FormMenuSchedule `json:"schedules" binding:"dive"`
+	CategoryIDs []uint             `json:"categoryIds"`
+	ProductIDs  []uint             `json:"productIds"`
+}
+
+type FormMenuSchedule struct {
+	Days  []string `json:"days" binding:"dive,oneof=sun mon tue wed thu fri sat"`
+	Start string   `json:"start" binding:"required,datetime=15:04"`
+	End   string   `json:"end" binding:"required,datetime=15:04"`
+}
+
+// CurrentMenu is what can be sold At: the menus open then and the
+// products for sale, whether on one of those menus or on none.
+type CurrentMenu struct {
+	At       time.Time `json:"at"`
+	Menus    []Menu    `json:"menus"`
+	Products []Product `json:"products"`
+}
+
+// FormEightySix marks a product as run out for the rest of the service,
+// or back on sale.
+type FormEightySix struct {
+	EightySixed *bool `json:"eightySixed" binding:"required"`
+}
@@ -32,6 +32,55 @@ func NotifStock(productName string) Notification {
 	}
 }
 
+func NotifLowStock(product Product, reorderPoint int) Notification {
+	return Notification{
+		Title:     "Low Stock Alert",
+		Message:   fmt.Sprintf("Product %s has %d items in stock, below its reorder point of %d.", product.Name, product.Qty, reorderPoint),
+		Status:    "new",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func NotifIngredientStock(ingredient Ingredient) Notification {
+	return Notification{
+		Title:     "Ingredient Stock Alert",
+		Message:   fmt.Sprintf("Ingredient %s is down to %g %s, below its reorder level of %g %s.", ingredient.Name, ingredient.Stock, ingredient.Unit, ingredient.ReorderLevel, ingredient.Unit),
+		Status:    "new",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func NotifNoShow(reservation Reservation) Notification {
+	return Notification{
+		Title:     "Reservation No-Show",
+		Message:   fmt.Sprintf("%s did not arrive for the reservation on table %d at %s and was marked as no-show.", reservation.Fullname, reservation.TableNumber, reservation.ReservationDate.Format("2006-01-02 15:04")),
+		Status:    "new",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// NotifWaitlist describes a change to a waitlist party, e.g. "joined the
+// waitlist" or "was called".
+func NotifWaitlist(entry Waitlist, change string) Notification {
+	message := fmt.Sprintf("%s (%d pax) %s.", entry.Fullname, entry.Pax, change)
+	if entry.Status == WaitlistWaiting {
+		message = fmt.Sprintf("%s (%d pax) %s, quoted wait %d minutes.", entry.Fullname, entry.Pax, change, entry.QuotedWait)
+	}
+	if entry.Status == WaitlistSeated {
+		message = fmt.Sprintf("%s (%d pax) %s at table %d.", entry.Fullname, entry.Pax, change, entry.TableID)
+	}
+	return Notification{
+		Title:     "Waitlist",
+		Message:   message,
+		Status:    "new",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
 func NotificationSeed() []Notification {
 	return []Notification{
 		{
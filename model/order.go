@@ -7,17 +7,32 @@ import (
 )
 
 type Order struct {
-	ID            uint            `gorm:"primaryKey" json:"id"`
-	TableID       uint            `json:"table_id" binding:"required"`
-	CustomerName  string          `json:"customer_name,omitempty" binding:"required"`
-	Status        string          `json:"status"`
-	TotalAmount   float64         `json:"total_amount"`
-	Tax           float64         `json:"tax"`
-	PaymentMethod uint            `json:"payment_method"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
-	DeletedAt     *gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggerignore:"true"`
-	OrderProducts []OrderProduct  `gorm:"-" json:"order_products" `
+	ID              uint            `gorm:"primaryKey" json:"id"`
+	TableID         uint            `json:"table_id" binding:"required"`
+	CustomerName    string          `json:"customer_name,omitempty" binding:"required"`
+	Status          string          `json:"status"`
+	TotalAmount     float64         `json:"total_amount"`
+	Tax             float64         `json:"tax"`
+	PaymentMethod   uint            `json:"payment_method"`
+	ReservationID   uint            `json:"reservation_id,omitempty"`
+	Credit          float64         `json:"credit"`
+	CustomerID      uint            `json:"customer_id,omitempty"`
+	CustomerPhone   string          `gorm:"-" json:"customer_phone,omitempty"`
+	CustomerEmail   string          `gorm:"-" json:"customer_email,omitempty"`
+	RedeemPoints    int             `gorm:"-" json:"redeem_points,omitempty"`
+	Discount        float64         `json:"discount"`
+	PointsEarned    int             `json:"points_earned"`
+	PointsRedeemed  int             `json:"points_redeemed"`
+	DrawerSessionID uint            `gorm:"index" json:"drawer_session_id,omitempty"`
+	CreatedBy       uint            `gorm:"index" json:"created_by,omitempty"`
+	ServedBy        uint            `gorm:"index" json:"served_by,omitempty"`
+	ActorID         uint            `gorm:"-" json:"-"`
+	ActorRole       string          `gorm:"-" json:"-"`
+	OverrideMenu    bool            `gorm:"-" json:"override_menu,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       *gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggerignore:"true"`
+	OrderProducts   []OrderProduct  `gorm:"-" json:"order_products" `
 }
 
 type OrderResponse struct {
@@ -25,6 +40,7 @@ type OrderResponse struct {
 	CustomerName string                 `json:"customer_name"`
 	TableID      int                    `json:"table_id"`
 	Status       string                 `json:"status"`
+	TotalAmount  float64                `json:"total_amount,omitempty"`
 	OrderDate    time.Time              `json:"order_date"`
 	SubTotal     int                    `json:"sub_total"`
 	OrderProduct []OrderProductResponse `json:"order_products" gorm:"-"`
@@ -1,10 +1,15 @@
 package model
 
+// OrderProduct is a line of an order. A scanner can give the product by
+// Barcode instead of ProductID. A bundle's line gives the option picked in
+// each of its choice groups, by group name.
 type OrderProduct struct {
-	ID        uint `gorm:"primaryKey" json:"id"`
-	OrderID   uint `json:"order_id"`
-	ProductID uint `json:"product_id"`
-	Qty       int  `json:"qty"`
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	OrderID   uint            `json:"order_id"`
+	ProductID uint            `json:"product_id"`
+	Barcode   string          `gorm:"-" json:"barcode,omitempty"`
+	Qty       int             `json:"qty"`
+	Choices   map[string]uint `gorm:"-" json:"choices,omitempty"`
 }
 
 func SeedOrderProducts() []OrderProduct {
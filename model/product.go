@@ -1,46 +1,123 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
+// Product is an item on sale. Qty is materialised from the stock_movements
+// ledger and only changes through it; Stock is the status derived from Qty.
 type Product struct {
-	ID         uint       `gorm:"primaryKey" json:"id"`
-	ImageURL   string     `json:"image_url" form:"image_url"`
-	Name       string     `json:"name" form:"name"`
-	ItemID     string     `json:"item_id" form:"item_id"`
-	Stock      string     `json:"stock" form:"stock"`
-	CategoryID uint       `json:"category_id" form:"category_id"`
-	Qty        int        `json:"qty" form:"qty"`
-	Price      float64    `json:"price" form:"price"`
-	Status     string     `json:"status" form:"status"`
-	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt  *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	ImageURL      string           `json:"image_url" form:"image_url"`
+	ImageVariants ImageVariants    `gorm:"serializer:json" json:"image_variants" form:"-"`
+	Name          string           `json:"name" form:"name"`
+	ItemID        string           `json:"item_id" form:"item_id"`
+	Stock         string           `gorm:"-" json:"stock"`
+	CategoryID    uint             `json:"category_id" form:"category_id"`
+	Qty           int              `json:"qty" form:"-"`
+	Price         float64          `json:"price" form:"price"`
+	CostPrice     float64          `json:"cost_price" form:"cost_price"`
+	SupplierID    uint             `json:"supplier_id,omitempty" form:"supplier_id"`
+	ReorderQty    int              `json:"reorder_qty" form:"reorder_qty"`
+	ReorderPoint  int              `json:"reorder_point" form:"reorder_point"`
+	Status        string           `json:"status" form:"status"`
+	EightySixed   bool             `json:"eighty_sixed" form:"-"`
+	CreatedAt     time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt     *time.Time       `gorm:"index" json:"deleted_at,omitempty"`
+	Barcodes      []ProductBarcode `gorm:"foreignKey:ProductID" json:"barcodes,omitempty" form:"-"`
+}
+
+// ProductUnavailable is the status a product is given while it is out of
+// stock, when it was available before.
+const ProductUnavailable = "unavailable"
+
+// SetStockStatus derives Stock from Qty against the product's low-stock
+// level, lowStock for a product without a reorder point.
+func (p *Product) SetStockStatus(lowStock int) {
+	p.Stock = StockStatus(p.Qty, p.LowStockLevel(lowStock))
+}
+
+// SetStockStatuses derives Stock for each of products.
+func SetStockStatuses(products []Product, lowStock int) {
+	for i := range products {
+		products[i].SetStockStatus(lowStock)
+	}
+}
+
+// LowStockLevel is the product's ReorderPoint, or lowStock for a product
+// without one. Stock below it is low.
+func (p Product) LowStockLevel(lowStock int) int {
+	if p.ReorderPoint > 0 {
+		return p.ReorderPoint
+	}
+	return lowStock
+}
+
+// ReorderQuantity is how much to order when the product runs low: its
+// ReorderQty, or else enough to bring it to twice its low-stock level.
+func (p Product) ReorderQuantity(lowStock int) int {
+	if p.ReorderQty > 0 {
+		return p.ReorderQty
+	}
+	return max(2*p.LowStockLevel(lowStock)-p.Qty, 1)
+}
+
+// ActiveStatuses are the product statuses, in lower case, that let a
+// product be sold.
+var ActiveStatuses = []string{"available", "active"}
+
+// Available reports whether the product's status lets it be sold.
+func (p Product) Available() bool {
+	for _, status := range ActiveStatuses {
+		if strings.EqualFold(p.Status, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProductFilter narrows and orders the product listing. Search matches
+// name and item_id; Stock is one of the stock statuses, against LowStock
+// for products without a reorder point; Sort is name, price, created_at or
+// popularity, ascending unless Order is desc.
+type ProductFilter struct {
+	Search     string
+	CategoryID uint
+	Status     string
+	MinPrice   *float64
+	MaxPrice   *float64
+	Stock      string
+	LowStock   int
+	Sort       string
+	Order      string
+	Page       int
+	Limit      int
 }
 
 func SeedProducts() []Product {
 	now := time.Now()
 	return []Product{
-		{ImageURL: "https://example.com/image1.jpg", Name: "Product 1", ItemID: "P001", Stock: "in stock", CategoryID: 1, Qty: 10, Price: 100.50, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image2.jpg", Name: "Product 2", ItemID: "P002", Stock: "out of stock", CategoryID: 2, Qty: 5, Price: 150.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image3.jpg", Name: "Product 3", ItemID: "P003", Stock: "in stock", CategoryID: 3, Qty: 8, Price: 75.75, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image4.jpg", Name: "Product 4", ItemID: "P004", Stock: "limited stock", CategoryID: 4, Qty: 2, Price: 50.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image5.jpg", Name: "Product 5", ItemID: "P005", Stock: "in stock", CategoryID: 5, Qty: 12, Price: 120.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image6.jpg", Name: "Product 6", ItemID: "P006", Stock: "out of stock", CategoryID: 1, Qty: 3, Price: 60.75, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image7.jpg", Name: "Product 7", ItemID: "P007", Stock: "limited stock", CategoryID: 2, Qty: 6, Price: 85.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image8.jpg", Name: "Product 8", ItemID: "P008", Stock: "in stock", CategoryID: 3, Qty: 10, Price: 95.99, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image9.jpg", Name: "Product 9", ItemID: "P009", Stock: "out of stock", CategoryID: 4, Qty: 4, Price: 40.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image10.jpg", Name: "Product 10", ItemID: "P010", Stock: "in stock", CategoryID: 5, Qty: 15, Price: 130.25, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image11.jpg", Name: "Product 11", ItemID: "P011", Stock: "in stock", CategoryID: 1, Qty: 18, Price: 200.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image12.jpg", Name: "Product 12", ItemID: "P012", Stock: "out of stock", CategoryID: 2, Qty: 8, Price: 140.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image13.jpg", Name: "Product 13", ItemID: "P013", Stock: "limited stock", CategoryID: 3, Qty: 6, Price: 80.50, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image14.jpg", Name: "Product 14", ItemID: "P014", Stock: "limited stock", CategoryID: 4, Qty: 3, Price: 30.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image15.jpg", Name: "Product 15", ItemID: "P015", Stock: "in stock", CategoryID: 5, Qty: 12, Price: 125.75, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image16.jpg", Name: "Product 16", ItemID: "P016", Stock: "out of stock", CategoryID: 1, Qty: 2, Price: 35.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image17.jpg", Name: "Product 17", ItemID: "P017", Stock: "in stock", CategoryID: 2, Qty: 10, Price: 90.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image18.jpg", Name: "Product 18", ItemID: "P018", Stock: "in stock", CategoryID: 3, Qty: 9, Price: 115.99, Status: "Active", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image19.jpg", Name: "Product 19", ItemID: "P019", Stock: "limited stock", CategoryID: 4, Qty: 1, Price: 20.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
-		{ImageURL: "https://example.com/image20.jpg", Name: "Product 20", ItemID: "P020", Stock: "in stock", CategoryID: 5, Qty: 14, Price: 150.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image1.jpg", Name: "Product 1", ItemID: "P001", CategoryID: 1, Qty: 10, Price: 100.50, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image2.jpg", Name: "Product 2", ItemID: "P002", CategoryID: 2, Qty: 5, Price: 150.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image3.jpg", Name: "Product 3", ItemID: "P003", CategoryID: 3, Qty: 8, Price: 75.75, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image4.jpg", Name: "Product 4", ItemID: "P004", CategoryID: 4, Qty: 2, Price: 50.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image5.jpg", Name: "Product 5", ItemID: "P005", CategoryID: 5, Qty: 12, Price: 120.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image6.jpg", Name: "Product 6", ItemID: "P006", CategoryID: 1, Qty: 3, Price: 60.75, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image7.jpg", Name: "Product 7", ItemID: "P007", CategoryID: 2, Qty: 6, Price: 85.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image8.jpg", Name: "Product 8", ItemID: "P008", CategoryID: 3, Qty: 10, Price: 95.99, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image9.jpg", Name: "Product 9", ItemID: "P009", CategoryID: 4, Qty: 4, Price: 40.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image10.jpg", Name: "Product 10", ItemID: "P010", CategoryID: 5, Qty: 15, Price: 130.25, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image11.jpg", Name: "Product 11", ItemID: "P011", CategoryID: 1, Qty: 18, Price: 200.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image12.jpg", Name: "Product 12", ItemID: "P012", CategoryID: 2, Qty: 8, Price: 140.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image13.jpg", Name: "Product 13", ItemID: "P013", CategoryID: 3, Qty: 6, Price: 80.50, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image14.jpg", Name: "Product 14", ItemID: "P014", CategoryID: 4, Qty: 3, Price: 30.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image15.jpg", Name: "Product 15", ItemID: "P015", CategoryID: 5, Qty: 12, Price: 125.75, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image16.jpg", Name: "Product 16", ItemID: "P016", CategoryID: 1, Qty: 2, Price: 35.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image17.jpg", Name: "Product 17", ItemID: "P017", CategoryID: 2, Qty: 10, Price: 90.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image18.jpg", Name: "Product 18", ItemID: "P018", CategoryID: 3, Qty: 9, Price: 115.99, Status: "Active", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image19.jpg", Name: "Product 19", ItemID: "P019", CategoryID: 4, Qty: 1, Price: 20.00, Status: "Inactive", CreatedAt: now, UpdatedAt: now},
+		{ImageURL: "https://example.com/image20.jpg", Name: "Product 20", ItemID: "P020", CategoryID: 5, Qty: 14, Price: 150.00, Status: "Active", CreatedAt: now, UpdatedAt: now},
 	}
 }
@@ -0,0 +1,35 @@
+package model
+
+// ProductColumns are the columns of a product import or export file, in
+// export order. A row is matched to a product by id, then by item_id. The
+// category is given by category_id, or else by its path of names, parents
+// first, separated by " / ". Barcodes are separated by spaces.
+var ProductColumns = []string{"id", "item_id", "name", "category_id", "category", "price", "cost_price", "qty", "status", "supplier_id", "reorder_qty", "reorder_point", "image_url", "barcodes"}
+
+// ProductImportRow is one row of an import file. Given holds the columns
+// that had a value: a blank cell leaves the field as it is on an existing
+// product and takes its default on a new one.
+type ProductImportRow struct {
+	Row      int
+	Category string
+	Barcodes []string
+	Product  Product
+	Given    map[string]bool
+}
+
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportResult tells what an import did, or would do in a dry run.
+// Nothing is written when there are errors.
+type ProductImportResult struct {
+	DryRun    bool                 `json:"dryRun"`
+	Rows      int                  `json:"rows"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Errors    []ProductImportError `json:"errors"`
+}
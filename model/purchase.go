@@ -0,0 +1,124 @@
+package model
+
+import "time"
+
+type Supplier struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name"`
+	ContactName  string    `json:"contactName,omitempty"`
+	PhoneNumber  string    `json:"phoneNumber,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	Address      string    `json:"address,omitempty"`
+	LeadTimeDays int       `json:"leadTimeDays"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type FormSupplier struct {
+	Name         string `json:"name" binding:"required"`
+	ContactName  string `json:"contactName"`
+	PhoneNumber  string `json:"phoneNumber"`
+	Email        string `json:"email" binding:"omitempty,email"`
+	Address      string `json:"address"`
+	LeadTimeDays int    `json:"leadTimeDays" binding:"min=0"`
+}
+
+// PurchaseOrder is stock ordered from a supplier. It is drafted, sent to
+// the supplier as ordered, and received in one or more goods receipts;
+// it is partial until every line has been received in full.
+type PurchaseOrder struct {
+	ID         uint                `gorm:"primaryKey" json:"id"`
+	SupplierID uint                `gorm:"index" json:"supplierId"`
+	Supplier   *Supplier           `json:"supplier,omitempty"`
+	Status     string              `gorm:"default:'draft'" json:"status"`
+	Notes      string              `json:"notes,omitempty"`
+	Auto       bool                `json:"auto"`
+	ExpectedAt *time.Time          `json:"expectedAt,omitempty"`
+	OrderedAt  *time.Time          `json:"orderedAt,omitempty"`
+	ReceivedAt *time.Time          `json:"receivedAt,omitempty"`
+	CreatedBy  uint                `json:"createdBy,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	UpdatedAt  time.Time           `json:"updatedAt"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+	Receipts   []GoodsReceipt      `json:"receipts,omitempty"`
+}
+
+// PurchaseOrderLine is a product on a purchase order at its expected unit
+// cost. ReceivedQty adds up the goods receipts against it.
+type PurchaseOrderLine struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID uint    `gorm:"index" json:"purchaseOrderId"`
+	ProductID       uint    `json:"productId"`
+	Qty             int     `json:"qty"`
+	ReceivedQty     int     `json:"receivedQty"`
+	UnitCost        float64 `json:"unitCost"`
+}
+
+// Outstanding is what is still to be received on the line.
+func (l PurchaseOrderLine) Outstanding() int {
+	return max(l.Qty-l.ReceivedQty, 0)
+}
+
+// GoodsReceipt is one delivery against a purchase order. Each of its lines
+// is posted to the stock ledger as a restock at the unit cost paid.
+type GoodsReceipt struct {
+	ID              uint               `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID uint               `gorm:"index" json:"purchaseOrderId"`
+	Note            string             `json:"note,omitempty"`
+	ReceivedBy      uint               `json:"receivedBy,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	Lines           []GoodsReceiptLine `json:"lines"`
+}
+
+type GoodsReceiptLine struct {
+	ID                  uint    `gorm:"primaryKey" json:"id"`
+	GoodsReceiptID      uint    `gorm:"index" json:"goodsReceiptId"`
+	PurchaseOrderLineID uint    `json:"purchaseOrderLineId"`
+	ProductID           uint    `json:"productId"`
+	Qty                 int     `json:"qty"`
+	UnitCost            float64 `json:"unitCost"`
+}
+
+const (
+	PurchaseDraft    = "draft"
+	PurchaseOrdered  = "ordered"
+	PurchasePartial  = "partial"
+	PurchaseReceived = "received"
+	PurchaseCanceled = "canceled"
+)
+
+// PurchaseOpen are the statuses of purchase orders still to be received.
+var PurchaseOpen = []string{PurchaseDraft, PurchaseOrdered, PurchasePartial}
+
+type FormPurchaseOrder struct {
+	SupplierID uint                    `json:"supplierId" binding:"required"`
+	Notes      string                  `json:"notes"`
+	ExpectedAt *time.Time              `json:"expectedAt"`
+	Lines      []FormPurchaseOrderLine `json:"lines" binding:"required,min=1,dive"`
+}
+
+type FormPurchaseOrderLine struct {
+	ProductID uint    `json:"productId" binding:"required"`
+	Qty       int     `json:"qty" binding:"required,min=1"`
+	UnitCost  float64 `json:"unitCost" binding:"min=0"`
+}
+
+// FormGoodsReceipt records a delivery. A line without a unit cost was
+// paid at the cost expected on the purchase order.
+type FormGoodsReceipt struct {
+	Note  string                 `json:"note"`
+	Lines []FormGoodsReceiptLine `json:"lines" binding:"required,min=1,dive"`
+}
+
+type FormGoodsReceiptLine struct {
+	LineID   uint     `json:"lineId" binding:"required"`
+	Qty      int      `json:"qty" binding:"required,min=1"`
+	UnitCost *float64 `json:"unitCost" binding:"omitempty,min=0"`
+}
+
+type PurchaseFilter struct {
+	Status     string
+	SupplierID uint
+	Page       int
+	Limit      int
+}
@@ -1,24 +1,140 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Reservation struct {
-	ID              uint      `gorm:"primaryKey" json:"id,omitempty"`
-	TableNumber     uint      `json:"tableNumber,omitempty"`
-	Pax             int       `json:"pax,omitempty"`
-	Date            string    `gorm:"-" json:"date,omitempty"`
-	Time            string    `gorm:"-" json:"time,omitempty"`
-	ReservationDate time.Time `gorm:"type:timestamp" json:"-"`
-	DepositFee      int       `json:"depositFee,omitempty"`
-	Status          string    `json:"status,omitempty" gorm:"default:'Confirmed'"`
-	Title           string    `json:"title,omitempty"`
-	Fullname        string    `json:"fullName,omitempty"`
-	PhoneNumber     string    `json:"phoneNumber,omitempty"`
-	Email           string    `json:"email,omitempty"`
+	ID               uint       `gorm:"primaryKey" json:"id,omitempty"`
+	TableNumber      uint       `json:"tableNumber,omitempty"`
+	Pax              int        `json:"pax,omitempty"`
+	Date             string     `gorm:"-" json:"date,omitempty"`
+	Time             string     `gorm:"-" json:"time,omitempty"`
+	ReservationDate  time.Time  `gorm:"type:timestamp;index" json:"-"`
+	Duration         int        `json:"duration,omitempty" gorm:"default:90"`
+	DepositFee       int        `json:"depositFee,omitempty"`
+	DepositStatus    string     `json:"depositStatus,omitempty" gorm:"default:'Unpaid'"`
+	DepositPayment   uint       `json:"depositPayment,omitempty"`
+	DepositPaidAt    *time.Time `json:"depositPaidAt,omitempty"`
+	DepositSettledAt *time.Time `json:"depositSettledAt,omitempty"`
+	Status           string     `json:"status,omitempty" gorm:"default:'Confirmed'"`
+	OrderID          uint       `json:"orderId,omitempty"`
+	Title            string     `json:"title,omitempty"`
+	Fullname         string     `json:"fullName,omitempty"`
+	PhoneNumber      string     `json:"phoneNumber,omitempty"`
+	Email            string     `json:"email,omitempty"`
+	CustomerID       uint       `json:"customerId,omitempty"`
 }
+type FormDeposit struct {
+	PaymentMethod uint `json:"paymentMethod" binding:"required"`
+}
+
+// ReservationFilter narrows the reservation listing. StartDate and EndDate
+// are inclusive days (YYYY-MM-DD); Search matches name, phone or email. A
+// Limit of zero returns every match.
+type ReservationFilter struct {
+	StartDate string
+	EndDate   string
+	Status    []string
+	Search    string
+	Sort      string
+	Order     string
+	Page      int
+	Limit     int
+}
+
+// ReservationSortColumns maps the sort values accepted by the listing to
+// their columns.
+var ReservationSortColumns = map[string]string{
+	"date":      "reservation_date",
+	"name":      "fullname",
+	"pax":       "pax",
+	"table":     "table_number",
+	"status":    "status",
+	"createdAt": "id",
+}
+
 type FormUpdate struct {
 	TableNumber uint
 	Status      string
+	Duration    int
+}
+
+const (
+	ReservationPending   = "Pending"
+	ReservationConfirmed = "Confirmed"
+	ReservationSeated    = "Seated"
+	ReservationCompleted = "Completed"
+	ReservationNoShow    = "No-Show"
+	ReservationCancelled = "Cancelled"
+)
+
+const (
+	DepositNone      = "None"
+	DepositUnpaid    = "Unpaid"
+	DepositPaid      = "Paid"
+	DepositApplied   = "Applied"
+	DepositRefunded  = "Refunded"
+	DepositForfeited = "Forfeited"
+)
+
+// ReservationStatuses lists every reservation status.
+var ReservationStatuses = []string{
+	ReservationPending, ReservationConfirmed, ReservationSeated,
+	ReservationCompleted, ReservationNoShow, ReservationCancelled,
+}
+
+// ReservationTransitions lists, for each status, the statuses a reservation
+// may move to next. Completed, No-Show and Cancelled are final.
+var ReservationTransitions = map[string][]string{
+	ReservationPending:   {ReservationConfirmed, ReservationCancelled},
+	ReservationConfirmed: {ReservationSeated, ReservationNoShow, ReservationCancelled},
+	ReservationSeated:    {ReservationCompleted},
+}
+
+// ReservationReleasedStatuses are the statuses of reservations that no longer
+// hold their table.
+var ReservationReleasedStatuses = []string{ReservationCompleted, ReservationNoShow, ReservationCancelled}
+
+// HoldsTable reports whether the reservation still blocks its table.
+func (r Reservation) HoldsTable() bool {
+	for _, released := range ReservationReleasedStatuses {
+		if strings.EqualFold(r.Status, released) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanTransition reports whether a reservation in status from may move to to.
+func CanTransition(from, to string) bool {
+	for _, next := range ReservationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultReservationDuration returns how long a table is held, in minutes,
+// when the reservation does not specify a duration.
+func DefaultReservationDuration(pax int) int {
+	switch {
+	case pax <= 2:
+		return 60
+	case pax <= 4:
+		return 90
+	case pax <= 8:
+		return 120
+	default:
+		return 150
+	}
+}
+
+// EndDate returns the time the table is released by this reservation.
+func (r Reservation) EndDate() time.Time {
+	return r.ReservationDate.Add(time.Duration(r.Duration) * time.Minute)
 }
 
 //	func SeedReservations() []Reservation {
@@ -35,3 +151,32 @@ func SeedReservations() []Reservation {
 		{TableNumber: 1, Pax: 5, ReservationDate: time.Date(2024, time.December, 22, 13, 12, 0, 0, time.UTC), DepositFee: 60, Title: "Mr", Fullname: "Will Smith", PhoneNumber: "086566156", Email: "willsmith@mail.com"},
 	}
 }
+
+// TimeSlot is a free window on a table, in "15:04" format. Any start time
+// between Start and End minus the requested duration can be booked.
+type TimeSlot struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type TableAvailability struct {
+	TableIDs []uint     `json:"tableIds"`
+	Names    []string   `json:"names"`
+	Capacity int        `json:"capacity"`
+	Slots    []TimeSlot `json:"slots"`
+}
+
+type ReservationAvailability struct {
+	Date         string              `json:"date"`
+	Pax          int                 `json:"pax"`
+	Duration     int                 `json:"duration"`
+	Tables       []TableAvailability `json:"tables"`
+	Combinations []TableAvailability `json:"combinations,omitempty"`
+}
+
+// WallClockNow returns the current local wall-clock time as UTC, the way
+// reservation dates are stored.
+func WallClockNow() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+}
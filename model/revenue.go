@@ -29,6 +29,7 @@ type ProductRevenue struct {
 	Profit       float64   `gorm:"type:decimal(10,2)" json:"profit" binding:"required" example:"7985.00"`
 	ProfitMargin float64   `gorm:"type:decimal(5,2)" json:"profit_margin" binding:"required" example:"15.00"`
 	TotalRevenue float64   `gorm:"type:decimal(10,2)" json:"total_revenue" binding:"required" example:"8000.00"`
+	FoodCost     float64   `gorm:"type:decimal(10,2)" json:"food_cost" example:"1500.00"`
 	RevenueDate  time.Time `gorm:"type:date" json:"revenue_date" binding:"required" example:"2024-03-28"`
 }
 
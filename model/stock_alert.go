@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// StockAlert is raised when a product, or an ingredient, falls below its
+// low-stock level and stays open until stock is back at or above it, so
+// each shortage is notified once. Level escalates from low to out when it
+// runs out. PreviousStatus holds the status a product had before it was
+// made unavailable for being out of stock, to be restored once it is back.
+type StockAlert struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ProductID      uint       `gorm:"index" json:"productId,omitempty"`
+	IngredientID   uint       `gorm:"index" json:"ingredientId,omitempty"`
+	Level          string     `json:"level"`
+	Qty            float64    `json:"qty"`
+	ReorderPoint   float64    `json:"reorderPoint"`
+	PreviousStatus string     `json:"previousStatus,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	ResolvedAt     *time.Time `gorm:"index" json:"resolvedAt,omitempty"`
+}
+
+const (
+	AlertLow = "low"
+	AlertOut = "out"
+)
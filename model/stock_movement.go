@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// StockMovement is one change to a product's stock. Qty is signed: sales,
+// waste and outgoing transfers are negative, restocks, voids and refunds
+// positive. Balance is the product's stock after the movement, and the
+// product's qty always equals the balance of its latest movement.
+type StockMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"index" json:"productId"`
+	Type      string    `json:"type"`
+	Qty       int       `json:"qty"`
+	Balance   int       `json:"balance"`
+	UnitCost  float64   `json:"unitCost,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	ActorID   uint      `json:"actorId,omitempty"`
+	OrderID   uint      `gorm:"index" json:"orderId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const (
+	MovementSale       = "sale"
+	MovementVoid       = "void"
+	MovementRefund     = "refund"
+	MovementRestock    = "restock"
+	MovementAdjustment = "adjustment"
+	MovementWaste      = "waste"
+	MovementTransfer   = "transfer"
+)
+
+const (
+	StockIn      = "in stock"
+	StockLimited = "limited stock"
+	StockOut     = "out of stock"
+)
+
+// StockStatus describes a stock level: out of stock at zero or below,
+// limited below lowStock, in stock otherwise.
+func StockStatus(qty, lowStock int) string {
+	switch {
+	case qty <= 0:
+		return StockOut
+	case qty < lowStock:
+		return StockLimited
+	default:
+		return StockIn
+	}
+}
+
+// FormStockMovement records a stock change made by hand. Restock and waste
+// take a positive quantity; adjustment and transfer are signed, negative
+// for stock going out.
+type FormStockMovement struct {
+	Type   string `json:"type" binding:"required,oneof=restock adjustment waste transfer"`
+	Qty    int    `json:"qty" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+func SeedStockMovements() []StockMovement {
+	movements := []StockMovement{}
+	for i, product := range SeedProducts() {
+		movements = append(movements, StockMovement{
+			ProductID: uint(i + 1),
+			Type:      MovementRestock,
+			Qty:       product.Qty,
+			Balance:   product.Qty,
+			Reason:    "Opening stock",
+			CreatedAt: product.CreatedAt,
+		})
+	}
+	return movements
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stocktake is a physical count of some or all products. Opening it
+// snapshots each product's system qty into its lines; committing it posts
+// the variance of every counted line as an adjustment movement.
+type Stocktake struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Status      string          `gorm:"default:open" json:"status"`
+	Note        string          `json:"note,omitempty"`
+	OpenedBy    uint            `json:"openedBy,omitempty"`
+	CommittedBy uint            `json:"committedBy,omitempty"`
+	CommittedAt *time.Time      `json:"committedAt,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	Lines       []StocktakeLine `json:"lines,omitempty"`
+}
+
+// StocktakeLine is one product to count. CountedQty is the sum of the
+// counts from every device; Variance is how far it is from SystemQty.
+// AdjustedQty is what committing posted, which can be less than the
+// variance when stock was sold meanwhile.
+type StocktakeLine struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	StocktakeID   uint    `gorm:"index" json:"stocktakeId"`
+	ProductID     uint    `json:"productId"`
+	ItemID        string  `json:"itemId"`
+	ProductName   string  `json:"productName"`
+	CategoryID    uint    `json:"categoryId"`
+	SystemQty     int     `json:"systemQty"`
+	Counted       bool    `json:"counted"`
+	CountedQty    int     `json:"countedQty"`
+	Variance      int     `json:"variance"`
+	UnitCost      float64 `json:"unitCost"`
+	VarianceValue float64 `gorm:"-" json:"varianceValue"`
+	AdjustedQty   int     `json:"adjustedQty"`
+}
+
+func (l *StocktakeLine) AfterFind(tx *gorm.DB) error {
+	l.VarianceValue = float64(l.Variance) * l.UnitCost
+	return nil
+}
+
+// StocktakeCount is what one device counted of a product. Counting the
+// same product again from the same device replaces its earlier count.
+type StocktakeCount struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StocktakeID uint      `gorm:"uniqueIndex:idx_stocktake_count" json:"stocktakeId"`
+	ProductID   uint      `gorm:"uniqueIndex:idx_stocktake_count" json:"productId"`
+	Device      string    `gorm:"uniqueIndex:idx_stocktake_count" json:"device"`
+	Qty         int       `json:"qty"`
+	CountedBy   uint      `json:"countedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+const (
+	StocktakeOpen      = "open"
+	StocktakeCommitted = "committed"
+	StocktakeCanceled  = "canceled"
+)
+
+// FormStocktake opens a count of the given categories, or of every
+// product when none are given.
+type FormStocktake struct {
+	CategoryIDs []uint `json:"categoryIds"`
+	Note        string `json:"note"`
+}
+
+// FormStocktakeCount is a batch of counts from one device, such as a
+// handheld scanner or a tablet.
+type FormStocktakeCount struct {
+	Device string               `json:"device" binding:"required"`
+	Counts []FormStocktakeEntry `json:"counts" binding:"required,min=1,dive"`
+}
+
+type FormStocktakeEntry struct {
+	ProductID uint `json:"productId" binding:"required"`
+	Qty       *int `json:"qty" binding:"required,min=0"`
+}
+
+type StocktakeReportExcel struct {
+	No            int
+	ItemId        string
+	ProductName   string
+	SystemQty     int
+	Counted       bool
+	CountedQty    int
+	Variance      int
+	UnitCost      float64
+	VarianceValue float64
+	AdjustedQty   int
+}
@@ -10,6 +10,7 @@ type Table struct {
 	ID        uint            `gorm:"primaryKey" json:"id"`
 	Name      string          `gorm:"type:varchar(255);not null" json:"name"`
 	IsBook    bool            `json:"is_book"`
+	Capacity  int             `gorm:"default:4" json:"capacity"`
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
 	DeletedAt *gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggerignore:"true"`
@@ -17,25 +18,25 @@ type Table struct {
 
 func SeedTables() []Table {
 	return []Table{
-		{Name: "Book A", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 1", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book B", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 2", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book C", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 3", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book D", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 4", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book E", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 5", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book F", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 6", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book G", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 7", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book H", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 8", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book I", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 9", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Book J", IsBook: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{Name: "Table 10", IsBook: false, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book A", IsBook: true, Capacity: 2, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 1", IsBook: false, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book B", IsBook: true, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 2", IsBook: false, Capacity: 6, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book C", IsBook: true, Capacity: 2, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 3", IsBook: false, Capacity: 8, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book D", IsBook: true, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 4", IsBook: false, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book E", IsBook: true, Capacity: 6, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 5", IsBook: false, Capacity: 2, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book F", IsBook: true, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 6", IsBook: false, Capacity: 2, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book G", IsBook: true, Capacity: 8, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 7", IsBook: false, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book H", IsBook: true, Capacity: 6, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 8", IsBook: false, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book I", IsBook: true, Capacity: 2, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 9", IsBook: false, Capacity: 4, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Book J", IsBook: true, Capacity: 10, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{Name: "Table 10", IsBook: false, Capacity: 6, CreatedAt: time.Now(), UpdatedAt: time.Now()},
 	}
 }
@@ -24,6 +24,12 @@ type User struct {
 	DeletedAt *gorm.DeletedAt `gorm:"index"`
 }
 
+// IsManager reports whether role may override what staff are held to,
+// such as selling an item off its menu.
+func IsManager(role string) bool {
+	return role == "admin" || role == "super_admin"
+}
+
 type Session struct {
 	ID           int `gorm:"primaryKey;autoIncrement" json:"id"`
 	UserID       int `gorm:"type:int"`
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Waitlist is a walk-in party queued for the next suitable table.
+// QuotedWait is the wait in minutes quoted when the party joined.
+type Waitlist struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Fullname    string     `json:"fullName" binding:"required"`
+	PhoneNumber string     `json:"phoneNumber" binding:"required"`
+	Pax         int        `json:"pax" binding:"required,min=1"`
+	Status      string     `json:"status" gorm:"default:'Waiting'"`
+	QuotedWait  int        `json:"quotedWait"`
+	TableID     uint       `json:"tableId,omitempty"`
+	OrderID     uint       `json:"orderId,omitempty"`
+	CalledAt    *time.Time `json:"calledAt,omitempty"`
+	SeatedAt    *time.Time `json:"seatedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type FormWaitlistSeat struct {
+	TableID uint `json:"tableId"`
+}
+
+// WaitQuote is the estimated wait for a party of Pax guests.
+type WaitQuote struct {
+	Pax          int `json:"pax"`
+	PartiesAhead int `json:"partiesAhead"`
+	Minutes      int `json:"minutes"`
+}
+
+// TableOccupancy is a table with the time its current guests sat down.
+// SeatedAt is nil when the table is free.
+type TableOccupancy struct {
+	Table
+	SeatedAt *time.Time
+}
+
+const (
+	WaitlistWaiting = "Waiting"
+	WaitlistCalled  = "Called"
+	WaitlistSeated  = "Seated"
+	WaitlistRemoved = "Removed"
+)
+
+// WaitlistQueued are the statuses of parties still waiting for a table.
+var WaitlistQueued = []string{WaitlistWaiting, WaitlistCalled}
+
+// InQueue reports whether the party is still waiting for a table.
+func (w Waitlist) InQueue() bool {
+	return w.Status == WaitlistWaiting || w.Status == WaitlistCalled
+}
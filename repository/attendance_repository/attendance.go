@@ -0,0 +1,325 @@
+package attendancerepository
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryAttendance interface {
+	FindEmployee(userID uint) (*model.Employee, error)
+	FindEmployees(employeeID uint) ([]model.Employee, error)
+	ClockIn(employeeID uint, at time.Time) (*model.TimeEntry, error)
+	ClockOut(employeeID uint, at time.Time) (*model.TimeEntry, error)
+	StartBreak(employeeID uint, at time.Time) (*model.TimeEntry, error)
+	EndBreak(employeeID uint, at time.Time) (*model.TimeEntry, error)
+	FindEntries(from, to time.Time, employeeID uint) ([]model.TimeEntry, error)
+	FindShifts(from, to time.Time, employeeID uint) ([]model.Shift, error)
+	AddShifts(shifts []model.Shift) error
+	DeleteShift(id uint) error
+}
+
+var (
+	ErrNotEmployee     = errors.New(" Not An Employee")
+	ErrEmployeeMissing = errors.New(" Employee Not Found")
+	ErrClockedIn       = errors.New(" Already Clocked In")
+	ErrNotClockedIn    = errors.New(" Not Clocked In")
+	ErrOnBreak         = errors.New(" Already On Break")
+	ErrNotOnBreak      = errors.New(" Not On Break")
+	ErrShiftNotFound   = errors.New(" Shift Not Found")
+	ErrShiftOverlap    = errors.New(" Shift Overlaps Another")
+	ErrShiftWorked     = errors.New(" Shift Already Clocked In For")
+)
+
+// A clock-in is for the rostered shift that has not ended yet and starts
+// within this long.
+const shiftLookahead = 12 * time.Hour
+
+type repositoryAttendance struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewAttendanceRepository(db *gorm.DB, log *zap.Logger) RepositoryAttendance {
+	return &repositoryAttendance{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryAttendance) FindEmployee(userID uint) (*model.Employee, error) {
+	var employee model.Employee
+	err := r.DB.Where("user_id = ?", userID).First(&employee).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotEmployee
+	}
+	if err != nil {
+		r.Log.Error("Failed to find employee", zap.Uint("user", userID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &employee, nil
+}
+
+// FindEmployees returns every employee, or only the one given.
+func (r *repositoryAttendance) FindEmployees(employeeID uint) ([]model.Employee, error) {
+	query := r.DB.Order("name").Order("id")
+	if employeeID != 0 {
+		query = query.Where("id = ?", employeeID)
+	}
+	employees := []model.Employee{}
+	if err := query.Find(&employees).Error; err != nil {
+		r.Log.Error("Failed to find employees", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return employees, nil
+}
+
+// ClockIn starts a time entry, for the rostered shift it is early or late
+// for when there is one.
+func (r *repositoryAttendance) ClockIn(employeeID uint, at time.Time) (*model.TimeEntry, error) {
+	entry := model.TimeEntry{EmployeeID: employeeID, ClockIn: at}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.Employee{}, employeeID).Error; err != nil {
+			return err
+		}
+		var open int64
+		if err := tx.Model(&model.TimeEntry{}).Where("employee_id = ? AND clock_out IS NULL", employeeID).Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrClockedIn
+		}
+
+		var shift model.Shift
+		err := tx.Where("employee_id = ? AND end_at > ? AND start_at < ?", employeeID, at, at.Add(shiftLookahead)).
+			Where("NOT EXISTS (SELECT 1 FROM time_entries WHERE time_entries.shift_id = shifts.id)").
+			Order("start_at").First(&shift).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		entry.ShiftID = shift.ID
+		return tx.Create(&entry).Error
+	})
+	if err := r.failure("Failed to clock in", err); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ClockOut ends the open time entry, and its break if one is running.
+func (r *repositoryAttendance) ClockOut(employeeID uint, at time.Time) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if entry, err = lockOpenEntry(tx, employeeID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.TimeBreak{}).Where("entry_id = ? AND end_at IS NULL", entry.ID).Update("end_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Model(entry).Update("clock_out", at).Error
+	})
+	if err := r.failure("Failed to clock out", err); err != nil {
+		return nil, err
+	}
+	return r.findEntry(entry.ID)
+}
+
+func (r *repositoryAttendance) StartBreak(employeeID uint, at time.Time) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if entry, err = lockOpenEntry(tx, employeeID); err != nil {
+			return err
+		}
+		var running int64
+		if err := tx.Model(&model.TimeBreak{}).Where("entry_id = ? AND end_at IS NULL", entry.ID).Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return ErrOnBreak
+		}
+		return tx.Create(&model.TimeBreak{EntryID: entry.ID, StartAt: at}).Error
+	})
+	if err := r.failure("Failed to start break", err); err != nil {
+		return nil, err
+	}
+	return r.findEntry(entry.ID)
+}
+
+func (r *repositoryAttendance) EndBreak(employeeID uint, at time.Time) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if entry, err = lockOpenEntry(tx, employeeID); err != nil {
+			return err
+		}
+		result := tx.Model(&model.TimeBreak{}).Where("entry_id = ? AND end_at IS NULL", entry.ID).Update("end_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotOnBreak
+		}
+		return nil
+	})
+	if err := r.failure("Failed to end break", err); err != nil {
+		return nil, err
+	}
+	return r.findEntry(entry.ID)
+}
+
+// FindEntries returns the time entries clocked in from from until to,
+// of every employee or only the one given.
+func (r *repositoryAttendance) FindEntries(from, to time.Time, employeeID uint) ([]model.TimeEntry, error) {
+	query := r.DB.Preload("Breaks", func(db *gorm.DB) *gorm.DB { return db.Order("start_at") }).
+		Where("clock_in >= ? AND clock_in < ?", from, to)
+	if employeeID != 0 {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	entries := []model.TimeEntry{}
+	if err := query.Order("clock_in").Order("id").Find(&entries).Error; err != nil {
+		r.Log.Error("Failed to find time entries", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return entries, nil
+}
+
+// FindShifts returns the rostered shifts starting from from until to,
+// with the names of their employees.
+func (r *repositoryAttendance) FindShifts(from, to time.Time, employeeID uint) ([]model.Shift, error) {
+	query := r.DB.Where("start_at >= ? AND start_at < ?", from, to)
+	if employeeID != 0 {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	shifts := []model.Shift{}
+	if err := query.Order("start_at").Order("employee_id").Find(&shifts).Error; err != nil {
+		r.Log.Error("Failed to find shifts", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	if len(shifts) == 0 {
+		return shifts, nil
+	}
+
+	employeeIDs := []uint{}
+	for _, shift := range shifts {
+		if !slices.Contains(employeeIDs, shift.EmployeeID) {
+			employeeIDs = append(employeeIDs, shift.EmployeeID)
+		}
+	}
+	var employees []model.Employee
+	if err := r.DB.Select("id", "name").Where("id IN ?", employeeIDs).Find(&employees).Error; err != nil {
+		r.Log.Error("Failed to find employees", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	for i, shift := range shifts {
+		for _, employee := range employees {
+			if employee.ID == shift.EmployeeID {
+				shifts[i].EmployeeName = employee.Name
+			}
+		}
+	}
+	return shifts, nil
+}
+
+// AddShifts puts shifts on the roster. An employee cannot be on two
+// shifts at once.
+func (r *repositoryAttendance) AddShifts(shifts []model.Shift) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		employeeIDs := []uint{}
+		for _, shift := range shifts {
+			if !slices.Contains(employeeIDs, shift.EmployeeID) {
+				employeeIDs = append(employeeIDs, shift.EmployeeID)
+			}
+		}
+		var employees []model.Employee
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id IN ?", employeeIDs).Find(&employees).Error; err != nil {
+			return err
+		}
+		if len(employees) != len(employeeIDs) {
+			return ErrEmployeeMissing
+		}
+
+		for i, shift := range shifts {
+			for _, other := range shifts[:i] {
+				if other.EmployeeID == shift.EmployeeID && other.StartAt.Before(shift.EndAt) && shift.StartAt.Before(other.EndAt) {
+					return ErrShiftOverlap
+				}
+			}
+			var overlapping int64
+			if err := tx.Model(&model.Shift{}).
+				Where("employee_id = ? AND start_at < ? AND end_at > ?", shift.EmployeeID, shift.EndAt, shift.StartAt).
+				Count(&overlapping).Error; err != nil {
+				return err
+			}
+			if overlapping > 0 {
+				return ErrShiftOverlap
+			}
+		}
+		return tx.Create(&shifts).Error
+	})
+	return r.failure("Failed to add shifts", err)
+}
+
+// DeleteShift takes a shift off the roster, unless it was clocked in for.
+func (r *repositoryAttendance) DeleteShift(id uint) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var shift model.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		var worked int64
+		if err := tx.Model(&model.TimeEntry{}).Where("shift_id = ?", id).Count(&worked).Error; err != nil {
+			return err
+		}
+		if worked > 0 {
+			return ErrShiftWorked
+		}
+		return tx.Delete(&shift).Error
+	})
+	return r.failure("Failed to delete shift", err)
+}
+
+func (r *repositoryAttendance) findEntry(id uint) (*model.TimeEntry, error) {
+	var entry model.TimeEntry
+	if err := r.DB.Preload("Breaks", func(db *gorm.DB) *gorm.DB { return db.Order("start_at") }).First(&entry, id).Error; err != nil {
+		r.Log.Error("Failed to find time entry", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &entry, nil
+}
+
+func (r *repositoryAttendance) failure(message string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrClockedIn), errors.Is(err, ErrNotClockedIn), errors.Is(err, ErrOnBreak),
+		errors.Is(err, ErrNotOnBreak), errors.Is(err, ErrShiftNotFound), errors.Is(err, ErrShiftOverlap),
+		errors.Is(err, ErrShiftWorked), errors.Is(err, ErrEmployeeMissing):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrEmployeeMissing
+	default:
+		r.Log.Error(message, zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+}
+
+func lockOpenEntry(tx *gorm.DB, employeeID uint) (*model.TimeEntry, error) {
+	var entry model.TimeEntry
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("employee_id = ? AND clock_out IS NULL", employeeID).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotClockedIn
+	}
+	return &entry, err
+}
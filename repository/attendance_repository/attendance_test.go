@@ -0,0 +1,68 @@
+package attendancerepository_test
+
+import (
+	"project_pos_app/helper"
+	attendancerepository "project_pos_app/repository/attendance_repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestClockIn(t *testing.T) {
+	employeeQuery := `SELECT "id" FROM "employees" WHERE "employees"."id" = $1`
+	openQuery := `SELECT count(*) FROM "time_entries" WHERE employee_id = $1 AND clock_out IS NULL`
+	shiftQuery := `SELECT * FROM "shifts" WHERE (employee_id = $1 AND end_at > $2 AND start_at < $3) AND NOT EXISTS (SELECT 1 FROM time_entries WHERE time_entries.shift_id = shifts.id) ORDER BY start_at`
+	at := time.Date(2026, 10, 19, 9, 3, 0, 0, time.UTC)
+
+	t.Run("Cannot clock in twice", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := attendancerepository.NewAttendanceRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(employeeQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(openQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		entry, err := repo.ClockIn(3, at)
+
+		assert.ErrorIs(t, err, attendancerepository.ErrClockedIn)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("Clocking in takes up the rostered shift", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := attendancerepository.NewAttendanceRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(employeeQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(openQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(shiftQuery)).
+			WithArgs(3, at, at.Add(12*time.Hour), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "employee_id", "start_at", "end_at"}).
+				AddRow(8, 3, at.Add(-3*time.Minute), at.Add(8*time.Hour)))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "time_entries"`)).
+			WithArgs(3, 8, at, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectCommit()
+
+		entry, err := repo.ClockIn(3, at)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(21), entry.ID)
+		assert.Equal(t, uint(8), entry.ShiftID)
+	})
+}
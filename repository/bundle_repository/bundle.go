@@ -0,0 +1,239 @@
+package bundlerepository
+
+import (
+	"errors"
+	"fmt"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"slices"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryBundle interface {
+	FindBundle(productID uint) (*model.Bundle, error)
+	SaveBundle(productID uint, form model.FormBundle) (*model.Bundle, error)
+	HasBundle(productID uint) (bool, error)
+}
+
+var (
+	ErrInvalidComponent = errors.New(" A Bundle Cannot Contain Itself Or Another Bundle")
+	ErrBundleHasRecipe  = errors.New(" A Product With A Recipe Cannot Be A Bundle")
+	ErrBundleComponent  = errors.New(" A Component Of A Bundle Cannot Be A Bundle")
+	ErrChoiceMissing    = errors.New(" Bundle Choice Missing")
+	ErrInvalidChoice    = errors.New(" Not A Choice Of The Bundle")
+)
+
+type repositoryBundle struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewBundleRepository(db *gorm.DB, log *zap.Logger) RepositoryBundle {
+	return &repositoryBundle{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryBundle) FindBundle(productID uint) (*model.Bundle, error) {
+	var product model.Product
+	if err := r.DB.Select("id", "price").Where("deleted_at IS NULL").First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, stockrepository.ErrProductNotFound
+		}
+		r.Log.Error("Failed to find product", zap.Uint("product", productID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	bundle := model.Bundle{ProductID: productID, Price: product.Price, Items: []model.BundleItem{}}
+	if err := r.DB.Preload("Product").Where("bundle_id = ?", productID).Order("group_name").Order("id").Find(&bundle.Items).Error; err != nil {
+		r.Log.Error("Failed to find bundle", zap.Uint("product", productID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	// Like stockrepository.RefreshBundles: the fewest of any fixed item,
+	// the most of any option of a group.
+	slots := map[string]int{}
+	for _, item := range bundle.Items {
+		available := 0
+		if item.Product != nil && item.Product.DeletedAt == nil {
+			available = max(item.Product.Qty/item.Qty, 0)
+		}
+		key := item.GroupName
+		if key == "" {
+			key = fmt.Sprintf("#%d", item.ID)
+		}
+		if current, ok := slots[key]; !ok || available > current {
+			slots[key] = available
+		}
+	}
+	first := true
+	for _, available := range slots {
+		if first || available < bundle.Available {
+			bundle.Available = available
+		}
+		first = false
+	}
+	return &bundle, nil
+}
+
+// SaveBundle replaces a product's components and recomputes its
+// availability. Removing every item makes it a single product again, on
+// its own stock ledger.
+func (r *repositoryBundle) SaveBundle(productID uint, form model.FormBundle) (*model.Bundle, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("deleted_at IS NULL").First(&model.Product{}, productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return stockrepository.ErrProductNotFound
+			}
+			return err
+		}
+
+		var recipes int64
+		if err := tx.Model(&model.RecipeItem{}).Where("product_id = ?", productID).Count(&recipes).Error; err != nil {
+			return err
+		}
+		if recipes > 0 && len(form.Items) > 0 {
+			return ErrBundleHasRecipe
+		}
+
+		var component int64
+		if err := tx.Model(&model.BundleItem{}).Where("product_id = ?", productID).Count(&component).Error; err != nil {
+			return err
+		}
+		if component > 0 && len(form.Items) > 0 {
+			return ErrBundleComponent
+		}
+
+		componentIDs := []uint{}
+		for _, item := range form.Items {
+			if item.ProductID == productID {
+				return ErrInvalidComponent
+			}
+			if !slices.Contains(componentIDs, item.ProductID) {
+				componentIDs = append(componentIDs, item.ProductID)
+			}
+		}
+		var components []model.Product
+		if err := tx.Select("id").Where("id IN ? AND deleted_at IS NULL", componentIDs).Find(&components).Error; err != nil {
+			return err
+		}
+		if len(components) != len(componentIDs) {
+			return stockrepository.ErrProductNotFound
+		}
+		var nested int64
+		if err := tx.Model(&model.BundleItem{}).Where("bundle_id IN ?", componentIDs).Count(&nested).Error; err != nil {
+			return err
+		}
+		if nested > 0 {
+			return ErrInvalidComponent
+		}
+
+		items := []model.BundleItem{}
+		for _, item := range form.Items {
+			items = append(items, model.BundleItem{BundleID: productID, GroupName: item.Group, ProductID: item.ProductID, Qty: item.Qty})
+		}
+
+		if err := tx.Where("bundle_id = ?", productID).Delete(&model.BundleItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return tx.Exec(`UPDATE products SET qty = COALESCE((SELECT balance FROM stock_movements WHERE product_id = ? ORDER BY id DESC LIMIT 1), 0) WHERE id = ?`, productID, productID).Error
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		return stockrepository.RefreshBundles(tx, componentIDs)
+	})
+	if err != nil {
+		if !errors.Is(err, stockrepository.ErrProductNotFound) && !errors.Is(err, ErrInvalidComponent) && !errors.Is(err, ErrBundleHasRecipe) && !errors.Is(err, ErrBundleComponent) {
+			r.Log.Error("Failed to save bundle", zap.Uint("product", productID), zap.Error(err))
+			return nil, errors.New(" Internal Server Error")
+		}
+		return nil, err
+	}
+	return r.FindBundle(productID)
+}
+
+func (r *repositoryBundle) HasBundle(productID uint) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&model.BundleItem{}).Where("bundle_id = ?", productID).Count(&count).Error; err != nil {
+		r.Log.Error("Failed to find bundle", zap.Uint("product", productID), zap.Error(err))
+		return false, errors.New(" Internal Server Error")
+	}
+	return count > 0, nil
+}
+
+// Components works out what an order line of a bundle sells, inside the
+// caller's transaction: every fixed item, and the option chosen in each
+// group, for the line's qty, with the bundle's price for the line shared
+// out over them. A group without a choice keeps the one in previous, what
+// the order sold of the bundle before an edit. It returns nil for a
+// product that is not a bundle.
+func Components(tx *gorm.DB, line model.OrderProduct, previous []model.OrderBundleItem) ([]model.OrderBundleItem, error) {
+	var items []model.BundleItem
+	if err := tx.Preload("Product").Where("bundle_id = ?", line.ProductID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	var bundle model.Product
+	if err := tx.Select("id", "price").First(&bundle, line.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	choices := map[string]uint{}
+	for _, item := range previous {
+		if item.GroupName != "" {
+			choices[item.GroupName] = item.ProductID
+		}
+	}
+	for group, productID := range line.Choices {
+		choices[group] = productID
+	}
+	for group := range line.Choices {
+		if !slices.ContainsFunc(items, func(item model.BundleItem) bool { return item.GroupName == group }) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidChoice, group)
+		}
+	}
+
+	sold := []model.OrderBundleItem{}
+	prices := map[uint]float64{}
+	groups := []string{}
+	for _, item := range items {
+		if item.GroupName != "" {
+			if !slices.Contains(groups, item.GroupName) {
+				groups = append(groups, item.GroupName)
+			}
+			if choices[item.GroupName] != item.ProductID {
+				continue
+			}
+		}
+		if item.Product == nil || item.Product.DeletedAt != nil {
+			return nil, fmt.Errorf("product with id %d has been deleted", item.ProductID)
+		}
+		sold = append(sold, model.OrderBundleItem{
+			OrderID:   line.OrderID,
+			BundleID:  line.ProductID,
+			GroupName: item.GroupName,
+			ProductID: item.ProductID,
+			Qty:       line.Qty * item.Qty,
+		})
+		prices[item.ProductID] = item.Product.Price
+	}
+	for _, group := range groups {
+		if !slices.ContainsFunc(sold, func(item model.OrderBundleItem) bool { return item.GroupName == group }) {
+			if _, chosen := choices[group]; chosen {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidChoice, group)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrChoiceMissing, group)
+		}
+	}
+
+	model.AllocateRevenue(bundle.Price*float64(line.Qty), sold, prices)
+	return sold, nil
+}
@@ -0,0 +1,37 @@
+package bundlerepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	bundlerepository "project_pos_app/repository/bundle_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSaveBundle(t *testing.T) {
+	t.Run("A component of another bundle cannot become a bundle", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := bundlerepository.NewBundleRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "products" WHERE deleted_at IS NULL AND "products"."id" = $1`)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "recipe_items" WHERE product_id = $1`)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "bundle_items" WHERE product_id = $1`)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		_, err := repo.SaveBundle(4, model.FormBundle{Items: []model.FormBundleItem{{ProductID: 7, Qty: 1}}})
+
+		assert.ErrorIs(t, err, bundlerepository.ErrBundleComponent)
+	})
+}
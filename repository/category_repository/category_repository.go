@@ -1,10 +1,13 @@
 package categoryrepository
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"project_pos_app/model"
+	"slices"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -12,11 +15,22 @@ import (
 
 type CategoryRepository interface {
 	ShowAllCategory(page, limit int) (*[]model.Category, int, int, error)
+	AllCategories() ([]model.Category, error)
 	GetCategoryByID(id uint) (*model.Category, error)
 	CreateCategory(category *model.Category) error
 	UpdateCategory(categoryID uint, category *model.Category) error
+	DeleteCategory(categoryID uint, reassignTo *uint) error
+	ReorderCategories(parentID *uint, ids []uint) error
 }
 
+var (
+	ErrCategoryNotFound = errors.New(" Category Not Found")
+	ErrCategoryInUse    = errors.New(" Category Still Has Products Or Subcategories")
+	ErrInvalidParent    = errors.New(" Invalid Parent Category")
+	ErrInvalidReassign  = errors.New(" Invalid Category To Reassign To")
+	ErrReorderMismatch  = errors.New(" Reorder Must List Every Sibling Category Once")
+)
+
 type categoryRepository struct {
 	db  *gorm.DB
 	log *zap.Logger
@@ -33,14 +47,14 @@ func (cr *categoryRepository) ShowAllCategory(page, limit int) (*[]model.Categor
 	var totalRecords int64
 
 	// Count total records
-	if err := cr.db.Model(&model.Category{}).Count(&totalRecords).Error; err != nil {
+	if err := cr.db.Model(&model.Category{}).Where("deleted_at IS NULL").Count(&totalRecords).Error; err != nil {
 		cr.log.Error("Error counting category", zap.Error(err))
 		return nil, 0, 0, err
 	}
 
 	// Fetch paginated results
 	offset := (page - 1) * limit
-	if err := cr.db.Offset(offset).Limit(limit).Find(&category).Error; err != nil {
+	if err := withProductCount(cr.db).Offset(offset).Limit(limit).Find(&category).Error; err != nil {
 		cr.log.Error("Error fetching products", zap.Error(err))
 		return nil, 0, 0, err
 	}
@@ -51,13 +65,35 @@ func (cr *categoryRepository) ShowAllCategory(page, limit int) (*[]model.Categor
 	return &category, int(totalRecords), totalPages, nil
 }
 
+// AllCategories fetches every category, for building the category tree.
+func (cr *categoryRepository) AllCategories() ([]model.Category, error) {
+	var categories []model.Category
+	if err := withProductCount(cr.db).Find(&categories).Error; err != nil {
+		cr.log.Error("Error fetching categories", zap.Error(err))
+		return nil, err
+	}
+	return categories, nil
+}
+
+// withProductCount selects live categories in display order, each with
+// the number of active products in it, counted in the same query.
+func withProductCount(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.Category{}).
+		Select("categories.*, COUNT(products.id) AS product_count").
+		Joins("LEFT JOIN products ON products.category_id = categories.id AND products.deleted_at IS NULL AND LOWER(products.status) IN ?", model.ActiveStatuses).
+		Where("categories.deleted_at IS NULL").
+		Group("categories.id").
+		Order("categories.position").
+		Order("categories.id")
+}
+
 func (cr *categoryRepository) GetCategoryByID(id uint) (*model.Category, error) {
 	cr.log.Info("Fetching category by ID", zap.Uint("id", id))
 
 	var category model.Category
-	if err := cr.db.First(&category, id).Error; err != nil {
+	if err := withProductCount(cr.db).Where("categories.id = ?", id).Take(&category).Error; err != nil {
 		cr.log.Error("Error fetching category", zap.Uint("id", id), zap.Error(err))
-		return nil, fmt.Errorf("category not found")
+		return nil, ErrCategoryNotFound
 	}
 
 	cr.log.Info("Successfully fetched category", zap.Uint("id", id))
@@ -71,6 +107,14 @@ func (cr *categoryRepository) CreateCategory(category *model.Category) error {
 	var err error
 
 	err = cr.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkParent(tx, 0, category.ParentID); err != nil {
+			return err
+		}
+		position, err := nextPosition(tx, category.ParentID)
+		if err != nil {
+			return err
+		}
+		category.Position = position
 		if err := tx.Create(category).Error; err != nil {
 			cr.log.Error("Failed to create category", zap.String("name", category.Name), zap.Error(err))
 			return fmt.Errorf("failed to create category: %w", err)
@@ -90,19 +134,41 @@ func (cr *categoryRepository) CreateCategory(category *model.Category) error {
 
 func (cr *categoryRepository) UpdateCategory(categoryID uint, category *model.Category) error {
 	cr.log.Info("Updating category with data", zap.Any("category", category))
-	result := cr.db.Model(&model.Category{}).Where("id = ?", categoryID).Updates(map[string]interface{}{
-		"name":        category.Name,
-		"description": category.Description,
-		"icon_url":    category.IconURL,
-	})
-	cr.log.Info("Update result", zap.Int64("RowsAffected", result.RowsAffected))
 
-	if result.Error != nil {
-		cr.log.Error("Failed to update category", zap.Uint("categoryID", categoryID), zap.Error(result.Error))
+	var rowsAffected int64
+	err := cr.db.Transaction(func(tx *gorm.DB) error {
+		var current model.Category
+		if err := tx.Select("id", "parent_id", "position").Where("deleted_at IS NULL").First(&current, categoryID).Error; err != nil {
+			return ErrCategoryNotFound
+		}
+		// A category moved under another parent goes last among its new
+		// siblings.
+		category.Position = current.Position
+		if !sameParent(current.ParentID, category.ParentID) {
+			if err := checkParent(tx, categoryID, category.ParentID); err != nil {
+				return err
+			}
+			position, err := nextPosition(tx, category.ParentID)
+			if err != nil {
+				return err
+			}
+			category.Position = position
+		}
+
+		result := tx.Model(&model.Category{}).Where("id = ?", categoryID).
+			Select("name", "description", "icon_url", "icon_variants", "points_multiplier", "parent_id", "position").
+			Updates(category)
+		rowsAffected = result.RowsAffected
 		return result.Error
+	})
+	cr.log.Info("Update result", zap.Int64("RowsAffected", rowsAffected))
+
+	if err != nil {
+		cr.log.Error("Failed to update category", zap.Uint("categoryID", categoryID), zap.Error(err))
+		return err
 	}
 
-	if result.RowsAffected == 0 {
+	if rowsAffected == 0 {
 		cr.log.Warn("No category found to update", zap.Uint("categoryID", categoryID))
 		return fmt.Errorf("no category found with id %d", categoryID)
 	}
@@ -110,3 +176,133 @@ func (cr *categoryRepository) UpdateCategory(categoryID uint, category *model.Ca
 	cr.log.Info("Successfully updated category", zap.Uint("categoryID", categoryID))
 	return nil
 }
+
+// DeleteCategory soft deletes a category. One that products or
+// subcategories still belong to is only deleted when reassignTo names the
+// category to move them to.
+func (cr *categoryRepository) DeleteCategory(categoryID uint, reassignTo *uint) error {
+	cr.log.Info("Deleting category", zap.Uint("categoryID", categoryID))
+
+	err := cr.db.Transaction(func(tx *gorm.DB) error {
+		var category model.Category
+		if err := tx.Select("id").Where("deleted_at IS NULL").First(&category, categoryID).Error; err != nil {
+			return ErrCategoryNotFound
+		}
+
+		var products, children int64
+		if err := tx.Model(&model.Product{}).Where("category_id = ? AND deleted_at IS NULL", categoryID).Count(&products).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Category{}).Where("parent_id = ? AND deleted_at IS NULL", categoryID).Count(&children).Error; err != nil {
+			return err
+		}
+
+		if products > 0 || children > 0 {
+			if reassignTo == nil {
+				return ErrCategoryInUse
+			}
+			if err := checkParent(tx, categoryID, reassignTo); err != nil {
+				if errors.Is(err, ErrInvalidParent) {
+					return ErrInvalidReassign
+				}
+				return err
+			}
+			if err := tx.Model(&model.Product{}).Where("category_id = ?", categoryID).
+				Update("category_id", *reassignTo).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.Category{}).Where("parent_id = ? AND deleted_at IS NULL", categoryID).
+				Update("parent_id", *reassignTo).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.Category{}).Where("id = ?", categoryID).Update("deleted_at", time.Now()).Error
+	})
+	if err != nil {
+		cr.log.Error("Failed to delete category", zap.Uint("categoryID", categoryID), zap.Error(err))
+		return err
+	}
+
+	cr.log.Info("Successfully deleted category", zap.Uint("categoryID", categoryID))
+	return nil
+}
+
+// ReorderCategories sets the positions of the subcategories of parentID,
+// or of the top level categories, to the order of ids. ids must list each
+// of them exactly once.
+func (cr *categoryRepository) ReorderCategories(parentID *uint, ids []uint) error {
+	err := cr.db.Transaction(func(tx *gorm.DB) error {
+		var siblings []uint
+		if err := siblingsOf(tx, parentID).Pluck("id", &siblings).Error; err != nil {
+			return err
+		}
+		given := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			if given[id] || !slices.Contains(siblings, id) {
+				return ErrReorderMismatch
+			}
+			given[id] = true
+		}
+		if len(given) != len(siblings) {
+			return ErrReorderMismatch
+		}
+
+		for position, id := range ids {
+			if err := tx.Model(&model.Category{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cr.log.Error("Failed to reorder categories", zap.Uints("ids", ids), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// checkParent makes sure parentID is a live category that is neither
+// categoryID nor one of its subcategories, so the hierarchy cannot loop.
+func checkParent(tx *gorm.DB, categoryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	var ancestors []uint
+	err := tx.Raw(`WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+	) SELECT id FROM ancestors`, *parentID).Scan(&ancestors).Error
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 || slices.Contains(ancestors, categoryID) {
+		return ErrInvalidParent
+	}
+	return nil
+}
+
+// siblingsOf selects the live subcategories of parentID, or the top level
+// categories when it is nil.
+func siblingsOf(tx *gorm.DB, parentID *uint) *gorm.DB {
+	query := tx.Model(&model.Category{}).Where("deleted_at IS NULL")
+	if parentID == nil {
+		return query.Where("parent_id IS NULL")
+	}
+	return query.Where("parent_id = ?", *parentID)
+}
+
+// nextPosition is the position after the last of parentID's subcategories.
+func nextPosition(tx *gorm.DB, parentID *uint) (int, error) {
+	var position int
+	err := siblingsOf(tx, parentID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error
+	return position, err
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,144 @@
+package categoryrepository_test
+
+import (
+	"project_pos_app/helper"
+	categoryrepository "project_pos_app/repository/category_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestDeleteCategory(t *testing.T) {
+	categoryQuery := `SELECT "id" FROM "categories" WHERE deleted_at IS NULL AND "categories"."id" = $1`
+	productCount := `SELECT count(*) FROM "products" WHERE category_id = $1 AND deleted_at IS NULL`
+	childCount := `SELECT count(*) FROM "categories" WHERE parent_id = $1 AND deleted_at IS NULL`
+	ancestorQuery := `WITH RECURSIVE ancestors AS`
+
+	t.Run("Empty category is soft deleted", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(regexp.QuoteMeta(productCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(childCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "categories" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteCategory(4, nil))
+	})
+
+	t.Run("Category in use is kept without a reassignment", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(regexp.QuoteMeta(productCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(childCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.DeleteCategory(4, nil), categoryrepository.ErrCategoryInUse)
+	})
+
+	t.Run("Products and subcategories move to the reassigned category", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+		target := uint(2)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(regexp.QuoteMeta(productCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(childCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(ancestorQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "category_id"=$1,"updated_at"=$2 WHERE category_id = $3`)).
+			WithArgs(2, sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "categories" SET "parent_id"=$1,"updated_at"=$2 WHERE parent_id = $3 AND deleted_at IS NULL`)).
+			WithArgs(2, sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "categories" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteCategory(4, &target))
+	})
+
+	t.Run("Subcategory cannot take over its parent's products", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+		target := uint(9)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(regexp.QuoteMeta(productCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(childCount)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		// 9 is a subcategory of 4, which would be left pointing at itself.
+		mock.ExpectQuery(regexp.QuoteMeta(ancestorQuery)).
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(4))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.DeleteCategory(4, &target), categoryrepository.ErrInvalidReassign)
+	})
+}
+
+func TestReorderCategories(t *testing.T) {
+	siblingQuery := `SELECT "id" FROM "categories" WHERE deleted_at IS NULL AND parent_id = $1`
+	parent := uint(1)
+
+	t.Run("Siblings take the positions of the given order", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(siblingQuery)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(6).AddRow(7))
+		for position, id := range []int{7, 5, 6} {
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "categories" SET "position"=$1,"updated_at"=$2 WHERE id = $3`)).
+				WithArgs(position, sqlmock.AnyArg(), id).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.ReorderCategories(&parent, []uint{7, 5, 6}))
+	})
+
+	t.Run("Every sibling must be listed once", func(t *testing.T) {
+		for _, ids := range [][]uint{{7, 5}, {7, 5, 5}, {7, 5, 6, 8}} {
+			db, mock := helper.SetupTestDB()
+			repo := categoryrepository.NewCategoryRepo(db, zap.NewNop())
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(siblingQuery)).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(6).AddRow(7))
+			mock.ExpectRollback()
+
+			assert.ErrorIs(t, repo.ReorderCategories(&parent, ids), categoryrepository.ErrReorderMismatch, "ids %v", ids)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package customerrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RepositoryCustomer interface {
+	FindCustomers(search string, page, limit int) ([]model.Customer, int, int, error)
+	FindCustomer(customer *model.Customer) error
+	FindOrCreate(customer *model.Customer) error
+	FindProfile(id uint) (*model.CustomerProfile, error)
+}
+
+// favouriteProductsLimit is how many favourite products a profile lists.
+const favouriteProductsLimit = 5
+
+type repositoryCustomer struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewCustomerRepository(db *gorm.DB, log *zap.Logger) RepositoryCustomer {
+	return &repositoryCustomer{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryCustomer) FindCustomers(search string, page, limit int) ([]model.Customer, int, int, error) {
+	var customers []model.Customer
+	var totalRecords int64
+
+	query := r.DB.Model(&model.Customer{})
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR phone_number ILIKE ? OR email ILIKE ?", like, like, like)
+	}
+	if err := query.Count(&totalRecords).Error; err != nil {
+		r.Log.Error("Failed to count customers", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	err := query.Order("name").Order("id").Offset((page - 1) * limit).Limit(limit).Find(&customers).Error
+	if err != nil {
+		r.Log.Error("Failed to find customers", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	totalPages := int(math.Ceil(float64(totalRecords) / float64(limit)))
+	return customers, int(totalRecords), totalPages, nil
+}
+func (r *repositoryCustomer) FindCustomer(customer *model.Customer) error {
+	err := r.DB.First(customer, customer.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New(" Customer Not Found")
+	}
+	if err != nil {
+		r.Log.Error("Failed to find customer", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// FindOrCreate looks the customer up by phone number, then by email, and
+// creates them when neither matches. A match is completed with the name,
+// phone number or email it was missing. The customer must be normalized.
+func (r *repositoryCustomer) FindOrCreate(customer *model.Customer) error {
+	found, err := r.match(customer)
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		createErr := r.DB.Create(customer).Error
+		if createErr == nil {
+			return nil
+		}
+		// Another request may have created the same customer since the
+		// lookup; the unique indexes reject the duplicate, so look again.
+		if found, err = r.match(customer); err != nil {
+			return err
+		}
+		if found == nil {
+			r.Log.Error("Failed to create customer", zap.Error(createErr))
+			return errors.New(" Internal Server Error")
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if found.Name == "" && customer.Name != "" {
+		updates["name"] = customer.Name
+	}
+	if found.PhoneNumber == "" && customer.PhoneNumber != "" {
+		updates["phone_number"] = customer.PhoneNumber
+	}
+	if found.Email == "" && customer.Email != "" {
+		updates["email"] = customer.Email
+	}
+	if len(updates) > 0 {
+		// The new phone number or email may already belong to another
+		// customer; keeping the match as it is is fine then.
+		if err := r.DB.Model(found).Updates(updates).Error; err != nil {
+			r.Log.Warn("Failed to complete customer details", zap.Uint("id", found.ID), zap.Error(err))
+		}
+	}
+	*customer = *found
+	return nil
+}
+
+func (r *repositoryCustomer) match(customer *model.Customer) (*model.Customer, error) {
+	for _, key := range []struct{ column, value string }{
+		{"phone_number", customer.PhoneNumber},
+		{"email", customer.Email},
+	} {
+		if key.value == "" {
+			continue
+		}
+		var found model.Customer
+		err := r.DB.Where(key.column+" = ?", key.value).First(&found).Error
+		if err == nil {
+			return &found, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Error("Failed to match customer", zap.Error(err))
+			return nil, errors.New(" Internal Server Error")
+		}
+	}
+	return nil, nil
+}
+
+// FindProfile returns the customer with their lifetime spend, visit count,
+// last visit and most ordered products, counting completed orders only.
+func (r *repositoryCustomer) FindProfile(id uint) (*model.CustomerProfile, error) {
+	profile := model.CustomerProfile{Customer: model.Customer{ID: id}}
+	if err := r.FindCustomer(&profile.Customer); err != nil {
+		return nil, err
+	}
+
+	err := r.DB.Model(&model.Order{}).
+		Select("COALESCE(SUM(total_amount), 0) AS lifetime_spend, COUNT(*) AS visit_count, MAX(created_at) AS last_visit").
+		Where("customer_id = ? AND LOWER(status) = ?", id, "completed").
+		Scan(&profile).Error
+	if err != nil {
+		r.Log.Error("Failed to summarize customer orders", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	profile.FavouriteProducts = []model.FavouriteProduct{}
+	err = r.DB.Table("order_products AS op").
+		Select("p.id AS product_id, p.name, SUM(op.qty) AS qty").
+		Joins("JOIN orders AS o ON o.id = op.order_id").
+		Joins("JOIN products AS p ON p.id = op.product_id").
+		Where("o.customer_id = ? AND LOWER(o.status) = ? AND o.deleted_at IS NULL", id, "completed").
+		Group("p.id, p.name").
+		Order("qty DESC").
+		Limit(favouriteProductsLimit).
+		Scan(&profile.FavouriteProducts).Error
+	if err != nil {
+		r.Log.Error("Failed to find favourite products", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &profile, nil
+}
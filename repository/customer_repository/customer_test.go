@@ -0,0 +1,91 @@
+package customerrepository_test
+
+import (
+	"errors"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	customerrepository "project_pos_app/repository/customer_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFindOrCreateCustomer(t *testing.T) {
+	phoneQuery := `SELECT * FROM "customers" WHERE phone_number = $1 ORDER BY "customers"."id" LIMIT $2`
+	emailQuery := `SELECT * FROM "customers" WHERE email = $1 ORDER BY "customers"."id" LIMIT $2`
+	columns := []string{"id", "name", "phone_number", "email"}
+
+	t.Run("Match existing customer by phone and complete the email", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := customerrepository.NewCustomerRepository(db, zap.NewNop())
+		customer := &model.Customer{Name: "John Doe", PhoneNumber: "+628123456789", Email: "john@example.com"}
+
+		mock.ExpectQuery(regexp.QuoteMeta(phoneQuery)).
+			WithArgs("+628123456789", 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, "John", "+628123456789", ""))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "customers" SET "email"=$1`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.FindOrCreate(customer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), customer.ID)
+		assert.Equal(t, "John", customer.Name)
+		assert.Equal(t, "john@example.com", customer.Email)
+	})
+
+	t.Run("Create customer when neither phone nor email match", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := customerrepository.NewCustomerRepository(db, zap.NewNop())
+		customer := &model.Customer{Name: "Jane Doe", PhoneNumber: "+628987654321", Email: "jane@example.com"}
+
+		mock.ExpectQuery(regexp.QuoteMeta(phoneQuery)).
+			WithArgs("+628987654321", 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(regexp.QuoteMeta(emailQuery)).
+			WithArgs("jane@example.com", 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "customers"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		err := repo.FindOrCreate(customer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(9), customer.ID)
+	})
+	t.Run("A failed create that matches no one logs the create error", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		core, logs := observer.New(zap.ErrorLevel)
+		repo := customerrepository.NewCustomerRepository(db, zap.New(core))
+		customer := &model.Customer{Name: "Jane Doe", PhoneNumber: "+628987654321"}
+
+		mock.ExpectQuery(regexp.QuoteMeta(phoneQuery)).
+			WithArgs("+628987654321", 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "customers"`)).
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+		mock.ExpectQuery(regexp.QuoteMeta(phoneQuery)).
+			WithArgs("+628987654321", 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		err := repo.FindOrCreate(customer)
+
+		assert.EqualError(t, err, " Internal Server Error")
+		entries := logs.FilterMessage("Failed to create customer").All()
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "connection reset", entries[0].ContextMap()["error"])
+	})
+}
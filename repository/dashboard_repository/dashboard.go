@@ -1,6 +1,7 @@
 package dashboardrepository
 
 import (
+	"database/sql"
 	"errors"
 	"project_pos_app/model"
 	"time"
@@ -14,6 +15,7 @@ type RepositoryDashboard interface {
 	FindNewProduct() ([]model.Product, error)
 	FindSummary(summary *model.Summary) error
 	FindReport(report *[]model.ReportExcel) error
+	FindStaffSales(from, to time.Time) ([]model.StaffSales, error)
 }
 
 type repositoryDashboard struct {
@@ -67,7 +69,7 @@ func (r *repositoryDashboard) FindPopularProduct() ([]model.Product, error) {
 }
 func (r *repositoryDashboard) FindNewProduct() ([]model.Product, error) {
 	var products []model.Product
-	err := r.DB.Where("created_at >= CURRENT_DATE - INTERVAL '30 days'").Find(&products).Error
+	err := r.DB.Where("created_at >= CURRENT_DATE - INTERVAL '30 days' AND deleted_at IS NULL").Find(&products).Error
 	if err != nil {
 		r.Log.Error("Failed to find new product", zap.Error(err))
 		return nil, errors.New(" Internal Server Error")
@@ -126,3 +128,50 @@ func (r *repositoryDashboard) FindReport(report *[]model.ReportExcel) error {
 	}
 	return nil
 }
+
+// FindStaffSales adds up the orders placed from from up to to by the staff
+// member who took them and the one who served them. Staff without any are
+// left out.
+func (r *repositoryDashboard) FindStaffSales(from, to time.Time) ([]model.StaffSales, error) {
+	staff := []model.StaffSales{}
+	err := r.DB.Raw(`
+    SELECT u.id AS user_id,
+        COALESCE((SELECT e.name FROM employees e WHERE e.user_id = u.id ORDER BY e.id LIMIT 1), u.email) AS name,
+        COALESCE(taken.orders, 0) AS orders_taken,
+        COALESCE(served.orders, 0) AS orders_served,
+        COALESCE(served.sales, 0) AS sales,
+        COALESCE(served.items, 0) AS items,
+        COALESCE(served.voids, 0) AS voids,
+        COALESCE(served.voided_amount, 0) AS voided_amount,
+        COALESCE(served.discounts, 0) AS discounts,
+        COALESCE(served.discounted_orders, 0) AS discounted_orders
+    FROM users u
+    LEFT JOIN (
+        SELECT created_by, COUNT(*) AS orders
+        FROM orders
+        WHERE created_by <> 0 AND created_at >= @from AND created_at < @to AND deleted_at IS NULL
+        GROUP BY created_by
+    ) taken ON taken.created_by = u.id
+    LEFT JOIN (
+        SELECT o.served_by,
+            COUNT(*) FILTER (WHERE LOWER(o.status) = 'completed') AS orders,
+            SUM(o.total_amount) FILTER (WHERE LOWER(o.status) = 'completed') AS sales,
+            SUM(op.qty) FILTER (WHERE LOWER(o.status) = 'completed') AS items,
+            COUNT(*) FILTER (WHERE LOWER(o.status) IN ('canceled', 'cancelled')) AS voids,
+            SUM(o.total_amount) FILTER (WHERE LOWER(o.status) IN ('canceled', 'cancelled')) AS voided_amount,
+            SUM(o.discount) FILTER (WHERE LOWER(o.status) = 'completed') AS discounts,
+            COUNT(*) FILTER (WHERE LOWER(o.status) = 'completed' AND o.discount > 0) AS discounted_orders
+        FROM orders o
+        LEFT JOIN (SELECT order_id, SUM(qty) AS qty FROM order_products GROUP BY order_id) op ON op.order_id = o.id
+        WHERE o.served_by <> 0 AND o.created_at >= @from AND o.created_at < @to AND o.deleted_at IS NULL
+        GROUP BY o.served_by
+    ) served ON served.served_by = u.id
+    WHERE taken.created_by IS NOT NULL OR served.served_by IS NOT NULL
+    ORDER BY sales DESC, u.id
+`, sql.Named("from", from), sql.Named("to", to)).Scan(&staff).Error
+	if err != nil {
+		r.Log.Error("Failed to find staff sales", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return staff, nil
+}
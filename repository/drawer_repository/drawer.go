@@ -0,0 +1,258 @@
+package drawerrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryDrawer interface {
+	FindSessions(page, limit int) ([]model.DrawerSession, int, int, error)
+	FindSession(id uint) (*model.DrawerSession, error)
+	FindOpen() (*model.DrawerSession, error)
+	Open(session *model.DrawerSession) error
+	Move(movement *model.DrawerMovement) error
+	Tally(sessionID uint) ([]model.DrawerTender, int64, error)
+	Close(form model.FormCloseDrawer, actorID uint) (*model.DrawerSession, error)
+}
+
+var (
+	ErrSessionNotFound = errors.New(" Drawer Session Not Found")
+	ErrNoOpenDrawer    = errors.New(" No Open Drawer Session")
+	ErrDrawerOpen      = errors.New(" A Drawer Session Is Already Open")
+	ErrUnsettledOrders = errors.New(" Drawer Has Unsettled Orders")
+)
+
+type repositoryDrawer struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewDrawerRepository(db *gorm.DB, log *zap.Logger) RepositoryDrawer {
+	return &repositoryDrawer{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryDrawer) FindSessions(page, limit int) ([]model.DrawerSession, int, int, error) {
+	var total int64
+	if err := r.DB.Model(&model.DrawerSession{}).Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count drawer sessions", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	sessions := []model.DrawerSession{}
+	err := r.DB.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		r.Log.Error("Failed to find drawer sessions", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return sessions, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+func (r *repositoryDrawer) FindSession(id uint) (*model.DrawerSession, error) {
+	var session model.DrawerSession
+	err := r.DB.Preload("Movements", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Tenders", func(db *gorm.DB) *gorm.DB { return db.Order("payment_method") }).
+		First(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find drawer session", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &session, nil
+}
+
+func (r *repositoryDrawer) FindOpen() (*model.DrawerSession, error) {
+	var session model.DrawerSession
+	err := r.DB.Preload("Movements", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("status = ?", model.DrawerOpen).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoOpenDrawer
+	}
+	if err != nil {
+		r.Log.Error("Failed to find open drawer session", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &session, nil
+}
+
+// Open starts a session. There is one drawer, so one session at a time:
+// two sessions opened at once both pass the count, and the one whose insert
+// then trips idx_drawer_sessions_open is told a session is already open.
+func (r *repositoryDrawer) Open(session *model.DrawerSession) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&model.DrawerSession{}).Where("status = ?", model.DrawerOpen).Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrDrawerOpen
+		}
+		session.Status = model.DrawerOpen
+		err := tx.Create(session).Error
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_drawer_sessions_open" {
+			return ErrDrawerOpen
+		}
+		return err
+	})
+	return r.failure("Failed to open drawer session", err)
+}
+
+// Move records cash paid in or out of the open session's drawer.
+func (r *repositoryDrawer) Move(movement *model.DrawerMovement) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		session, err := lockOpen(tx)
+		if err != nil {
+			return err
+		}
+		movement.SessionID = session.ID
+		if err := tx.Create(movement).Error; err != nil {
+			return err
+		}
+
+		column := "paid_in"
+		if movement.Type == model.DrawerPaidOut {
+			column = "paid_out"
+		}
+		return tx.Model(session).Update(column, gorm.Expr(column+" + ?", movement.Amount)).Error
+	})
+	return r.failure("Failed to record drawer movement", err)
+}
+
+// Tally sums up what a session's settled orders took in each payment
+// method, and counts its orders still to settle.
+func (r *repositoryDrawer) Tally(sessionID uint) ([]model.DrawerTender, int64, error) {
+	tenders, open, err := tally(r.DB, sessionID)
+	if err != nil {
+		r.Log.Error("Failed to tally drawer session", zap.Uint("session", sessionID), zap.Error(err))
+		return nil, 0, errors.New(" Internal Server Error")
+	}
+	return tenders, open, nil
+}
+
+// Close counts the open session's drawer and closes it, keeping what was
+// expected and counted of each tender for its Z report. A session with
+// orders still to settle cannot be closed.
+func (r *repositoryDrawer) Close(form model.FormCloseDrawer, actorID uint) (*model.DrawerSession, error) {
+	var id uint
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		session, err := lockOpen(tx)
+		if err != nil {
+			return err
+		}
+		id = session.ID
+
+		tenders, open, err := tally(tx, session.ID)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrUnsettledOrders
+		}
+
+		counted := map[uint]float64{}
+		for _, count := range form.Counts {
+			counted[count.PaymentMethod] = *count.Counted
+		}
+		session.Expect(tenders)
+		session.Count(*form.CountedCash, counted)
+		for i := range session.Tenders {
+			session.Tenders[i].SessionID = session.ID
+		}
+		if len(session.Tenders) > 0 {
+			if err := tx.Create(&session.Tenders).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":        model.DrawerClosed,
+			"expected_cash": session.ExpectedCash,
+			"counted_cash":  session.CountedCash,
+			"variance":      session.Variance,
+			"closed_by":     actorID,
+			"closed_at":     &now,
+		}
+		if form.Note != "" {
+			updates["note"] = form.Note
+		}
+		return tx.Model(&model.DrawerSession{ID: session.ID}).Updates(updates).Error
+	})
+	if err := r.failure("Failed to close drawer session", err); err != nil {
+		return nil, err
+	}
+	return r.FindSession(id)
+}
+
+func (r *repositoryDrawer) failure(message string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrSessionNotFound), errors.Is(err, ErrNoOpenDrawer), errors.Is(err, ErrDrawerOpen),
+		errors.Is(err, ErrUnsettledOrders):
+		return err
+	default:
+		r.Log.Error(message, zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+}
+
+// OpenSessionID returns the open session inside the caller's transaction,
+// holding it open until the transaction ends, for an order settled into
+// its drawer.
+func OpenSessionID(tx *gorm.DB) (uint, error) {
+	var session model.DrawerSession
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").
+		Where("status = ?", model.DrawerOpen).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrNoOpenDrawer
+	}
+	return session.ID, err
+}
+
+func lockOpen(tx *gorm.DB) (*model.DrawerSession, error) {
+	var session model.DrawerSession
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ?", model.DrawerOpen).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoOpenDrawer
+	}
+	return &session, err
+}
+
+func tally(tx *gorm.DB, sessionID uint) ([]model.DrawerTender, int64, error) {
+	tenders := []model.DrawerTender{}
+	err := tx.Table("payments").
+		Select("payments.id AS payment_method, payments.name, COUNT(orders.id) AS orders, COALESCE(SUM(orders.total_amount), 0) AS sales").
+		Joins("LEFT JOIN orders ON orders.payment_method = payments.id AND orders.drawer_session_id = ? AND LOWER(orders.status) = 'completed' AND orders.deleted_at IS NULL", sessionID).
+		Where("payments.deleted_at IS NULL").
+		Group("payments.id, payments.name").
+		Order("payments.id").
+		Scan(&tenders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var open int64
+	err = tx.Model(&model.Order{}).
+		Where("drawer_session_id = ? AND LOWER(status) NOT IN ?", sessionID, []string{"completed", "canceled", "cancelled"}).
+		Count(&open).Error
+	return tenders, open, err
+}
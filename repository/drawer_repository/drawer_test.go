@@ -0,0 +1,142 @@
+package drawerrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	drawerrepository "project_pos_app/repository/drawer_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestOpen(t *testing.T) {
+	countQuery := `SELECT count(*) FROM "drawer_sessions" WHERE status = $1`
+
+	t.Run("A session opened at the same time loses to the unique index", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := drawerrepository.NewDrawerRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+			WithArgs(model.DrawerOpen).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "drawer_sessions"`)).
+			WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_drawer_sessions_open"})
+		mock.ExpectRollback()
+
+		err := repo.Open(&model.DrawerSession{OpeningFloat: 100})
+
+		assert.ErrorIs(t, err, drawerrepository.ErrDrawerOpen)
+	})
+
+	t.Run("Other insert failures stay internal", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := drawerrepository.NewDrawerRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+			WithArgs(model.DrawerOpen).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "drawer_sessions"`)).
+			WillReturnError(&pgconn.PgError{Code: "23502", ColumnName: "opened_by"})
+		mock.ExpectRollback()
+
+		err := repo.Open(&model.DrawerSession{OpeningFloat: 100})
+
+		assert.EqualError(t, err, " Internal Server Error")
+	})
+}
+
+func TestClose(t *testing.T) {
+	sessionQuery := `SELECT * FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR UPDATE`
+	tallyQuery := `SELECT payments.id AS payment_method, payments.name, COUNT(orders.id) AS orders, COALESCE(SUM(orders.total_amount), 0) AS sales FROM "payments" LEFT JOIN orders ON orders.payment_method = payments.id AND orders.drawer_session_id = $1`
+	openQuery := `SELECT count(*) FROM "orders" WHERE (drawer_session_id = $1 AND LOWER(status) NOT IN ($2,$3,$4)) AND "orders"."deleted_at" IS NULL`
+	sessionColumns := []string{"id", "status", "opening_float", "paid_in", "paid_out"}
+	tenderColumns := []string{"payment_method", "name", "orders", "sales"}
+	cash := 412.5
+
+	t.Run("Unsettled orders keep the drawer open", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := drawerrepository.NewDrawerRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sessionQuery)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow(5, model.DrawerOpen, 100.0, 0.0, 0.0))
+		mock.ExpectQuery(regexp.QuoteMeta(tallyQuery)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(tenderColumns).AddRow(1, "Cash", 2, 300.0))
+		mock.ExpectQuery(regexp.QuoteMeta(openQuery)).
+			WithArgs(5, "completed", "canceled", "cancelled").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		session, err := repo.Close(model.FormCloseDrawer{CountedCash: &cash}, 2)
+
+		assert.ErrorIs(t, err, drawerrepository.ErrUnsettledOrders)
+		assert.Nil(t, session)
+	})
+
+	t.Run("Counts are checked against each tender", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := drawerrepository.NewDrawerRepository(db, zap.NewNop())
+
+		card := 180.0
+		form := model.FormCloseDrawer{
+			CountedCash: &cash,
+			Counts:      []model.FormDrawerCount{{PaymentMethod: 2, Counted: &card}},
+		}
+
+		// 100 float, 300 cash sales, 20 paid in and 5 paid out: 415 expected.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sessionQuery)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow(5, model.DrawerOpen, 100.0, 20.0, 5.0))
+		mock.ExpectQuery(regexp.QuoteMeta(tallyQuery)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(tenderColumns).
+				AddRow(1, "Cash", 2, 300.0).
+				AddRow(2, "Credit Card", 1, 180.0).
+				AddRow(3, "E-Wallet", 1, 50.0))
+		mock.ExpectQuery(regexp.QuoteMeta(openQuery)).
+			WithArgs(5, "completed", "canceled", "cancelled").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "drawer_tenders" ("session_id","payment_method","name","orders","sales","expected","counted","variance") VALUES ($1,$2,$3,$4,$5,$6,$7,$8),($9,$10,$11,$12,$13,$14,$15,$16),($17,$18,$19,$20,$21,$22,$23,$24)`)).
+			WithArgs(
+				5, 1, "Cash", 2, 300.0, 415.0, 412.5, -2.5,
+				5, 2, "Credit Card", 1, 180.0, 180.0, 180.0, 0.0,
+				5, 3, "E-Wallet", 1, 50.0, 50.0, 50.0, 0.0,
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "drawer_sessions" SET "closed_at"=$1,"closed_by"=$2,"counted_cash"=$3,"expected_cash"=$4,"status"=$5,"variance"=$6,"updated_at"=$7 WHERE "id" = $8`)).
+			WithArgs(sqlmock.AnyArg(), 2, 412.5, 415.0, model.DrawerClosed, -2.5, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "drawer_sessions" WHERE "drawer_sessions"."id" = $1`)).
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "expected_cash", "counted_cash", "variance"}).
+				AddRow(5, model.DrawerClosed, 415.0, 412.5, -2.5))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "drawer_movements" WHERE "drawer_movements"."session_id" = $1 ORDER BY id`)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "session_id", "type", "amount"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "drawer_tenders" WHERE "drawer_tenders"."session_id" = $1 ORDER BY payment_method`)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "session_id", "payment_method", "name"}).
+				AddRow(1, 5, 1, "Cash").AddRow(2, 5, 2, "Credit Card").AddRow(3, 5, 3, "E-Wallet"))
+
+		session, err := repo.Close(form, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.DrawerClosed, session.Status)
+		assert.Equal(t, -2.5, session.Variance)
+		assert.Len(t, session.Tenders, 3)
+	})
+}
@@ -0,0 +1,102 @@
+package ingredientrepository
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EvaluateAlerts checks the given ingredients, or every ingredient when
+// none are given, against their reorder level, through the same stock
+// alerts as products. An ingredient that falls below it gets an alert and
+// a notification, unless an alert is already open for it, and again when
+// it runs out. Alerts close once stock is back. It returns the
+// notifications sent.
+func (r *repositoryIngredient) EvaluateAlerts(ingredientIDs []uint) ([]model.Notification, error) {
+	notifications := []model.Notification{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if len(ingredientIDs) > 0 {
+			query = query.Where("id IN ?", ingredientIDs)
+		}
+		var ingredients []model.Ingredient
+		if err := query.Order("id").Find(&ingredients).Error; err != nil {
+			return err
+		}
+		if len(ingredients) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(ingredients))
+		for i, ingredient := range ingredients {
+			ids[i] = ingredient.ID
+		}
+		var alerts []model.StockAlert
+		if err := tx.Where("ingredient_id IN ? AND resolved_at IS NULL", ids).Find(&alerts).Error; err != nil {
+			return err
+		}
+		open := map[uint]*model.StockAlert{}
+		for i := range alerts {
+			open[alerts[i].IngredientID] = &alerts[i]
+		}
+
+		for _, ingredient := range ingredients {
+			notification, err := evaluate(tx, ingredient, open[ingredient.ID])
+			if err != nil {
+				return err
+			}
+			if notification != nil {
+				notifications = append(notifications, *notification)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Log.Error("Failed to evaluate ingredient alerts", zap.Uints("ingredients", ingredientIDs), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return notifications, nil
+}
+
+// evaluate brings one ingredient's alert in line with its stock, returning
+// the notification to send, if any.
+func evaluate(tx *gorm.DB, ingredient model.Ingredient, alert *model.StockAlert) (*model.Notification, error) {
+	if ingredient.Stock >= ingredient.ReorderLevel {
+		if alert == nil {
+			return nil, nil
+		}
+		now := time.Now()
+		return nil, tx.Model(alert).Updates(map[string]interface{}{"qty": ingredient.Stock, "resolved_at": &now}).Error
+	}
+
+	level := model.AlertLow
+	if ingredient.Stock <= 0 {
+		level = model.AlertOut
+	}
+	if alert != nil && alert.Level == level {
+		return nil, nil
+	}
+
+	if alert == nil {
+		alert = &model.StockAlert{IngredientID: ingredient.ID, Level: level, Qty: ingredient.Stock, ReorderPoint: ingredient.ReorderLevel}
+		if err := tx.Create(alert).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		updates := map[string]interface{}{"level": level, "qty": ingredient.Stock, "reorder_point": ingredient.ReorderLevel}
+		if err := tx.Model(alert).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		// Back above zero but still low: the shortage was already notified.
+		if level == model.AlertLow {
+			return nil, nil
+		}
+	}
+
+	notification := model.NotifIngredientStock(ingredient)
+	return &notification, tx.Create(&notification).Error
+}
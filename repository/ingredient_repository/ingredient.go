@@ -0,0 +1,315 @@
+package ingredientrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"slices"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryIngredient interface {
+	FindIngredients(search string, page, limit int) ([]model.Ingredient, int, int, error)
+	FindIngredient(id uint) (*model.Ingredient, error)
+	Create(ingredient *model.Ingredient) error
+	Update(ingredient *model.Ingredient) error
+	FindHistory(ingredientID uint, page, limit int) ([]model.IngredientMovement, int, int, error)
+	Record(movement *model.IngredientMovement) error
+	FindRecipe(productID uint) (*model.Recipe, error)
+	SaveRecipe(productID uint, form model.FormRecipe) (*model.Recipe, error)
+	HasRecipe(productID uint) (bool, error)
+	EvaluateAlerts(ingredientIDs []uint) ([]model.Notification, error)
+	MovedIngredients(orderID uint) ([]uint, error)
+}
+
+var ErrIngredientNotFound = errors.New(" Ingredient Not Found")
+
+type repositoryIngredient struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewIngredientRepository(db *gorm.DB, log *zap.Logger) RepositoryIngredient {
+	return &repositoryIngredient{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryIngredient) FindIngredients(search string, page, limit int) ([]model.Ingredient, int, int, error) {
+	query := r.DB.Model(&model.Ingredient{})
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count ingredients", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	ingredients := []model.Ingredient{}
+	if err := query.Order("name").Order("id").Offset((page - 1) * limit).Limit(limit).Find(&ingredients).Error; err != nil {
+		r.Log.Error("Failed to find ingredients", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return ingredients, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+func (r *repositoryIngredient) FindIngredient(id uint) (*model.Ingredient, error) {
+	var ingredient model.Ingredient
+	err := r.DB.First(&ingredient, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIngredientNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find ingredient", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &ingredient, nil
+}
+
+func (r *repositoryIngredient) Create(ingredient *model.Ingredient) error {
+	if err := r.DB.Create(ingredient).Error; err != nil {
+		r.Log.Error("Failed to create ingredient", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// Update changes an ingredient's name, cost and reorder level. Its unit
+// cannot change once stocked, since stock and recipes are kept in it.
+func (r *repositoryIngredient) Update(ingredient *model.Ingredient) error {
+	result := r.DB.Model(ingredient).
+		Select("name", "cost_per_unit", "reorder_level").
+		Updates(ingredient)
+	if result.Error != nil {
+		r.Log.Error("Failed to update ingredient", zap.Uint("id", ingredient.ID), zap.Error(result.Error))
+		return errors.New(" Internal Server Error")
+	}
+	if result.RowsAffected == 0 {
+		return ErrIngredientNotFound
+	}
+	return nil
+}
+
+func (r *repositoryIngredient) FindHistory(ingredientID uint, page, limit int) ([]model.IngredientMovement, int, int, error) {
+	if _, err := r.FindIngredient(ingredientID); err != nil {
+		return nil, 0, 0, err
+	}
+
+	query := r.DB.Model(&model.IngredientMovement{}).Where("ingredient_id = ?", ingredientID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count ingredient movements", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	movements := []model.IngredientMovement{}
+	err := query.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&movements).Error
+	if err != nil {
+		r.Log.Error("Failed to find ingredient movements", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return movements, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+func (r *repositoryIngredient) Record(movement *model.IngredientMovement) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := post(tx, movement); err != nil {
+			return err
+		}
+		return refreshAvailability(tx, []uint{movement.IngredientID})
+	})
+	if err != nil {
+		if !errors.Is(err, ErrIngredientNotFound) && !errors.Is(err, stockrepository.ErrInsufficientStock) {
+			r.Log.Error("Failed to record ingredient movement", zap.Uint("ingredient", movement.IngredientID), zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repositoryIngredient) FindRecipe(productID uint) (*model.Recipe, error) {
+	if err := r.DB.Select("id").First(&model.Product{}, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, stockrepository.ErrProductNotFound
+		}
+		r.Log.Error("Failed to find product", zap.Uint("product", productID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	recipe := model.Recipe{ProductID: productID, Items: []model.RecipeItem{}}
+	if err := r.DB.Preload("Ingredient").Where("product_id = ?", productID).Order("id").Find(&recipe.Items).Error; err != nil {
+		r.Log.Error("Failed to find recipe", zap.Uint("product", productID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	for i, item := range recipe.Items {
+		recipe.FoodCost += item.Qty * item.Ingredient.CostPerUnit
+		available := int(math.Max(math.Floor(item.Ingredient.Stock/item.Qty), 0))
+		if i == 0 || available < recipe.Available {
+			recipe.Available = available
+		}
+	}
+	recipe.FoodCost = math.Round(recipe.FoodCost*100) / 100
+	return &recipe, nil
+}
+
+// SaveRecipe replaces a product's recipe and recomputes its availability.
+// Removing every item puts the product back on its own stock ledger.
+func (r *repositoryIngredient) SaveRecipe(productID uint, form model.FormRecipe) (*model.Recipe, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.Product{}, productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return stockrepository.ErrProductNotFound
+			}
+			return err
+		}
+
+		ingredientIDs := []uint{}
+		for _, item := range form.Items {
+			if !slices.Contains(ingredientIDs, item.IngredientID) {
+				ingredientIDs = append(ingredientIDs, item.IngredientID)
+			}
+		}
+		var ingredients []model.Ingredient
+		if err := tx.Where("id IN ?", ingredientIDs).Find(&ingredients).Error; err != nil {
+			return err
+		}
+
+		items := []model.RecipeItem{}
+		for _, formItem := range form.Items {
+			index := slices.IndexFunc(ingredients, func(ingredient model.Ingredient) bool { return ingredient.ID == formItem.IngredientID })
+			if index < 0 {
+				return ErrIngredientNotFound
+			}
+			qty, err := model.ConvertUnit(formItem.Qty, formItem.Unit, ingredients[index].Unit)
+			if err != nil {
+				return err
+			}
+			items = append(items, model.RecipeItem{ProductID: productID, IngredientID: formItem.IngredientID, Qty: qty})
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&model.RecipeItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return tx.Exec(`UPDATE products SET qty = COALESCE((SELECT balance FROM stock_movements WHERE product_id = ? ORDER BY id DESC LIMIT 1), 0) WHERE id = ?`, productID, productID).Error
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		return refreshAvailability(tx, ingredientIDs)
+	})
+	if err != nil {
+		if !errors.Is(err, stockrepository.ErrProductNotFound) && !errors.Is(err, ErrIngredientNotFound) && !errors.Is(err, model.ErrUnitMismatch) {
+			r.Log.Error("Failed to save recipe", zap.Uint("product", productID), zap.Error(err))
+			return nil, errors.New(" Internal Server Error")
+		}
+		return nil, err
+	}
+	return r.FindRecipe(productID)
+}
+
+func (r *repositoryIngredient) HasRecipe(productID uint) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&model.RecipeItem{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+		r.Log.Error("Failed to find recipe", zap.Uint("product", productID), zap.Error(err))
+		return false, errors.New(" Internal Server Error")
+	}
+	return count > 0, nil
+}
+
+// MovedIngredients returns the ingredients whose stock an order moved.
+func (r *repositoryIngredient) MovedIngredients(orderID uint) ([]uint, error) {
+	ingredientIDs := []uint{}
+	err := r.DB.Model(&model.IngredientMovement{}).Where("order_id = ?", orderID).Distinct().Pluck("ingredient_id", &ingredientIDs).Error
+	if err != nil {
+		r.Log.Error("Failed to find ingredients moved by order", zap.Uint("order", orderID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return ingredientIDs, nil
+}
+
+// Consume takes the ingredients of a product's recipe out of stock for a
+// sale, or puts them back for a void or refund, inside the caller's
+// transaction. The movement is in product units: a sale of two portions
+// has Qty -2. It reports false, posting nothing, for a product without a
+// recipe, whose own stock is to be moved instead.
+func Consume(tx *gorm.DB, movement model.StockMovement) (bool, error) {
+	var items []model.RecipeItem
+	if err := tx.Where("product_id = ?", movement.ProductID).Order("ingredient_id").Find(&items).Error; err != nil {
+		return false, err
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	ingredientIDs := []uint{}
+	for _, item := range items {
+		entry := model.IngredientMovement{
+			IngredientID: item.IngredientID,
+			Type:         movement.Type,
+			Qty:          float64(movement.Qty) * item.Qty,
+			Reason:       movement.Reason,
+			ActorID:      movement.ActorID,
+			OrderID:      movement.OrderID,
+			ProductID:    movement.ProductID,
+		}
+		if err := post(tx, &entry); err != nil {
+			return true, err
+		}
+		ingredientIDs = append(ingredientIDs, item.IngredientID)
+	}
+	return true, refreshAvailability(tx, ingredientIDs)
+}
+
+// post writes an ingredient movement and moves the ingredient's stock by
+// it, locking the ingredient like stockrepository.Post locks a product.
+func post(tx *gorm.DB, movement *model.IngredientMovement) error {
+	var ingredient model.Ingredient
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&ingredient, movement.IngredientID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrIngredientNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	balance := math.Round((ingredient.Stock+movement.Qty)*1000) / 1000
+	if movement.Qty < 0 && balance < 0 {
+		return stockrepository.ErrInsufficientStock
+	}
+	if err := tx.Model(&ingredient).Update("stock", balance).Error; err != nil {
+		return err
+	}
+
+	movement.Balance = balance
+	return tx.Create(movement).Error
+}
+
+// refreshAvailability sets the qty of every product made with the given
+// ingredients to how many can be made from the stock left, then that of
+// the bundles they are part of.
+func refreshAvailability(tx *gorm.DB, ingredientIDs []uint) error {
+	var productIDs []uint
+	if err := tx.Raw(`UPDATE products SET qty = a.available FROM (
+			SELECT r.product_id, GREATEST(MIN(FLOOR(i.stock / r.qty)), 0) AS available
+			FROM recipe_items r JOIN ingredients i ON i.id = r.ingredient_id
+			WHERE r.product_id IN (SELECT product_id FROM recipe_items WHERE ingredient_id IN ?)
+			GROUP BY r.product_id
+		) a WHERE products.id = a.product_id RETURNING products.id`, ingredientIDs).Scan(&productIDs).Error; err != nil {
+		return err
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+	return stockrepository.RefreshBundles(tx, productIDs)
+}
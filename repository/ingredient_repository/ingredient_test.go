@@ -0,0 +1,172 @@
+package ingredientrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	ingredientrepository "project_pos_app/repository/ingredient_repository"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestConsume(t *testing.T) {
+	recipeQuery := `SELECT * FROM "recipe_items" WHERE product_id = $1 ORDER BY ingredient_id`
+	ingredientQuery := `SELECT "id","stock" FROM "ingredients" WHERE "ingredients"."id" = $1 ORDER BY "ingredients"."id" LIMIT $2 FOR UPDATE`
+
+	t.Run("A sale takes every ingredient of the recipe out of stock", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		mock.ExpectBegin()
+		tx := db.Begin()
+
+		mock.ExpectQuery(regexp.QuoteMeta(recipeQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "ingredient_id", "qty"}).
+				AddRow(1, 3, 5, 0.25).
+				AddRow(2, 3, 8, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(5, 2.0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "ingredients" SET "stock"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(1.5, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "ingredient_movements"`)).
+			WithArgs(5, model.MovementSale, -0.5, 1.5, "", 0, 7, 3, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(8, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(8, 10.0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "ingredients" SET "stock"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(8.0, sqlmock.AnyArg(), 8).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "ingredient_movements"`)).
+			WithArgs(8, model.MovementSale, -2.0, 8.0, "", 0, 7, 3, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(5, 8).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		consumed, err := ingredientrepository.Consume(tx, model.StockMovement{ProductID: 3, Type: model.MovementSale, Qty: -2, OrderID: 7})
+
+		assert.NoError(t, err)
+		assert.True(t, consumed)
+	})
+
+	t.Run("A product without a recipe is left to its own stock", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		mock.ExpectBegin()
+		tx := db.Begin()
+
+		mock.ExpectQuery(regexp.QuoteMeta(recipeQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "ingredient_id", "qty"}))
+
+		consumed, err := ingredientrepository.Consume(tx, model.StockMovement{ProductID: 4, Type: model.MovementSale, Qty: -1})
+
+		assert.NoError(t, err)
+		assert.False(t, consumed)
+	})
+
+	t.Run("An ingredient cannot go below zero", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		mock.ExpectBegin()
+		tx := db.Begin()
+
+		mock.ExpectQuery(regexp.QuoteMeta(recipeQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "ingredient_id", "qty"}).AddRow(1, 3, 5, 0.25))
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(5, 0.1))
+
+		consumed, err := ingredientrepository.Consume(tx, model.StockMovement{ProductID: 3, Type: model.MovementSale, Qty: -1})
+
+		assert.True(t, consumed)
+		assert.ErrorIs(t, err, stockrepository.ErrInsufficientStock)
+	})
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	ingredientQuery := `SELECT * FROM "ingredients" WHERE id IN ($1) ORDER BY id FOR UPDATE`
+	alertQuery := `SELECT * FROM "stock_alerts" WHERE ingredient_id IN ($1) AND resolved_at IS NULL`
+	ingredientColumns := []string{"id", "name", "unit", "stock", "reorder_level"}
+	alertColumns := []string{"id", "ingredient_id", "level", "qty", "reorder_point"}
+
+	t.Run("Falling below the reorder level opens an alert and notifies once", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := ingredientrepository.NewIngredientRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(ingredientColumns).AddRow(2, "Milk", "ml", 400.0, 1000.0))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(alertColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_alerts"`)).
+			WithArgs(0, 2, model.AlertLow, 400.0, 1000.0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notifications"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{2})
+
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, "Ingredient Milk is down to 400 ml, below its reorder level of 1000 ml.", notifications[0].Message)
+	})
+
+	t.Run("An open alert is not notified again", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := ingredientrepository.NewIngredientRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(ingredientColumns).AddRow(2, "Milk", "ml", 300.0, 1000.0))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(alertColumns).AddRow(1, 2, model.AlertLow, 400.0, 1000.0))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{2})
+
+		assert.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+
+	t.Run("Restocking closes the alert", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := ingredientrepository.NewIngredientRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(ingredientQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(ingredientColumns).AddRow(2, "Milk", "ml", 5000.0, 1000.0))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(alertColumns).AddRow(1, 2, model.AlertLow, 400.0, 1000.0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stock_alerts" SET "qty"=$1,"resolved_at"=$2,"updated_at"=$3 WHERE "id" = $4`)).
+			WithArgs(5000.0, sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{2})
+
+		assert.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+}
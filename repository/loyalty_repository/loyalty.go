@@ -0,0 +1,302 @@
+package loyaltyrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryLoyalty interface {
+	FindAccount(customerID uint) (*model.LoyaltyAccount, error)
+	Adjust(customerID uint, form model.FormPointsAdjust) (*model.LoyaltyLedger, error)
+	ExpirePoints(now time.Time) ([]model.LoyaltyLedger, error)
+}
+
+var (
+	ErrInsufficientPoints = errors.New(" Insufficient Points")
+	ErrNoCustomer         = errors.New(" Order Has No Customer")
+)
+
+type repositoryLoyalty struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+	// settings are the earn and redemption rules.
+	settings model.LoyaltySettings
+}
+
+func NewLoyaltyRepository(db *gorm.DB, log *zap.Logger, settings model.LoyaltySettings) RepositoryLoyalty {
+	return &repositoryLoyalty{
+		DB:       db,
+		Log:      log,
+		settings: settings,
+	}
+}
+
+func (r *repositoryLoyalty) FindAccount(customerID uint) (*model.LoyaltyAccount, error) {
+	var customer model.Customer
+	err := r.DB.First(&customer, customerID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New(" Customer Not Found")
+	}
+	if err != nil {
+		r.Log.Error("Failed to find loyalty customer", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	account := model.LoyaltyAccount{
+		CustomerID:     customer.ID,
+		Points:         customer.Points,
+		LifetimePoints: customer.LifetimePoints,
+		Tier:           customer.Tier,
+		Ledger:         []model.LoyaltyLedger{},
+	}
+	err = r.DB.Where("customer_id = ?", customerID).Order("created_at DESC").Order("id DESC").Find(&account.Ledger).Error
+	if err != nil {
+		r.Log.Error("Failed to find loyalty ledger", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &account, nil
+}
+
+// Adjust corrects a customer's balance by hand. Added points form a new lot
+// that expires like earned points and count towards the tier; removed points
+// are taken from the oldest lots.
+func (r *repositoryLoyalty) Adjust(customerID uint, form model.FormPointsAdjust) (*model.LoyaltyLedger, error) {
+	entry := model.LoyaltyLedger{CustomerID: customerID, Type: model.LedgerAdjust, Points: form.Points, Reason: form.Reason}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		customer, err := lockCustomer(tx, customerID)
+		if err != nil {
+			return err
+		}
+		if form.Points > 0 {
+			entry.Remaining = form.Points
+			entry.ExpiresAt = expiry(r.settings, time.Now())
+			customer.LifetimePoints += form.Points
+		} else {
+			if customer.Points < -form.Points {
+				return ErrInsufficientPoints
+			}
+			if err := consume(tx, customerID, -form.Points); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		customer.Points += form.Points
+		customer.Tier = r.settings.TierFor(customer.LifetimePoints).Name
+		return saveBalance(tx, customer)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInsufficientPoints) && err.Error() != " Customer Not Found" {
+			r.Log.Error("Failed to adjust points", zap.Uint("customer", customerID), zap.Error(err))
+			return nil, errors.New(" Internal Server Error")
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ExpirePoints writes off what is left of the lots that expired before now
+// and returns the expiry entries, one per customer.
+func (r *repositoryLoyalty) ExpirePoints(now time.Time) ([]model.LoyaltyLedger, error) {
+	var customerIDs []uint
+	err := r.DB.Model(&model.LoyaltyLedger{}).
+		Where("remaining > 0 AND expires_at < ?", now).
+		Distinct().Pluck("customer_id", &customerIDs).Error
+	if err != nil {
+		r.Log.Error("Failed to find expired points", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	expired := []model.LoyaltyLedger{}
+	for _, customerID := range customerIDs {
+		entry := model.LoyaltyLedger{CustomerID: customerID, Type: model.LedgerExpire, Reason: "Points expired"}
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			customer, err := lockCustomer(tx, customerID)
+			if err != nil {
+				return err
+			}
+			var lots []model.LoyaltyLedger
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("customer_id = ? AND remaining > 0 AND expires_at < ?", customerID, now).
+				Find(&lots).Error
+			if err != nil {
+				return err
+			}
+			for _, lot := range lots {
+				entry.Points -= lot.Remaining
+				if err := tx.Model(&lot).Update("remaining", 0).Error; err != nil {
+					return err
+				}
+			}
+			if entry.Points == 0 {
+				return nil
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			customer.Points = max(customer.Points+entry.Points, 0)
+			return saveBalance(tx, customer)
+		})
+		if err != nil {
+			r.Log.Error("Failed to expire points", zap.Uint("customer", customerID), zap.Error(err))
+			continue
+		}
+		if entry.Points != 0 {
+			expired = append(expired, entry)
+		}
+	}
+	return expired, nil
+}
+
+// SettleOrder applies the customer's tier discount and point redemption to
+// an order being paid and credits the points it earns, inside the payment
+// transaction. The customer row stays locked until the transaction ends, so
+// the same points cannot be spent twice.
+//
+// The tier discount comes off the lines before tax; redeemed points are a
+// tender against what is left to pay. Points are earned on the discounted
+// lines, times their category multiplier, for the share not paid in points.
+func SettleOrder(tx *gorm.DB, settings model.LoyaltySettings, customerID uint, order *model.Order, lines []model.LoyaltyLine, credit float64) error {
+	if customerID == 0 {
+		if order.RedeemPoints > 0 {
+			return ErrNoCustomer
+		}
+		return nil
+	}
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return err
+	}
+	if order.RedeemPoints > customer.Points {
+		return ErrInsufficientPoints
+	}
+	rate := settings.Discount(customer.Tier) / 100
+	subtotal, earnBase := 0.0, 0.0
+	multipliers, err := categoryMultipliers(tx, lines)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		subtotal += line.Subtotal
+		earnBase += line.Subtotal * (1 - rate) * multipliers[line.CategoryID]
+	}
+	discount := subtotal * rate
+	due := math.Max((subtotal-discount)*(1+order.Tax/100)-credit, 0)
+
+	redeemed, redeemedValue := 0, 0.0
+	if order.RedeemPoints > 0 && settings.PointValue > 0 {
+		redeemed = min(order.RedeemPoints, int(math.Ceil(due/settings.PointValue)))
+		redeemedValue = math.Min(float64(redeemed)*settings.PointValue, due)
+		if err := consume(tx, customerID, redeemed); err != nil {
+			return err
+		}
+		entry := model.LoyaltyLedger{CustomerID: customerID, OrderID: order.ID, Type: model.LedgerRedeem, Points: -redeemed}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	earned := 0
+	if due > 0 && settings.EarnUnit > 0 {
+		earned = int(math.Floor(earnBase * (due - redeemedValue) / due / settings.EarnUnit))
+	}
+	if earned > 0 {
+		entry := model.LoyaltyLedger{
+			CustomerID: customerID,
+			OrderID:    order.ID,
+			Type:       model.LedgerEarn,
+			Points:     earned,
+			Remaining:  earned,
+			ExpiresAt:  expiry(settings, time.Now()),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	customer.Points += earned - redeemed
+	customer.LifetimePoints += earned
+	customer.Tier = settings.TierFor(customer.LifetimePoints).Name
+	if err := saveBalance(tx, customer); err != nil {
+		return err
+	}
+
+	order.Discount = discount + redeemedValue
+	order.TotalAmount = due - redeemedValue
+	order.PointsRedeemed = redeemed
+	order.PointsEarned = earned
+	return nil
+}
+
+func lockCustomer(tx *gorm.DB, customerID uint) (*model.Customer, error) {
+	var customer model.Customer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New(" Customer Not Found")
+	}
+	return &customer, err
+}
+
+func saveBalance(tx *gorm.DB, customer *model.Customer) error {
+	return tx.Model(customer).Select("points", "lifetime_points", "tier").Updates(customer).Error
+}
+
+// consume takes points from the customer's lots, soonest to expire first.
+func consume(tx *gorm.DB, customerID uint, points int) error {
+	var lots []model.LoyaltyLedger
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ? AND remaining > 0", customerID).
+		Order("expires_at").Order("id").
+		Find(&lots).Error
+	if err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if points == 0 {
+			break
+		}
+		used := min(points, lot.Remaining)
+		if err := tx.Model(&lot).Update("remaining", lot.Remaining-used).Error; err != nil {
+			return err
+		}
+		points -= used
+	}
+	return nil
+}
+
+// categoryMultipliers maps the categories of the lines to their points
+// multiplier; a category without one counts once.
+func categoryMultipliers(tx *gorm.DB, lines []model.LoyaltyLine) (map[uint]float64, error) {
+	ids := []uint{}
+	multipliers := map[uint]float64{}
+	for _, line := range lines {
+		if _, ok := multipliers[line.CategoryID]; !ok {
+			multipliers[line.CategoryID] = 1
+			ids = append(ids, line.CategoryID)
+		}
+	}
+	var categories []model.Category
+	if err := tx.Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.PointsMultiplier > 0 {
+			multipliers[category.ID] = category.PointsMultiplier
+		}
+	}
+	return multipliers, nil
+}
+
+func expiry(settings model.LoyaltySettings, from time.Time) *time.Time {
+	if settings.ExpiryDays <= 0 {
+		return nil
+	}
+	expiresAt := from.AddDate(0, 0, settings.ExpiryDays)
+	return &expiresAt
+}
@@ -0,0 +1,91 @@
+package loyaltyrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	loyaltyrepository "project_pos_app/repository/loyalty_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSettleOrder(t *testing.T) {
+	settings := model.LoyaltySettings{
+		EarnUnit:   10,
+		PointValue: 1,
+		ExpiryDays: 365,
+		Tiers: []model.LoyaltyTier{
+			{Name: model.TierSilver, Threshold: 500, Discount: 5},
+			{Name: model.TierGold, Threshold: 2000, Discount: 10},
+		},
+	}
+
+	customerQuery := `SELECT * FROM "customers" WHERE "customers"."id" = $1 ORDER BY "customers"."id" LIMIT $2 FOR UPDATE`
+	customerColumns := []string{"id", "name", "points", "lifetime_points", "tier"}
+
+	t.Run("Redeem points, apply the tier discount and earn on the rest", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		order := &model.Order{ID: 5, Tax: 0, RedeemPoints: 20}
+		lines := []model.LoyaltyLine{{Subtotal: 200, CategoryID: 1}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(customerQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows(customerColumns).AddRow(3, "John", 50, 600, model.TierSilver))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "categories" WHERE id IN ($1)`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "points_multiplier"}).AddRow(1, 2))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loyalty_ledgers" WHERE customer_id = $1 AND remaining > 0 ORDER BY expires_at,id FOR UPDATE`)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "remaining"}).AddRow(1, 3, 50))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loyalty_ledgers" SET "remaining"=$1 WHERE "id" = $2`)).
+			WithArgs(30, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loyalty_ledgers"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loyalty_ledgers"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "customers" SET "points"=$1,"lifetime_points"=$2,"tier"=$3`)).
+			WithArgs(64, 634, model.TierSilver, sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return loyaltyrepository.SettleOrder(tx, settings, 3, order, lines, 0)
+		})
+
+		assert.NoError(t, err)
+		// 5% silver discount on 200 leaves 190, 20 of it paid in points.
+		assert.Equal(t, 170.0, order.TotalAmount)
+		assert.Equal(t, 30.0, order.Discount)
+		assert.Equal(t, 20, order.PointsRedeemed)
+		// 190 * 2 (category multiplier) * 170/190 paid / 10 per point.
+		assert.Equal(t, 34, order.PointsEarned)
+	})
+
+	t.Run("Reject redeeming more points than the balance", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		order := &model.Order{ID: 5, RedeemPoints: 80}
+
+		mock.ExpectQuery(regexp.QuoteMeta(customerQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows(customerColumns).AddRow(3, "John", 50, 600, model.TierSilver))
+
+		err := loyaltyrepository.SettleOrder(db, settings, 3, order, nil, 0)
+
+		assert.ErrorIs(t, err, loyaltyrepository.ErrInsufficientPoints)
+	})
+
+	t.Run("Reject redeeming points on an order without customer", func(t *testing.T) {
+		db, _ := helper.SetupTestDB()
+
+		err := loyaltyrepository.SettleOrder(db, settings, 0, &model.Order{RedeemPoints: 10}, nil, 0)
+
+		assert.ErrorIs(t, err, loyaltyrepository.ErrNoCustomer)
+	})
+}
@@ -0,0 +1,280 @@
+package menurepository
+
+import (
+	"errors"
+	"project_pos_app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RepositoryMenu interface {
+	FindMenus() ([]model.Menu, error)
+	FindMenu(id uint) (*model.Menu, error)
+	Create(menu *model.Menu) error
+	Update(menu *model.Menu) error
+	Delete(id uint) error
+	FindProducts(ids []uint) ([]model.Product, error)
+	FindSellableProducts() ([]model.Product, error)
+	SetEightySixed(productID uint, eightySixed bool) error
+	ClearEightySixed() (int64, error)
+}
+
+var (
+	ErrMenuNotFound    = errors.New(" Menu Not Found")
+	ErrProductNotFound = errors.New(" Product Not Found")
+	ErrUnknownItem     = errors.New(" Menu Lists An Unknown Category Or Product")
+)
+
+type repositoryMenu struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewMenuRepository(db *gorm.DB, log *zap.Logger) RepositoryMenu {
+	return &repositoryMenu{
+		DB:  db,
+		Log: log,
+	}
+}
+
+// FindMenus returns every menu with its schedules and items, by name.
+func (r *repositoryMenu) FindMenus() ([]model.Menu, error) {
+	menus := []model.Menu{}
+	if err := r.DB.Preload("Schedules").Order("name").Order("id").Find(&menus).Error; err != nil {
+		r.Log.Error("Failed to find menus", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	if err := r.attachItems(menus); err != nil {
+		r.Log.Error("Failed to find menu items", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return menus, nil
+}
+
+func (r *repositoryMenu) FindMenu(id uint) (*model.Menu, error) {
+	var menu model.Menu
+	err := r.DB.Preload("Schedules").First(&menu, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMenuNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find menu", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	menus := []model.Menu{menu}
+	if err := r.attachItems(menus); err != nil {
+		r.Log.Error("Failed to find menu items", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &menus[0], nil
+}
+
+// attachItems fills in the categories and products assigned to menus.
+func (r *repositoryMenu) attachItems(menus []model.Menu) error {
+	if len(menus) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(menus))
+	index := make(map[uint]int, len(menus))
+	for i, menu := range menus {
+		ids[i] = menu.ID
+		index[menu.ID] = i
+		menus[i].CategoryIDs = []uint{}
+		menus[i].ProductIDs = []uint{}
+	}
+
+	var categories []model.MenuCategory
+	if err := r.DB.Where("menu_id IN ?", ids).Order("category_id").Find(&categories).Error; err != nil {
+		return err
+	}
+	for _, item := range categories {
+		menu := &menus[index[item.MenuID]]
+		menu.CategoryIDs = append(menu.CategoryIDs, item.CategoryID)
+	}
+
+	var products []model.MenuProduct
+	if err := r.DB.Where("menu_id IN ?", ids).Order("product_id").Find(&products).Error; err != nil {
+		return err
+	}
+	for _, item := range products {
+		menu := &menus[index[item.MenuID]]
+		menu.ProductIDs = append(menu.ProductIDs, item.ProductID)
+	}
+	return nil
+}
+
+// Create saves a menu with its schedules and items.
+func (r *repositoryMenu) Create(menu *model.Menu) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(menu).Error; err != nil {
+			return err
+		}
+		return saveItems(tx, menu)
+	})
+	if errors.Is(err, ErrUnknownItem) {
+		return err
+	}
+	if err != nil {
+		r.Log.Error("Failed to create menu", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// Update replaces a menu's name, schedules and items.
+func (r *repositoryMenu) Update(menu *model.Menu) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(menu).Select("name", "active").Updates(menu)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMenuNotFound
+		}
+
+		if err := tx.Where("menu_id = ?", menu.ID).Delete(&model.MenuSchedule{}).Error; err != nil {
+			return err
+		}
+		for i := range menu.Schedules {
+			menu.Schedules[i].MenuID = menu.ID
+		}
+		if len(menu.Schedules) > 0 {
+			if err := tx.Create(&menu.Schedules).Error; err != nil {
+				return err
+			}
+		}
+		return saveItems(tx, menu)
+	})
+	if errors.Is(err, ErrMenuNotFound) || errors.Is(err, ErrUnknownItem) {
+		return err
+	}
+	if err != nil {
+		r.Log.Error("Failed to update menu", zap.Uint("id", menu.ID), zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// saveItems replaces the categories and products assigned to menu, which
+// must all exist and not be deleted.
+func saveItems(tx *gorm.DB, menu *model.Menu) error {
+	if err := tx.Where("menu_id = ?", menu.ID).Delete(&model.MenuCategory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("menu_id = ?", menu.ID).Delete(&model.MenuProduct{}).Error; err != nil {
+		return err
+	}
+
+	if len(menu.CategoryIDs) > 0 {
+		var count int64
+		if err := tx.Model(&model.Category{}).Where("id IN ? AND deleted_at IS NULL", menu.CategoryIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(menu.CategoryIDs) {
+			return ErrUnknownItem
+		}
+		items := make([]model.MenuCategory, len(menu.CategoryIDs))
+		for i, id := range menu.CategoryIDs {
+			items[i] = model.MenuCategory{MenuID: menu.ID, CategoryID: id}
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(menu.ProductIDs) > 0 {
+		var count int64
+		if err := tx.Model(&model.Product{}).Where("id IN ? AND deleted_at IS NULL", menu.ProductIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(menu.ProductIDs) {
+			return ErrUnknownItem
+		}
+		items := make([]model.MenuProduct, len(menu.ProductIDs))
+		for i, id := range menu.ProductIDs {
+			items[i] = model.MenuProduct{MenuID: menu.ID, ProductID: id}
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a menu. Its products stay, sold on their other menus or,
+// when it was the only one, at any time.
+func (r *repositoryMenu) Delete(id uint) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, item := range []interface{}{&model.MenuSchedule{}, &model.MenuCategory{}, &model.MenuProduct{}} {
+			if err := tx.Where("menu_id = ?", id).Delete(item).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&model.Menu{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMenuNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, ErrMenuNotFound) {
+		return err
+	}
+	if err != nil {
+		r.Log.Error("Failed to delete menu", zap.Uint("id", id), zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// FindProducts fetches the products ordered, to check they can be sold.
+func (r *repositoryMenu) FindProducts(ids []uint) ([]model.Product, error) {
+	products := []model.Product{}
+	if err := r.DB.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		r.Log.Error("Failed to find products", zap.Uints("ids", ids), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return products, nil
+}
+
+// FindSellableProducts fetches the products whose status lets them be
+// sold and that have not run out, leaving schedules to the caller.
+func (r *repositoryMenu) FindSellableProducts() ([]model.Product, error) {
+	products := []model.Product{}
+	err := r.DB.Where("deleted_at IS NULL AND eighty_sixed = ? AND LOWER(status) IN ?", false, model.ActiveStatuses).
+		Order("category_id").Order("name").Order("id").
+		Find(&products).Error
+	if err != nil {
+		r.Log.Error("Failed to find sellable products", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return products, nil
+}
+
+// SetEightySixed marks a product as run out for the rest of the service,
+// or puts it back on sale.
+func (r *repositoryMenu) SetEightySixed(productID uint, eightySixed bool) error {
+	result := r.DB.Model(&model.Product{}).Where("id = ? AND deleted_at IS NULL", productID).Update("eighty_sixed", eightySixed)
+	if result.Error != nil {
+		r.Log.Error("Failed to 86 product", zap.Uint("product", productID), zap.Error(result.Error))
+		return errors.New(" Internal Server Error")
+	}
+	if result.RowsAffected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// ClearEightySixed puts every 86'd product back on sale and returns how
+// many there were.
+func (r *repositoryMenu) ClearEightySixed() (int64, error) {
+	result := r.DB.Model(&model.Product{}).Where("eighty_sixed = ?", true).Update("eighty_sixed", false)
+	if result.Error != nil {
+		r.Log.Error("Failed to clear 86'd products", zap.Error(result.Error))
+		return 0, errors.New(" Internal Server Error")
+	}
+	return result.RowsAffected, nil
+}
@@ -4,16 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"project_pos_app/model"
+	bundlerepository "project_pos_app/repository/bundle_repository"
+	drawerrepository "project_pos_app/repository/drawer_repository"
+	ingredientrepository "project_pos_app/repository/ingredient_repository"
+	loyaltyrepository "project_pos_app/repository/loyalty_repository"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"slices"
 	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository interface {
 	GetAllOrder(search, status string) ([]*model.OrderResponse, error)
+	GetOrdersByCustomer(customerID uint) ([]*model.OrderResponse, error)
 	CreateOrder(order *model.Order) error
 	UpdateOrder(id int, order *model.Order) error
+	FindOrderProductIDs(orderID int) ([]uint, error)
 	GetAllTable() ([]*model.Table, error)
 	GetAllPayment() ([]*model.Payment, error)
 	DeleteOrder(id int) error
@@ -22,10 +32,12 @@ type OrderRepository interface {
 type orderRepository struct {
 	DB  *gorm.DB
 	Log *zap.Logger
+	// loyalty are the rules paid orders earn and redeem points by.
+	loyalty model.LoyaltySettings
 }
 
-func NewOrderRepo(DB *gorm.DB, Log *zap.Logger) OrderRepository {
-	return &orderRepository{DB, Log}
+func NewOrderRepo(DB *gorm.DB, Log *zap.Logger, loyalty model.LoyaltySettings) OrderRepository {
+	return &orderRepository{DB, Log, loyalty}
 }
 
 func (or *orderRepository) GetAllOrder(search, status string) ([]*model.OrderResponse, error) {
@@ -55,6 +67,37 @@ func (or *orderRepository) GetAllOrder(search, status string) ([]*model.OrderRes
 		return []*model.OrderResponse{}, nil
 	}
 
+	if err := or.attachOrderProducts(orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetOrdersByCustomer returns the orders of a customer, newest first.
+func (or *orderRepository) GetOrdersByCustomer(customerID uint) ([]*model.OrderResponse, error) {
+	orders := []*model.OrderResponse{}
+
+	if err := or.DB.Table("orders as o").
+		Select("o.id, o.table_id, o.customer_name, o.status, o.total_amount, o.created_at as order_date").
+		Where("o.deleted_at IS NULL AND o.customer_id = ?", customerID).
+		Order("o.created_at DESC").
+		Scan(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	if err := or.attachOrderProducts(orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (or *orderRepository) attachOrderProducts(orders []*model.OrderResponse) error {
 	orderIDs := []int{}
 	for _, order := range orders {
 		orderIDs = append(orderIDs, int(order.ID))
@@ -66,7 +109,7 @@ func (or *orderRepository) GetAllOrder(search, status string) ([]*model.OrderRes
 		Joins("JOIN products as p ON p.id = po.product_id").
 		Where("po.order_id IN ?", orderIDs).
 		Scan(&orderProducts).Error; err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, order := range orders {
@@ -81,7 +124,7 @@ func (or *orderRepository) GetAllOrder(search, status string) ([]*model.OrderRes
 		}
 	}
 
-	return orders, nil
+	return nil
 }
 
 func (or *orderRepository) CreateOrder(order *model.Order) error {
@@ -91,6 +134,16 @@ func (or *orderRepository) CreateOrder(order *model.Order) error {
 			return err
 		}
 
+		// The order belongs to the shift it is taken in, which cannot
+		// close until it is settled. One taken with the drawer closed
+		// joins the shift it is settled in.
+		sessionID, err := drawerrepository.OpenSessionID(tx)
+		if err != nil && !errors.Is(err, drawerrepository.ErrNoOpenDrawer) {
+			return err
+		}
+		order.DrawerSessionID = sessionID
+		order.CreatedBy = order.ActorID
+
 		if err := tx.Create(&order).Error; err != nil {
 			return err
 		}
@@ -113,6 +166,22 @@ func (or *orderRepository) CreateOrder(order *model.Order) error {
 				return fmt.Errorf("failed to fetch product with id %d: %v", op.ProductID, err)
 			}
 
+			if product.DeletedAt != nil {
+				return fmt.Errorf("product with id %d has been deleted", op.ProductID)
+			}
+
+			op.OrderID = order.ID
+			components, err := bundlerepository.Components(tx, op, nil)
+			if err != nil {
+				return err
+			}
+			if components != nil {
+				if err := sellBundle(tx, order, op, components); err != nil {
+					return err
+				}
+				continue
+			}
+
 			if product.Qty <= 0 {
 				return fmt.Errorf("product with id %d stock habis", op.ProductID)
 			}
@@ -121,9 +190,8 @@ func (or *orderRepository) CreateOrder(order *model.Order) error {
 				return fmt.Errorf("stock product with id %d less than qty", op.ProductID)
 			}
 
-			if err := tx.Model(&model.Product{}).
-				Where("id = ?", op.ProductID).
-				Update("qty", gorm.Expr("qty - ?", op.Qty)).Error; err != nil {
+			sale := model.StockMovement{ProductID: op.ProductID, Type: model.MovementSale, Qty: -op.Qty, ActorID: order.ActorID, OrderID: order.ID}
+			if err := postStock(tx, &sale); err != nil {
 				return err
 			}
 
@@ -141,13 +209,27 @@ func (or *orderRepository) UpdateOrder(id int, order *model.Order) error {
 	return or.DB.Transaction(func(tx *gorm.DB) error {
 
 		existingOrder := model.Order{}
-		if err := tx.First(&existingOrder, "id = ?", id).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existingOrder, "id = ?", id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return fmt.Errorf("order with id %d does not exist", id)
 			}
 			return err
 		}
 
+		// Payment goes into the drawer open at the time, if there is one.
+		if order.Status == "completed" && !strings.EqualFold(existingOrder.Status, "completed") {
+			sessionID, err := drawerrepository.OpenSessionID(tx)
+			if err != nil && !errors.Is(err, drawerrepository.ErrNoOpenDrawer) {
+				return err
+			}
+			order.DrawerSessionID = sessionID
+		}
+
+		// Whoever settles or voids the order is the one who served it.
+		if isClosed(order.Status) && !isClosed(existingOrder.Status) {
+			order.ServedBy = order.ActorID
+		}
+
 		if existingOrder.TableID != order.TableID {
 			if err := or.findTable(int(order.TableID)); err != nil {
 				return err
@@ -167,32 +249,89 @@ func (or *orderRepository) UpdateOrder(id int, order *model.Order) error {
 			return fmt.Errorf("failed to retrieve existing order products: %v", err)
 		}
 
-		for _, existingOrderProduct := range existingOrderProducts {
-			if err := updateStock(tx, int(existingOrderProduct.ProductID), existingOrderProduct.Qty); err != nil {
-				return err
+		existingBundleItems := []model.OrderBundleItem{}
+		if err := tx.Where("order_id = ?", id).Order("id").Find(&existingBundleItems).Error; err != nil {
+			return fmt.Errorf("failed to retrieve existing bundle items: %v", err)
+		}
+
+		// Bundles move the stock of their components, not their own.
+		sold := map[uint]int{}
+		if !strings.EqualFold(existingOrder.Status, "canceled") {
+			for _, existingOrderProduct := range existingOrderProducts {
+				if !slices.ContainsFunc(existingBundleItems, func(item model.OrderBundleItem) bool { return item.BundleID == existingOrderProduct.ProductID }) {
+					sold[existingOrderProduct.ProductID] += existingOrderProduct.Qty
+				}
+			}
+			for _, item := range existingBundleItems {
+				sold[item.ProductID] += item.Qty
 			}
 		}
+		ordered := map[uint]int{}
+		bundleItems := []model.OrderBundleItem{}
+		if order.Status != "canceled" {
+			for _, orderProduct := range order.OrderProducts {
+				orderProduct.OrderID = uint(id)
+				previous := []model.OrderBundleItem{}
+				for _, item := range existingBundleItems {
+					if item.BundleID == orderProduct.ProductID {
+						previous = append(previous, item)
+					}
+				}
+				components, err := bundlerepository.Components(tx, orderProduct, previous)
+				if err != nil {
+					return err
+				}
+				if components == nil {
+					ordered[orderProduct.ProductID] += orderProduct.Qty
+					continue
+				}
+				for _, component := range components {
+					ordered[component.ProductID] += component.Qty
+				}
+				bundleItems = append(bundleItems, components...)
+			}
+		}
+		returned := model.MovementVoid
+		if strings.EqualFold(existingOrder.Status, "completed") {
+			returned = model.MovementRefund
+		}
+		if err := updateStock(tx, uint(id), order.ActorID, sold, ordered, returned); err != nil {
+			return err
+		}
 
 		if order.Status != "canceled" {
 			if err := tx.Where("order_id = ?", id).Delete(&model.OrderProduct{}).Error; err != nil {
 				return fmt.Errorf("failed to delete order products: %v", err)
 			}
+			if len(existingBundleItems) > 0 {
+				if err := tx.Where("order_id = ?", id).Delete(&model.OrderBundleItem{}).Error; err != nil {
+					return fmt.Errorf("failed to delete bundle items: %v", err)
+				}
+			}
+			if len(bundleItems) > 0 {
+				if err := tx.Create(&bundleItems).Error; err != nil {
+					return fmt.Errorf("failed to record bundle items: %v", err)
+				}
+			}
 
 			var totalAmount float64
+			lines := []model.LoyaltyLine{}
 			for _, orderProduct := range order.OrderProducts {
 				product := model.Product{}
 				if err := tx.First(&product, "id = ?", orderProduct.ProductID).Error; err != nil {
 					return fmt.Errorf("failed to find product with id %d: %v", orderProduct.ProductID, err)
 				}
 
+				// Deleted products stay on the orders they were already on.
+				if product.DeletedAt != nil && !slices.ContainsFunc(existingOrderProducts, func(existing model.OrderProduct) bool {
+					return existing.ProductID == product.ID
+				}) {
+					return fmt.Errorf("product with id %d has been deleted", orderProduct.ProductID)
+				}
+
 				subtotal := float64(orderProduct.Qty) * product.Price
 				totalAmount += subtotal
-
-				if err := tx.Model(&model.Product{}).
-					Where("id = ?", orderProduct.ProductID).
-					Update("qty", gorm.Expr("qty - ?", orderProduct.Qty)).Error; err != nil {
-					return fmt.Errorf("failed to deduct stock for product %d: %v", orderProduct.ProductID, err)
-				}
+				lines = append(lines, model.LoyaltyLine{Subtotal: subtotal, CategoryID: product.CategoryID})
 
 				orderProduct.ID = 0
 				orderProduct.OrderID = uint(id)
@@ -202,7 +341,16 @@ func (or *orderRepository) UpdateOrder(id int, order *model.Order) error {
 				}
 			}
 
-			order.TotalAmount = totalAmount + (totalAmount * order.Tax / 100)
+			order.TotalAmount = totalAmount + (totalAmount * order.Tax / 100) - existingOrder.Credit
+			if order.TotalAmount < 0 {
+				order.TotalAmount = 0
+			}
+
+			if order.Status == "completed" && !strings.EqualFold(existingOrder.Status, "completed") {
+				if err := loyaltyrepository.SettleOrder(tx, or.loyalty, existingOrder.CustomerID, order, lines, existingOrder.Credit); err != nil {
+					return err
+				}
+			}
 
 		}
 
@@ -210,6 +358,14 @@ func (or *orderRepository) UpdateOrder(id int, order *model.Order) error {
 			tx.Model(&model.Table{}).Where("id = ?", order.TableID).Update("is_book", false)
 		}
 
+		if order.Status == "completed" && existingOrder.ReservationID != 0 {
+			if err := tx.Model(&model.Reservation{}).
+				Where("id = ? AND status = ?", existingOrder.ReservationID, model.ReservationSeated).
+				Update("status", model.ReservationCompleted).Error; err != nil {
+				return fmt.Errorf("failed to complete reservation: %v", err)
+			}
+		}
+
 		if err := tx.Model(&model.Order{}).Where("id = ?", id).Updates(&order).Error; err != nil {
 			return fmt.Errorf("failed to update order: %v", err)
 		}
@@ -218,6 +374,15 @@ func (or *orderRepository) UpdateOrder(id int, order *model.Order) error {
 	})
 }
 
+// FindOrderProductIDs returns the products already on an order.
+func (or *orderRepository) FindOrderProductIDs(orderID int) ([]uint, error) {
+	productIDs := []uint{}
+	if err := or.DB.Model(&model.OrderProduct{}).Where("order_id = ?", orderID).Distinct().Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve order products: %v", err)
+	}
+	return productIDs, nil
+}
+
 func (or *orderRepository) DeleteOrder(id int) error {
 	result := or.DB.Where("id = ?", id).Delete(&model.Order{})
 
@@ -232,13 +397,69 @@ func (or *orderRepository) DeleteOrder(id int) error {
 	return nil
 }
 
-func updateStock(tx *gorm.DB, id, qty int) error {
+// sellBundle takes the components of a bundle line out of stock, each
+// through its own ledger or recipe, and records what was sold.
+func sellBundle(tx *gorm.DB, order *model.Order, op model.OrderProduct, components []model.OrderBundleItem) error {
+	for _, component := range components {
+		sale := model.StockMovement{ProductID: component.ProductID, Type: model.MovementSale, Qty: -component.Qty, ActorID: order.ActorID, OrderID: order.ID}
+		if err := postStock(tx, &sale); err != nil {
+			return fmt.Errorf("failed to sell product %d of bundle %d: %w", component.ProductID, op.ProductID, err)
+		}
+	}
+	if err := tx.Create(&op).Error; err != nil {
+		return err
+	}
+	return tx.Create(&components).Error
+}
+
+// updateStock posts the difference between what an order had sold and what
+// it now orders: a sale for products added, and a void, or a refund once the
+// order was paid, for products taken off. Products go in id order so that
+// concurrent edits lock them in the same order.
+func updateStock(tx *gorm.DB, orderID, actorID uint, sold, ordered map[uint]int, returned string) error {
+	productIDs := []uint{}
+	for productID := range sold {
+		productIDs = append(productIDs, productID)
+	}
+	for productID := range ordered {
+		if _, ok := sold[productID]; !ok {
+			productIDs = append(productIDs, productID)
+		}
+	}
+	slices.Sort(productIDs)
 
-	if err := tx.Model(&model.Product{}).
-		Where("id = ?", id).
-		Update("qty", gorm.Expr("qty + ?", qty)).Error; err != nil {
-		return fmt.Errorf("failed to restore stock for product %d: %v", id, err)
+	for _, productID := range productIDs {
+		diff := sold[productID] - ordered[productID]
+		if diff == 0 {
+			continue
+		}
+		movement := model.StockMovement{ProductID: productID, Type: model.MovementSale, Qty: diff, ActorID: actorID, OrderID: orderID}
+		if diff > 0 {
+			movement.Type = returned
+		}
+		if err := postStock(tx, &movement); err != nil {
+			return fmt.Errorf("failed to update stock for product %d: %w", productID, err)
+		}
 	}
 
 	return nil
 }
+
+// postStock moves the ingredients of a product made from a recipe, or the
+// product's own stock otherwise.
+func postStock(tx *gorm.DB, movement *model.StockMovement) error {
+	made, err := ingredientrepository.Consume(tx, *movement)
+	if err != nil || made {
+		return err
+	}
+	return stockrepository.Post(tx, movement)
+}
+
+// isClosed reports whether an order in status was settled or voided.
+func isClosed(status string) bool {
+	switch strings.ToLower(status) {
+	case "completed", "canceled", "cancelled":
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package orderrepository_test
 
 import (
+	"errors"
 	"fmt"
 	"project_pos_app/helper"
 	"project_pos_app/model"
@@ -20,7 +21,7 @@ func TestGetAllOrder(t *testing.T) {
 	defer func() { _ = mock.ExpectationsWereMet() }()
 
 	log := zap.NewNop()
-	orderRepo := orderrepository.NewOrderRepo(db, log)
+	orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 	t.Run("Successfully get all orders", func(t *testing.T) {
 		search, status := "John", "Completed"
@@ -116,13 +117,17 @@ func TestCreateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tables" WHERE id = $1 AND "tables"."deleted_at" IS NULL ORDER BY "tables"."id" LIMIT $2`)).
 			WithArgs(order.TableID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "is_book"}).AddRow(order.TableID, false))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR SHARE`)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
 		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
 			WithArgs(order.TableID,
 				order.CustomerName,
@@ -130,6 +135,15 @@ func TestCreateOrder(t *testing.T) {
 				order.TotalAmount,
 				order.Tax,
 				order.PaymentMethod,
+				order.ReservationID,
+				order.Credit,
+				order.CustomerID,
+				order.Discount,
+				order.PointsEarned,
+				order.PointsRedeemed,
+				4,
+				0,
+				0,
 				sqlmock.AnyArg(),
 				sqlmock.AnyArg(),
 				sqlmock.AnyArg(),
@@ -144,10 +158,30 @@ func TestCreateOrder(t *testing.T) {
 			WithArgs(order.OrderProducts[0].ProductID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(order.OrderProducts[0].ProductID, 10))
 
-		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=qty - $1,"updated_at"=$2 WHERE id = $3`)).
-			WithArgs(order.OrderProducts[0].Qty, sqlmock.AnyArg(), order.OrderProducts[0].ProductID).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bundle_items" WHERE bundle_id = $1 ORDER BY id`)).
+			WithArgs(order.OrderProducts[0].ProductID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bundle_id", "product_id", "qty"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "recipe_items" WHERE product_id = $1 ORDER BY ingredient_id`)).
+			WithArgs(order.OrderProducts[0].ProductID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "ingredient_id", "qty"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(order.OrderProducts[0].ProductID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(order.OrderProducts[0].ProductID, 10))
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(8, sqlmock.AnyArg(), order.OrderProducts[0].ProductID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(order.OrderProducts[0].ProductID, model.MovementSale, -order.OrderProducts[0].Qty, 8, 0.0, "", 0, 1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(order.OrderProducts[0].ProductID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
 		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_products"`)).
 			WithArgs(1, order.OrderProducts[0].ProductID, order.OrderProducts[0].Qty).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
@@ -170,7 +204,7 @@ func TestCreateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 
@@ -191,13 +225,17 @@ func TestCreateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tables" WHERE id = $1 AND "tables"."deleted_at" IS NULL ORDER BY "tables"."id" LIMIT $2`)).
 			WithArgs(order.TableID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "is_book"}).AddRow(order.TableID, false))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR SHARE`)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
 		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
 			WithArgs(order.TableID,
 				order.CustomerName,
@@ -205,6 +243,15 @@ func TestCreateOrder(t *testing.T) {
 				order.TotalAmount,
 				order.Tax,
 				order.PaymentMethod,
+				order.ReservationID,
+				order.Credit,
+				order.CustomerID,
+				order.Discount,
+				order.PointsEarned,
+				order.PointsRedeemed,
+				0,
+				0,
+				0,
 				sqlmock.AnyArg(),
 				sqlmock.AnyArg(),
 				sqlmock.AnyArg(),
@@ -225,7 +272,7 @@ func TestCreateOrder(t *testing.T) {
 // 	defer func() { _ = mock.ExpectationsWereMet() }()
 //
 // 	log := zap.NewNop()
-// 	orderRepo := orderrepository.NewOrderRepo(db, log)
+// 	orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 //
 // 	t.Run("Successfully delete order", func(t *testing.T) {
 // 		orderID := 1
@@ -307,7 +354,7 @@ func TestUpdateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 
@@ -316,25 +363,50 @@ func TestUpdateOrder(t *testing.T) {
 			WithArgs(order.ID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "table_id", "status"}).AddRow(order.ID, 1, "pending"))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR SHARE`)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_products" WHERE order_id = $1`)).
 			WithArgs(order.ID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "qty"}).AddRow(1, 1, 5))
 
-		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=qty + $1,"updated_at"=$2 WHERE id = $3`)).
-			WithArgs(5, sqlmock.AnyArg(), 1).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_bundle_items" WHERE order_id = $1 ORDER BY id`)).
+			WithArgs(order.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "bundle_id", "product_id", "qty"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bundle_items" WHERE bundle_id = $1 ORDER BY id`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bundle_id", "product_id", "qty"}))
+
+		// The order goes from 5 to 2, so 3 go back to stock.
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "recipe_items" WHERE product_id = $1 ORDER BY ingredient_id`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "ingredient_id", "qty"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(1, 10))
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(13, sqlmock.AnyArg(), 1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(1, model.MovementVoid, 3, 13, 0.0, "", 0, order.ID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
 		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "order_products" WHERE order_id = $1`)).
 			WithArgs(order.ID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 ORDER BY "products"."id" LIMIT $2`)).
 			WithArgs(order.OrderProducts[0].ProductID, 1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "stock", "price"}).AddRow(1, 10, 5000))
-
-		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=qty - $1,"updated_at"=$2 WHERE id = $3`)).
-			WithArgs(order.OrderProducts[0].Qty, sqlmock.AnyArg(), order.OrderProducts[0].ProductID).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(1, 13, 5000))
 
 		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_products"`)).
 			WithArgs(sqlmock.AnyArg(), order.OrderProducts[0].ProductID, order.OrderProducts[0].Qty).
@@ -349,7 +421,7 @@ func TestUpdateOrder(t *testing.T) {
 				order.TableID,
 				order.CustomerName,
 				sqlmock.AnyArg(),
-				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
@@ -359,6 +431,31 @@ func TestUpdateOrder(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, order.TotalAmount, 11200.0) // Total amount = (2 * 5000) + 10% tax
 		assert.Equal(t, order.Status, "completed")
+		assert.Equal(t, uint(4), order.DrawerSessionID)
+	})
+
+	t.Run("Settling goes on without an open drawer", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+		orderRepo := orderrepository.NewOrderRepo(db, zap.NewNop(), model.LoyaltySettings{})
+		settled := &model.Order{ID: 1, TableID: 1, Status: "completed", PaymentMethod: 1}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE id = $1 AND "orders"."deleted_at" IS NULL ORDER BY "orders"."id" LIMIT $2`)).
+			WithArgs(settled.ID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "table_id", "status"}).AddRow(settled.ID, 1, "In Process"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_products" WHERE order_id = $1`)).
+			WithArgs(settled.ID).
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		err := orderRepo.UpdateOrder(int(settled.ID), settled)
+
+		assert.EqualError(t, err, "failed to retrieve existing order products: database error")
+		assert.Zero(t, settled.DrawerSessionID)
 	})
 
 	t.Run("Order not found", func(t *testing.T) {
@@ -366,7 +463,7 @@ func TestUpdateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE id = $1 AND "orders"."deleted_at" IS NULL ORDER BY "orders"."id" LIMIT $2`)).
@@ -385,7 +482,7 @@ func TestUpdateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 
@@ -393,6 +490,10 @@ func TestUpdateOrder(t *testing.T) {
 			WithArgs(order.ID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "table_id"}).AddRow(order.ID, 2))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR SHARE`)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tables" SET "is_book"=$1,"updated_at"=$2 WHERE id = $3 AND "tables"."deleted_at" IS NULL`)).
 			WithArgs(false, sqlmock.AnyArg(), 2).
 			WillReturnError(fmt.Errorf("failed to release old table"))
@@ -410,7 +511,7 @@ func TestUpdateOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 
@@ -418,6 +519,10 @@ func TestUpdateOrder(t *testing.T) {
 			WithArgs(order.ID, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "table_id"}).AddRow(order.ID, 1))
 
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions" WHERE status = $1 ORDER BY "drawer_sessions"."id" LIMIT $2 FOR SHARE`)).
+			WithArgs(model.DrawerOpen, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_products" WHERE order_id = $1`)).
 			WithArgs(order.OrderProducts[0].ProductID).
 			WillReturnError(gorm.ErrRecordNotFound)
@@ -442,7 +547,7 @@ func TestDeleteOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1 WHERE id = $2 AND "orders"."deleted_at" IS NULL`)).
@@ -460,7 +565,7 @@ func TestDeleteOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1 WHERE id = $2 AND "orders"."deleted_at" IS NULL`)).
@@ -479,7 +584,7 @@ func TestDeleteOrder(t *testing.T) {
 		defer func() { _ = mock.ExpectationsWereMet() }()
 
 		log := zap.NewNop()
-		orderRepo := orderrepository.NewOrderRepo(db, log)
+		orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1 WHERE id = $2 AND "orders"."deleted_at" IS NULL`)).
@@ -493,3 +598,73 @@ func TestDeleteOrder(t *testing.T) {
 		assert.EqualError(t, err, "failed to delete order: filed deletes ID 1")
 	})
 }
+
+func TestCreateOrderWithBundle(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	orderRepo := orderrepository.NewOrderRepo(db, zap.NewNop(), model.LoyaltySettings{})
+
+	// Two burger meals at 5.00, each a burger (4.00 alone) and the drink
+	// picked, a cola (2.00 alone).
+	order := &model.Order{
+		TableID:      1,
+		CustomerName: "John Doe",
+		OrderProducts: []model.OrderProduct{
+			{ProductID: 9, Qty: 2, Choices: map[string]uint{"Drink": 3}},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tables" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "is_book"}).AddRow(1, false))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "drawer_sessions"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tables" SET "is_book"=$1`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 ORDER BY "products"."id" LIMIT $2`)).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "qty", "price"}).AddRow(9, "Burger Meal", 0, 5.0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "bundle_items" WHERE bundle_id = $1 ORDER BY id`)).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bundle_id", "group_name", "product_id", "qty"}).
+			AddRow(1, 9, "", 2, 1).
+			AddRow(2, 9, "Drink", 3, 1).
+			AddRow(3, 9, "Drink", 4, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" IN ($1,$2,$3)`)).
+		WithArgs(2, 3, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "qty", "price"}).
+			AddRow(2, "Burger", 10, 4.0).
+			AddRow(3, "Cola", 10, 2.0).
+			AddRow(4, "Tea", 10, 2.0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","price" FROM "products" WHERE "products"."id" = $1`)).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(9, 5.0))
+	for _, productID := range []int{2, 3} {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "recipe_items" WHERE product_id = $1`)).
+			WithArgs(productID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(productID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(productID, 10))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(8, sqlmock.AnyArg(), productID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(productID, model.MovementSale, -2, 8, 0.0, "", 0, 7, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(productID))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(productID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_products"`)).
+		WithArgs(7, 9, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_bundle_items" ("order_id","bundle_id","group_name","product_id","qty","revenue") VALUES ($1,$2,$3,$4,$5,$6),($7,$8,$9,$10,$11,$12)`)).
+		WithArgs(7, 9, "", 2, 2, 6.67, 7, 9, "Drink", 3, 2, 3.33).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, orderRepo.CreateOrder(order))
+}
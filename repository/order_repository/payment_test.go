@@ -3,6 +3,7 @@ package orderrepository_test
 import (
 	"fmt"
 	"project_pos_app/helper"
+	"project_pos_app/model"
 	orderrepository "project_pos_app/repository/order_repository"
 	"regexp"
 	"testing"
@@ -18,7 +19,7 @@ func TestGetAllPayment(t *testing.T) {
 	defer func() { _ = mock.ExpectationsWereMet() }()
 
 	log := zap.NewNop()
-	orderRepo := orderrepository.NewOrderRepo(db, log)
+	orderRepo := orderrepository.NewOrderRepo(db, log, model.LoyaltySettings{})
 
 	t.Run("Successfully get all payments", func(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(
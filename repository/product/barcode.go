@@ -0,0 +1,110 @@
+package productrepository
+
+import (
+	"errors"
+	"project_pos_app/barcode"
+	"project_pos_app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindByBarcode finds the live product a normalized code is printed on.
+func (pr *productRepo) FindByBarcode(code string) (*model.Product, error) {
+	var product model.Product
+	err := pr.db.Preload("Barcodes").
+		Joins("JOIN product_barcodes ON product_barcodes.product_id = products.id").
+		Where("product_barcodes.code = ? AND products.deleted_at IS NULL", code).
+		First(&product).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownBarcode
+		}
+		pr.log.Error("Error looking up barcode", zap.String("code", code), zap.Error(err))
+		return nil, err
+	}
+	return &product, nil
+}
+
+// AddBarcode gives a product another normalized code. A code belongs to
+// one product only, deleted products included, so restoring one never
+// makes a scan ambiguous.
+func (pr *productRepo) AddBarcode(productID uint, code string) (*model.ProductBarcode, error) {
+	productBarcode := model.ProductBarcode{ProductID: productID, Code: code}
+	err := pr.db.Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("deleted_at IS NULL").First(&product, productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		var taken int64
+		if err := tx.Model(&model.ProductBarcode{}).Where("code = ?", code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrBarcodeTaken
+		}
+		return tx.Create(&productBarcode).Error
+	})
+	if err != nil {
+		pr.log.Error("Failed to add barcode", zap.Uint("productID", productID), zap.String("code", code), zap.Error(err))
+		return nil, err
+	}
+	return &productBarcode, nil
+}
+
+// RemoveBarcode takes a code off a product.
+func (pr *productRepo) RemoveBarcode(productID uint, code string) error {
+	result := pr.db.Where("product_id = ? AND code = ?", productID, code).Delete(&model.ProductBarcode{})
+	if result.Error != nil {
+		pr.log.Error("Failed to remove barcode", zap.Uint("productID", productID), zap.String("code", code), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUnknownBarcode
+	}
+	return nil
+}
+
+// LabelProducts returns the live products to print labels for, with their
+// barcodes: the ones asked for, or every product without a barcode when
+// none are. Those without a barcode are given an in-store code first, so
+// the labels printed for them scan.
+func (pr *productRepo) LabelProducts(productIDs []uint) ([]model.Product, error) {
+	products := []model.Product{}
+	err := pr.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Preload("Barcodes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Where("deleted_at IS NULL")
+		if len(productIDs) > 0 {
+			query = query.Where("id IN ?", productIDs)
+		} else {
+			query = query.Where("NOT EXISTS (SELECT 1 FROM product_barcodes WHERE product_barcodes.product_id = products.id)")
+		}
+		if err := query.Order("name").Order("id").Find(&products).Error; err != nil {
+			return err
+		}
+		if len(productIDs) > 0 && len(products) != len(productIDs) {
+			return ErrProductNotFound
+		}
+
+		for i, product := range products {
+			if len(product.Barcodes) > 0 {
+				continue
+			}
+			code := model.ProductBarcode{ProductID: product.ID, Code: barcode.InStore(product.ID)}
+			if err := tx.Create(&code).Error; err != nil {
+				return err
+			}
+			products[i].Barcodes = []model.ProductBarcode{code}
+		}
+		return nil
+	})
+	if err != nil {
+		pr.log.Error("Failed to prepare barcode labels", zap.Uints("productIDs", productIDs), zap.Error(err))
+		return nil, err
+	}
+	return products, nil
+}
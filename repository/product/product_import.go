@@ -0,0 +1,321 @@
+package productrepository
+
+import (
+	"errors"
+	"fmt"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errDryRun = errors.New("dry run")
+
+// ImportProducts creates or updates a product for each row, matched by id
+// and then by item_id, in one transaction. A qty different from the
+// product's is posted to the stock ledger. Any row error, or a dry run,
+// rolls all of it back, so the result tells what the import would do.
+func (pr *productRepo) ImportProducts(rows []model.ProductImportRow, dryRun bool, actorID uint) (*model.ProductImportResult, error) {
+	result := &model.ProductImportResult{DryRun: dryRun, Rows: len(rows), Errors: []model.ProductImportError{}}
+
+	err := pr.db.Transaction(func(tx *gorm.DB) error {
+		var categories []model.Category
+		if err := tx.Find(&categories).Error; err != nil {
+			return err
+		}
+		// An export names deleted categories too, so category_id takes any
+		// of them; names and paths only match the live ones.
+		paths := categoryPaths(categories)
+		allCategories := map[uint]bool{}
+		categoryIDs := map[string][]uint{}
+		for _, category := range categories {
+			allCategories[category.ID] = true
+			if category.DeletedAt != nil {
+				continue
+			}
+			path := strings.ToLower(paths[category.ID])
+			categoryIDs[path] = append(categoryIDs[path], category.ID)
+			if name := strings.ToLower(strings.TrimSpace(category.Name)); name != path {
+				categoryIDs[name] = append(categoryIDs[name], category.ID)
+			}
+		}
+
+		var supplierIDs []uint
+		if err := tx.Model(&model.Supplier{}).Pluck("id", &supplierIDs).Error; err != nil {
+			return err
+		}
+		suppliers := map[uint]bool{}
+		for _, id := range supplierIDs {
+			suppliers[id] = true
+		}
+
+		for _, row := range rows {
+			rowErrors := []model.ProductImportError{}
+			fail := func(column, message string) {
+				rowErrors = append(rowErrors, model.ProductImportError{Row: row.Row, Column: column, Message: message})
+			}
+
+			if row.Given["category_id"] {
+				if !allCategories[row.Product.CategoryID] {
+					fail("category_id", fmt.Sprintf("no category with id %d", row.Product.CategoryID))
+				}
+			} else if row.Given["category"] {
+				switch ids := categoryIDs[strings.ToLower(row.Category)]; len(ids) {
+				case 0:
+					fail("category", fmt.Sprintf("no category named %q", row.Category))
+				case 1:
+					row.Product.CategoryID = ids[0]
+				default:
+					fail("category", fmt.Sprintf("more than one category is named %q", row.Category))
+				}
+			}
+			if row.Given["supplier_id"] && row.Product.SupplierID != 0 && !suppliers[row.Product.SupplierID] {
+				fail("supplier_id", fmt.Sprintf("no supplier with id %d", row.Product.SupplierID))
+			}
+
+			existing, found, err := findImported(tx, row)
+			if err != nil {
+				return err
+			}
+			if row.Given["id"] && !found {
+				fail("id", fmt.Sprintf("no product with id %d", row.Product.ID))
+			}
+
+			if !found && !row.Given["id"] {
+				for _, column := range []string{"name", "category", "price"} {
+					if !row.Given[column] && !(column == "category" && row.Given["category_id"]) {
+						fail(column, column+" is required for a new product")
+					}
+				}
+			}
+			if found && row.Given["item_id"] {
+				if err := itemIDFree(tx, row.Product.ItemID, existing.ID); errors.Is(err, ErrItemIDTaken) {
+					fail("item_id", fmt.Sprintf("item_id %q is already in use", row.Product.ItemID))
+				} else if err != nil {
+					return err
+				}
+			}
+			if row.Given["barcodes"] {
+				var taken []string
+				if err := tx.Model(&model.ProductBarcode{}).Where("code IN ? AND product_id <> ?", row.Barcodes, existing.ID).Pluck("code", &taken).Error; err != nil {
+					return err
+				}
+				for _, code := range taken {
+					fail("barcodes", fmt.Sprintf("barcode %s is on another product", code))
+				}
+			}
+			if len(rowErrors) > 0 {
+				result.Errors = append(result.Errors, rowErrors...)
+				continue
+			}
+
+			if !found {
+				if err := createImported(tx, row, actorID); err != nil {
+					return err
+				}
+				result.Created++
+				continue
+			}
+
+			changed, err := updateImported(tx, existing, row, actorID)
+			if errors.Is(err, errRecipeStock) {
+				result.Errors = append(result.Errors, model.ProductImportError{Row: row.Row, Column: "qty", Message: "stock of a product made from a recipe comes from its ingredients"})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if changed {
+				result.Updated++
+			} else {
+				result.Unchanged++
+			}
+		}
+
+		if dryRun || len(result.Errors) > 0 {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		pr.log.Error("Failed to import products", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return result, nil
+}
+
+// findImported locks the live product a row is for: the one with its id
+// when it has one, or else the one with its item_id.
+func findImported(tx *gorm.DB, row model.ProductImportRow) (model.Product, bool, error) {
+	var existing model.Product
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Barcodes")
+	switch {
+	case row.Given["id"]:
+		query = query.Where("id = ? AND deleted_at IS NULL", row.Product.ID)
+	case row.Product.ItemID != "":
+		query = query.Where("item_id = ? AND deleted_at IS NULL", row.Product.ItemID)
+	default:
+		return existing, false, nil
+	}
+	err := query.Order("id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return existing, false, nil
+	}
+	return existing, err == nil, err
+}
+
+// categoryPaths names each category by its path from the top level, such
+// as "Drinks / Coffee".
+func categoryPaths(categories []model.Category) map[uint]string {
+	byID := map[uint]model.Category{}
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	paths := map[uint]string{}
+	for _, category := range categories {
+		names := []string{strings.TrimSpace(category.Name)}
+		parent := category.ParentID
+		// A parent chain longer than the categories has a cycle.
+		for depth := 0; parent != nil && depth < len(categories); depth++ {
+			next, ok := byID[*parent]
+			if !ok {
+				break
+			}
+			names = append([]string{strings.TrimSpace(next.Name)}, names...)
+			parent = next.ParentID
+		}
+		paths[category.ID] = strings.Join(names, " / ")
+	}
+	return paths
+}
+
+var errRecipeStock = errors.New("recipe stock")
+
+func createImported(tx *gorm.DB, row model.ProductImportRow, actorID uint) error {
+	product := row.Product
+	if !row.Given["status"] {
+		product.Status = "available"
+	}
+	opening := product.Qty
+	product.Qty = 0
+	if err := tx.Create(&product).Error; err != nil {
+		return err
+	}
+	if _, err := setBarcodes(tx, product.ID, nil, row); err != nil {
+		return err
+	}
+	if opening > 0 {
+		restock := model.StockMovement{ProductID: product.ID, Type: model.MovementRestock, Qty: opening, ActorID: actorID, Reason: "Product import"}
+		return stockrepository.Post(tx, &restock)
+	}
+	return nil
+}
+
+// updateImported applies the given columns that differ from the product,
+// reporting whether anything changed.
+func updateImported(tx *gorm.DB, existing model.Product, row model.ProductImportRow, actorID uint) (bool, error) {
+	imported := row.Product
+	updates := map[string]interface{}{}
+	set := func(column string, current, value interface{}) {
+		if row.Given[column] && current != value {
+			updates[column] = value
+		}
+	}
+	set("item_id", existing.ItemID, imported.ItemID)
+	set("name", existing.Name, imported.Name)
+	set("price", existing.Price, imported.Price)
+	set("cost_price", existing.CostPrice, imported.CostPrice)
+	set("status", existing.Status, imported.Status)
+	set("supplier_id", existing.SupplierID, imported.SupplierID)
+	set("reorder_qty", existing.ReorderQty, imported.ReorderQty)
+	set("reorder_point", existing.ReorderPoint, imported.ReorderPoint)
+	set("image_url", existing.ImageURL, imported.ImageURL)
+	if (row.Given["category_id"] || row.Given["category"]) && existing.CategoryID != imported.CategoryID {
+		updates["category_id"] = imported.CategoryID
+	}
+
+	moved := row.Given["qty"] && existing.Qty != imported.Qty
+	if moved {
+		var recipeItems int64
+		if err := tx.Model(&model.RecipeItem{}).Where("product_id = ?", existing.ID).Count(&recipeItems).Error; err != nil {
+			return false, err
+		}
+		if recipeItems > 0 {
+			return false, errRecipeStock
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&model.Product{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+			return false, err
+		}
+	}
+	recoded, err := setBarcodes(tx, existing.ID, existing.Barcodes, row)
+	if err != nil {
+		return false, err
+	}
+	if moved {
+		adjustment := model.StockMovement{ProductID: existing.ID, Type: model.MovementAdjustment, Qty: imported.Qty - existing.Qty, ActorID: actorID, Reason: "Product import"}
+		if err := stockrepository.Post(tx, &adjustment); err != nil {
+			return false, err
+		}
+	}
+	return len(updates) > 0 || moved || recoded, nil
+}
+
+// setBarcodes gives a product exactly the barcodes of a row that has them,
+// reporting whether that changed any.
+func setBarcodes(tx *gorm.DB, productID uint, current []model.ProductBarcode, row model.ProductImportRow) (bool, error) {
+	if !row.Given["barcodes"] {
+		return false, nil
+	}
+	keep := map[string]bool{}
+	for _, code := range row.Barcodes {
+		keep[code] = true
+	}
+	had := map[string]bool{}
+	var removed []uint
+	for _, productBarcode := range current {
+		had[productBarcode.Code] = true
+		if !keep[productBarcode.Code] {
+			removed = append(removed, productBarcode.ID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Where("id IN ?", removed).Delete(&model.ProductBarcode{}).Error; err != nil {
+			return false, err
+		}
+	}
+	added := []model.ProductBarcode{}
+	for _, code := range row.Barcodes {
+		if !had[code] {
+			added = append(added, model.ProductBarcode{ProductID: productID, Code: code})
+			had[code] = true
+		}
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return false, err
+		}
+	}
+	return len(removed) > 0 || len(added) > 0, nil
+}
+
+// ExportProducts returns every product, by item_id, with its barcodes and
+// the paths of their categories.
+func (pr *productRepo) ExportProducts() ([]model.Product, map[uint]string, error) {
+	var products []model.Product
+	if err := pr.db.Preload("Barcodes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Where("deleted_at IS NULL").Order("item_id").Order("id").Find(&products).Error; err != nil {
+		pr.log.Error("Failed to find products to export", zap.Error(err))
+		return nil, nil, errors.New(" Internal Server Error")
+	}
+	var categories []model.Category
+	if err := pr.db.Find(&categories).Error; err != nil {
+		pr.log.Error("Failed to find categories to export", zap.Error(err))
+		return nil, nil, errors.New(" Internal Server Error")
+	}
+	return products, categoryPaths(categories), nil
+}
@@ -1,24 +1,45 @@
 package productrepository
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepo defines the interface for product operations.
 type ProductRepo interface {
-	ShowAllProducts(page, limit int) (*[]model.Product, int, int, error)
+	ShowAllProducts(filter model.ProductFilter) (*[]model.Product, int, int, error)
 	GetProductByID(id uint) (*model.Product, error)
-	CreateProduct(product *model.Product) error
+	CreateProduct(product *model.Product, actorID uint) error
 	UpdateProduct(productID uint, product *model.Product) error
 	DeleteProduct(id uint) error
+	TrashProducts(page, limit int) ([]model.Product, int, int, error)
+	RestoreProduct(id uint) error
+	PurgeProducts(deletedBefore time.Time) ([]uint, error)
+	ImportProducts(rows []model.ProductImportRow, dryRun bool, actorID uint) (*model.ProductImportResult, error)
+	ExportProducts() ([]model.Product, map[uint]string, error)
+	FindByBarcode(code string) (*model.Product, error)
+	AddBarcode(productID uint, code string) (*model.ProductBarcode, error)
+	RemoveBarcode(productID uint, code string) error
+	LabelProducts(productIDs []uint) ([]model.Product, error)
 }
 
+var (
+	ErrProductNotFound = errors.New(" Product Not Found")
+	ErrItemIDTaken     = errors.New(" Item ID Already In Use")
+	ErrUnknownBarcode  = errors.New(" Unknown Barcode")
+	ErrBarcodeTaken    = errors.New(" Barcode Already In Use")
+)
+
 // productRepo implements the ProductRepo interface.
 type productRepo struct {
 	db  *gorm.DB
@@ -30,38 +51,96 @@ func NewProductRepo(db *gorm.DB, log *zap.Logger) ProductRepo {
 	return &productRepo{db, log}
 }
 
-// ShowAllProducts fetches all products with pagination.
-func (pr *productRepo) ShowAllProducts(page, limit int) (*[]model.Product, int, int, error) {
-	pr.log.Info("Fetching all products", zap.Int("page", page), zap.Int("limit", limit))
+// ShowAllProducts fetches the products matching filter, paginated.
+func (pr *productRepo) ShowAllProducts(filter model.ProductFilter) (*[]model.Product, int, int, error) {
+	pr.log.Info("Fetching all products", zap.Int("page", filter.Page), zap.Int("limit", filter.Limit))
 
 	var products []model.Product
 	var totalRecords int64
 
+	query := pr.filterProducts(filter)
+
 	// Count total records
-	if err := pr.db.Model(&model.Product{}).Count(&totalRecords).Error; err != nil {
+	if err := query.Session(&gorm.Session{}).Count(&totalRecords).Error; err != nil {
 		pr.log.Error("Error counting products", zap.Error(err))
 		return nil, 0, 0, err
 	}
 
 	// Fetch paginated results
-	offset := (page - 1) * limit
-	if err := pr.db.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+	offset := (filter.Page - 1) * filter.Limit
+	if err := sortProducts(query, filter).Offset(offset).Limit(filter.Limit).Find(&products).Error; err != nil {
 		pr.log.Error("Error fetching products", zap.Error(err))
 		return nil, 0, 0, err
 	}
 
-	totalPages := int(math.Ceil(float64(totalRecords) / float64(limit)))
+	totalPages := int(math.Ceil(float64(totalRecords) / float64(filter.Limit)))
 
 	pr.log.Info("Successfully fetched products", zap.Int("totalRecords", int(totalRecords)), zap.Int("totalPages", totalPages))
 	return &products, int(totalRecords), totalPages, nil
 }
 
+// filterProducts applies filter's conditions. Search is a full-text match
+// on name and item_id, or a substring match for partial words and codes;
+// both are backed by GIN indexes.
+func (pr *productRepo) filterProducts(filter model.ProductFilter) *gorm.DB {
+	query := pr.db.Model(&model.Product{}).Where("products.deleted_at IS NULL")
+	if filter.Search != "" {
+		like := "%" + filter.Search + "%"
+		query = query.Where(`to_tsvector('simple', name || ' ' || item_id) @@ plainto_tsquery('simple', ?) OR name ILIKE ? OR item_id ILIKE ?`,
+			filter.Search, like, like)
+	}
+	if filter.CategoryID != 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if filter.Status != "" {
+		query = query.Where("LOWER(status) = LOWER(?)", filter.Status)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+
+	lowStock := filter.LowStock
+	switch filter.Stock {
+	case model.StockOut:
+		query = query.Where("qty <= 0")
+	case model.StockLimited:
+		query = query.Where("qty > 0 AND qty < COALESCE(NULLIF(reorder_point, 0), ?)", lowStock)
+	case model.StockIn:
+		query = query.Where("qty >= COALESCE(NULLIF(reorder_point, 0), ?)", lowStock)
+	}
+	return query
+}
+
+// sortProducts orders the listing. Popularity is the quantity ever
+// ordered; a search without a sort puts the closest names first.
+func sortProducts(query *gorm.DB, filter model.ProductFilter) *gorm.DB {
+	direction := "ASC"
+	if strings.EqualFold(filter.Order, "desc") {
+		direction = "DESC"
+	}
+	switch filter.Sort {
+	case "name", "price", "created_at":
+		query = query.Order("products." + filter.Sort + " " + direction)
+	case "popularity":
+		query = query.Joins("LEFT JOIN (SELECT product_id, SUM(qty) AS sold FROM order_products GROUP BY product_id) popularity ON popularity.product_id = products.id").
+			Order("COALESCE(popularity.sold, 0) " + direction)
+	default:
+		if filter.Search != "" {
+			query = query.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "similarity(products.name, ?) DESC", Vars: []interface{}{filter.Search}}})
+		}
+	}
+	return query.Order("products.id")
+}
+
 // GetProductByID fetches a product by its ID.
 func (pr *productRepo) GetProductByID(id uint) (*model.Product, error) {
 	pr.log.Info("Fetching product by ID", zap.Uint("id", id))
 
 	var product model.Product
-	if err := pr.db.First(&product, id).Error; err != nil {
+	if err := pr.db.Preload("Barcodes").Where("deleted_at IS NULL").First(&product, id).Error; err != nil {
 		pr.log.Error("Error fetching product", zap.Uint("id", id), zap.Error(err))
 		return nil, fmt.Errorf("product not found")
 	}
@@ -70,18 +149,31 @@ func (pr *productRepo) GetProductByID(id uint) (*model.Product, error) {
 	return &product, nil
 }
 
-// CreateProduct creates a new product.
-func (pr *productRepo) CreateProduct(product *model.Product) error {
+// CreateProduct creates a new product. Its opening qty is posted to the
+// stock ledger as a restock.
+func (pr *productRepo) CreateProduct(product *model.Product, actorID uint) error {
 	pr.log.Info("Creating product", zap.String("name", product.Name))
 
 	var wg sync.WaitGroup
 	var err error
 
 	err = pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := itemIDFree(tx, product.ItemID, 0); err != nil {
+			return err
+		}
+		opening := product.Qty
+		product.Qty = 0
 		if err := tx.Create(product).Error; err != nil {
 			pr.log.Error("Failed to create product", zap.String("name", product.Name), zap.Error(err))
 			return fmt.Errorf("failed to create product: %w", err)
 		}
+		if opening > 0 {
+			restock := model.StockMovement{ProductID: product.ID, Type: model.MovementRestock, Qty: opening, ActorID: actorID, Reason: "Opening stock"}
+			if err := stockrepository.Post(tx, &restock); err != nil {
+				return fmt.Errorf("failed to post opening stock: %w", err)
+			}
+			product.Qty = restock.Balance
+		}
 		wg.Wait()
 		return nil
 	})
@@ -95,11 +187,17 @@ func (pr *productRepo) CreateProduct(product *model.Product) error {
 	return nil
 }
 
-// UpdateProduct updates an existing product by its ID.
+// UpdateProduct updates an existing product by its ID. Qty is left alone;
+// stock only changes through the ledger.
 func (pr *productRepo) UpdateProduct(productID uint, product *model.Product) error {
 	pr.log.Info("Updating product", zap.Uint("productID", productID))
 
-	result := pr.db.Model(&model.Product{}).Where("id = ?", productID).Updates(product)
+	if err := itemIDFree(pr.db, product.ItemID, productID); err != nil {
+		pr.log.Warn("Item ID already in use", zap.String("itemID", product.ItemID))
+		return err
+	}
+
+	result := pr.db.Model(&model.Product{}).Where("id = ? AND deleted_at IS NULL", productID).Omit("qty").Updates(product)
 	if result.Error != nil {
 		pr.log.Error("Failed to update product", zap.Uint("productID", productID), zap.Error(result.Error))
 		return result.Error
@@ -114,11 +212,12 @@ func (pr *productRepo) UpdateProduct(productID uint, product *model.Product) err
 	return nil
 }
 
-// DeleteProduct deletes a product by its ID.
+// DeleteProduct soft deletes a product. It can no longer be ordered but
+// stays in the orders and reports it is already part of.
 func (pr *productRepo) DeleteProduct(id uint) error {
 	pr.log.Info("Deleting product", zap.Uint("id", id))
 
-	result := pr.db.Delete(&model.Product{}, id)
+	result := pr.db.Model(&model.Product{}).Where("id = ? AND deleted_at IS NULL", id).Update("deleted_at", time.Now())
 	if result.Error != nil {
 		pr.log.Error("Failed to delete product", zap.Uint("id", id), zap.Error(result.Error))
 		return result.Error
@@ -126,9 +225,117 @@ func (pr *productRepo) DeleteProduct(id uint) error {
 
 	if result.RowsAffected == 0 {
 		pr.log.Warn("No product found to delete", zap.Uint("id", id))
-		return fmt.Errorf("product not found with id %d", id)
+		return ErrProductNotFound
 	}
 
 	pr.log.Info("Successfully deleted product", zap.Uint("id", id))
 	return nil
 }
+
+// itemIDFree checks that no live product other than exceptID has itemID,
+// the product's SKU. Products without one are not checked.
+func itemIDFree(tx *gorm.DB, itemID string, exceptID uint) error {
+	if itemID == "" {
+		return nil
+	}
+	var taken int64
+	if err := tx.Model(&model.Product{}).Where("item_id = ? AND deleted_at IS NULL AND id <> ?", itemID, exceptID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrItemIDTaken
+	}
+	return nil
+}
+
+// TrashProducts lists deleted products, most recently deleted first.
+func (pr *productRepo) TrashProducts(page, limit int) ([]model.Product, int, int, error) {
+	query := pr.db.Model(&model.Product{}).Where("deleted_at IS NOT NULL")
+
+	var totalRecords int64
+	if err := query.Session(&gorm.Session{}).Count(&totalRecords).Error; err != nil {
+		pr.log.Error("Error counting deleted products", zap.Error(err))
+		return nil, 0, 0, err
+	}
+
+	products := []model.Product{}
+	if err := query.Order("deleted_at DESC").Order("id").Offset((page - 1) * limit).Limit(limit).Find(&products).Error; err != nil {
+		pr.log.Error("Error fetching deleted products", zap.Error(err))
+		return nil, 0, 0, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalRecords) / float64(limit)))
+	return products, int(totalRecords), totalPages, nil
+}
+
+// RestoreProduct brings a deleted product back, unless another product
+// has taken its item_id meanwhile.
+func (pr *productRepo) RestoreProduct(id uint) error {
+	err := pr.db.Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("deleted_at IS NOT NULL").First(&product, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		if err := itemIDFree(tx, product.ItemID, id); err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Product{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		pr.log.Error("Failed to restore product", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+
+	pr.log.Info("Successfully restored product", zap.Uint("id", id))
+	return nil
+}
+
+// PurgeProducts permanently removes products deleted before deletedBefore
+// that were never sold, bought or counted, along with their stock history,
+// alerts, recipes, barcodes, bundle items and menu places. Products with
+// sales, purchases, stocktakes or ingredient movements, bundles included,
+// stay deleted for the orders, stocktakes and reports that show them, and
+// so do components of a bundle, which would otherwise sell without them.
+func (pr *productRepo) PurgeProducts(deletedBefore time.Time) ([]uint, error) {
+	var ids []uint
+	err := pr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Product{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Where("NOT EXISTS (SELECT 1 FROM order_products WHERE order_products.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM order_bundle_items WHERE order_bundle_items.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM purchase_order_lines WHERE purchase_order_lines.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM stocktake_lines WHERE stocktake_lines.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM stocktake_counts WHERE stocktake_counts.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM ingredient_movements WHERE ingredient_movements.product_id = products.id)").
+			Where("NOT EXISTS (SELECT 1 FROM bundle_items WHERE bundle_items.product_id = products.id)").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, related := range []interface{}{&model.StockMovement{}, &model.StockAlert{}, &model.RecipeItem{}, &model.MenuProduct{}, &model.ProductBarcode{}} {
+			if err := tx.Where("product_id IN ?", ids).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("bundle_id IN ?", ids).Delete(&model.BundleItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.Product{}).Error
+	})
+	if err != nil {
+		pr.log.Error("Failed to purge deleted products", zap.Error(err))
+		return nil, err
+	}
+
+	pr.log.Info("Purged deleted products", zap.Uints("ids", ids))
+	return ids, nil
+}
@@ -0,0 +1,277 @@
+package productrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	productrepository "project_pos_app/repository/product"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestShowAllProducts(t *testing.T) {
+	t.Run("Search, filters and sort are applied", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+		minPrice := 20.0
+		filter := model.ProductFilter{Search: "latte", CategoryID: 2, MinPrice: &minPrice, Stock: model.StockLimited, LowStock: 10, Sort: "price", Order: "desc", Page: 2, Limit: 5}
+
+		where := `WHERE products.deleted_at IS NULL AND (to_tsvector('simple', name || ' ' || item_id) @@ plainto_tsquery('simple', $1) OR name ILIKE $2 OR item_id ILIKE $3) AND category_id = $4 AND price >= $5 AND (qty > 0 AND qty < COALESCE(NULLIF(reorder_point, 0), $6))`
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" `+where)).
+			WithArgs("latte", "%latte%", "%latte%", 2, 20.0, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" `+where+` ORDER BY products.price DESC,products.id LIMIT $7 OFFSET $8`)).
+			WithArgs("latte", "%latte%", "%latte%", 2, 20.0, 10, 5, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "qty", "price"}).AddRow(7, "Iced Latte", 4, 25.0))
+
+		products, total, totalPages, err := repo.ShowAllProducts(filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, total)
+		assert.Equal(t, 2, totalPages)
+		assert.Len(t, *products, 1)
+		assert.Equal(t, 4, (*products)[0].Qty)
+	})
+
+	t.Run("Popularity sorts by quantity ordered", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`LEFT JOIN (SELECT product_id, SUM(qty) AS sold FROM order_products GROUP BY product_id) popularity ON popularity.product_id = products.id WHERE products.deleted_at IS NULL ORDER BY COALESCE(popularity.sold, 0) DESC,products.id LIMIT $1`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "Espresso"))
+
+		products, _, _, err := repo.ShowAllProducts(model.ProductFilter{Sort: "popularity", Order: "desc", Page: 1, Limit: 10})
+
+		assert.NoError(t, err)
+		assert.Len(t, *products, 1)
+	})
+}
+
+func TestImportProducts(t *testing.T) {
+	categoryQuery := `SELECT * FROM "categories"`
+	supplierQuery := `SELECT "id" FROM "suppliers"`
+	productQuery := `SELECT * FROM "products" WHERE item_id = $1 AND deleted_at IS NULL ORDER BY id,"products"."id" LIMIT $2 FOR UPDATE`
+	barcodeQuery := `SELECT * FROM "product_barcodes" WHERE "product_barcodes"."product_id" = $1`
+	given := map[string]bool{"item_id": true, "name": true, "category": true, "price": true, "qty": true, "status": true}
+
+	t.Run("A dry run reports what would change and writes nothing", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+		rows := []model.ProductImportRow{
+			{Row: 2, Category: "Coffee", Given: given, Product: model.Product{ItemID: "P001", Name: "Latte", Price: 4.5, Qty: 12, Status: "available"}},
+			{Row: 3, Category: "coffee", Given: given, Product: model.Product{ItemID: "P999", Name: "Mocha", Price: 5, Status: "available"}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Coffee"))
+		mock.ExpectQuery(regexp.QuoteMeta(supplierQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs("P001", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "name", "category_id", "price", "qty", "status"}).
+				AddRow(5, "P001", "Latte", 1, 4.5, 12, "available"))
+		mock.ExpectQuery(regexp.QuoteMeta(barcodeQuery)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE item_id = $1 AND deleted_at IS NULL AND id <> $2`)).
+			WithArgs("P001", 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs("P999", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "products"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectRollback()
+
+		result, err := repo.ImportProducts(rows, true, 2)
+
+		assert.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.Empty(t, result.Errors)
+	})
+
+	t.Run("A row error rolls the whole import back", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+		rows := []model.ProductImportRow{
+			{Row: 2, Category: "Coffee", Given: given, Product: model.Product{ItemID: "P001", Name: "Flat White", Price: 4.5, Qty: 12, Status: "available"}},
+			{Row: 3, Category: "Tea", Given: given, Product: model.Product{ItemID: "P998", Name: "Chai", Price: 3}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Coffee"))
+		mock.ExpectQuery(regexp.QuoteMeta(supplierQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs("P001", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "name", "category_id", "price", "qty", "status"}).
+				AddRow(5, "P001", "Latte", 1, 4.5, 12, "available"))
+		mock.ExpectQuery(regexp.QuoteMeta(barcodeQuery)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE item_id = $1 AND deleted_at IS NULL AND id <> $2`)).
+			WithArgs("P001", 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "name"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs("Flat White", sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs("P998", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		result, err := repo.ImportProducts(rows, false, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Updated)
+		assert.Equal(t, []model.ProductImportError{{Row: 3, Column: "category", Message: `no category named "Tea"`}}, result.Errors)
+	})
+
+	t.Run("An exported row matches by id and keeps its category and barcodes", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+		exported := map[string]bool{"id": true, "name": true, "category_id": true, "category": true, "price": true, "barcodes": true}
+		rows := []model.ProductImportRow{
+			{Row: 2, Category: "Drinks / Coffee", Barcodes: []string{"4006381333931", "2000000000053"}, Given: exported,
+				Product: model.Product{ID: 5, Name: "Latte", CategoryID: 3, Price: 4.5}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(categoryQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "name", "deleted_at"}).
+				AddRow(1, nil, "Drinks", nil).
+				AddRow(2, 1, "Coffee", nil).
+				AddRow(3, 1, "Coffee", time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta(supplierQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 AND deleted_at IS NULL ORDER BY id,"products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_id", "price"}).
+				AddRow(5, "Latte", 2, 4.5))
+		mock.ExpectQuery(regexp.QuoteMeta(barcodeQuery)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "code"}).
+				AddRow(7, 5, "4006381333931").
+				AddRow(8, 5, "2000000000046"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "code" FROM "product_barcodes" WHERE code IN ($1,$2) AND product_id <> $3`)).
+			WithArgs("4006381333931", "2000000000053", 5).
+			WillReturnRows(sqlmock.NewRows([]string{"code"}))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "category_id"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(3, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "product_barcodes" WHERE id IN ($1)`)).
+			WithArgs(8).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "product_barcodes" ("product_id","code","created_at") VALUES ($1,$2,$3) RETURNING "id"`)).
+			WithArgs(5, "2000000000053", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		result, err := repo.ImportProducts(rows, false, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Updated)
+		assert.Empty(t, result.Errors)
+	})
+}
+
+func TestRestoreProduct(t *testing.T) {
+	productQuery := `SELECT * FROM "products" WHERE deleted_at IS NOT NULL AND "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`
+	takenQuery := `SELECT count(*) FROM "products" WHERE item_id = $1 AND deleted_at IS NULL AND id <> $2`
+
+	t.Run("A product whose item_id was reused stays deleted", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "name"}).AddRow(7, "P001", "Latte"))
+		mock.ExpectQuery(regexp.QuoteMeta(takenQuery)).
+			WithArgs("P001", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := repo.RestoreProduct(7)
+
+		assert.Equal(t, productrepository.ErrItemIDTaken, err)
+	})
+
+	t.Run("A deleted product is restored", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := productrepository.NewProductRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "name"}).AddRow(7, "P001", "Latte"))
+		mock.ExpectQuery(regexp.QuoteMeta(takenQuery)).
+			WithArgs("P001", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(nil, sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.RestoreProduct(7))
+	})
+}
+
+func TestLabelProducts(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	repo := productrepository.NewProductRepo(db, zap.NewNop())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE deleted_at IS NULL AND id IN ($1,$2) ORDER BY name,id`)).
+		WithArgs(3, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(42, "Brownie").AddRow(3, "Cola"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "product_barcodes" WHERE "product_barcodes"."product_id" IN ($1,$2) ORDER BY id`)).
+		WithArgs(42, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "code"}).AddRow(1, 3, "5449000000996"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "product_barcodes" ("product_id","code","created_at") VALUES ($1,$2,$3) RETURNING "id"`)).
+		WithArgs(42, "2000000000428", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	products, err := repo.LabelProducts([]uint{3, 42})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2000000000428", products[0].Barcodes[0].Code)
+	assert.Equal(t, "5449000000996", products[1].Barcodes[0].Code)
+}
+
+func TestPurgeProducts(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	repo := productrepository.NewProductRepo(db, zap.NewNop())
+	before := time.Date(2026, 9, 19, 0, 0, 0, 0, time.Local)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "products" WHERE (deleted_at IS NOT NULL AND deleted_at < $1) AND NOT EXISTS (SELECT 1 FROM order_products WHERE order_products.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM order_bundle_items WHERE order_bundle_items.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM purchase_order_lines WHERE purchase_order_lines.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM stocktake_lines WHERE stocktake_lines.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM stocktake_counts WHERE stocktake_counts.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM ingredient_movements WHERE ingredient_movements.product_id = products.id) AND NOT EXISTS (SELECT 1 FROM bundle_items WHERE bundle_items.product_id = products.id) FOR UPDATE`)).
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	ids, err := repo.PurgeProducts(before)
+
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,354 @@
+package purchaserepository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	supplierrepository "project_pos_app/repository/supplier_repository"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryPurchase interface {
+	FindOrders(filter model.PurchaseFilter) ([]model.PurchaseOrder, int, int, error)
+	FindOrder(id uint) (*model.PurchaseOrder, error)
+	CreateOrder(order *model.PurchaseOrder) error
+	UpdateOrder(order *model.PurchaseOrder) error
+	Transition(id uint, from []string, to string) (*model.PurchaseOrder, error)
+	Receive(id uint, form model.FormGoodsReceipt, receivedBy uint) (*model.PurchaseOrder, error)
+	DraftLowStock(threshold int) ([]model.PurchaseOrder, error)
+}
+
+var (
+	ErrOrderNotFound      = errors.New(" Purchase Order Not Found")
+	ErrLineNotFound       = errors.New(" Purchase Order Line Not Found")
+	ErrInvalidStatus      = errors.New(" Invalid Purchase Order Status")
+	ErrExceedsOutstanding = errors.New(" Received Qty Exceeds Outstanding Qty")
+)
+
+type repositoryPurchase struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewPurchaseRepository(db *gorm.DB, log *zap.Logger) RepositoryPurchase {
+	return &repositoryPurchase{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryPurchase) FindOrders(filter model.PurchaseFilter) ([]model.PurchaseOrder, int, int, error) {
+	query := r.DB.Model(&model.PurchaseOrder{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count purchase orders", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	orders := []model.PurchaseOrder{}
+	err := query.Preload("Supplier").Preload("Lines").
+		Order("created_at DESC").Order("id DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).
+		Find(&orders).Error
+	if err != nil {
+		r.Log.Error("Failed to find purchase orders", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return orders, int(total), int(math.Ceil(float64(total) / float64(filter.Limit))), nil
+}
+
+func (r *repositoryPurchase) FindOrder(id uint) (*model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	err := r.DB.Preload("Supplier").Preload("Lines").Preload("Receipts.Lines").First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find purchase order", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &order, nil
+}
+
+func (r *repositoryPurchase) CreateOrder(order *model.PurchaseOrder) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkReferences(tx, order); err != nil {
+			return err
+		}
+		return tx.Create(order).Error
+	})
+	return r.failure("Failed to create purchase order", err)
+}
+
+// UpdateOrder replaces the supplier, notes, expected date and lines of a
+// purchase order that is still a draft.
+func (r *repositoryPurchase) UpdateOrder(order *model.PurchaseOrder) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockOrder(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if existing.Status != model.PurchaseDraft {
+			return ErrInvalidStatus
+		}
+		if err := checkReferences(tx, order); err != nil {
+			return err
+		}
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&model.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		for i := range order.Lines {
+			order.Lines[i].PurchaseOrderID = order.ID
+		}
+		if err := tx.Create(&order.Lines).Error; err != nil {
+			return err
+		}
+		return tx.Model(existing).Updates(map[string]interface{}{
+			"supplier_id": order.SupplierID,
+			"notes":       order.Notes,
+			"expected_at": order.ExpectedAt,
+		}).Error
+	})
+	return r.failure("Failed to update purchase order", err)
+}
+
+// Transition moves a purchase order to another status if it is in one of
+// the from statuses.
+func (r *repositoryPurchase) Transition(id uint, from []string, to string) (*model.PurchaseOrder, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(from, order.Status) {
+			return ErrInvalidStatus
+		}
+		updates := map[string]interface{}{"status": to}
+		if to == model.PurchaseOrdered {
+			updates["ordered_at"] = time.Now()
+		}
+		return tx.Model(order).Updates(updates).Error
+	})
+	if err := r.failure("Failed to change purchase order status", err); err != nil {
+		return nil, err
+	}
+	return r.FindOrder(id)
+}
+
+// Receive books a delivery against an ordered purchase order. Each line
+// received is posted to the stock ledger as a restock at its unit cost and
+// moves the product's cost price to the weighted average of the stock on
+// hand and the stock received.
+func (r *repositoryPurchase) Receive(id uint, form model.FormGoodsReceipt, receivedBy uint) (*model.PurchaseOrder, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != model.PurchaseOrdered && order.Status != model.PurchasePartial {
+			return ErrInvalidStatus
+		}
+		if err := tx.Where("purchase_order_id = ?", id).Order("id").Find(&order.Lines).Error; err != nil {
+			return err
+		}
+
+		receipt := model.GoodsReceipt{PurchaseOrderID: id, Note: form.Note, ReceivedBy: receivedBy}
+		for _, received := range form.Lines {
+			index := slices.IndexFunc(order.Lines, func(line model.PurchaseOrderLine) bool {
+				return line.ID == received.LineID
+			})
+			if index < 0 {
+				return ErrLineNotFound
+			}
+			line := &order.Lines[index]
+			if received.Qty > line.Outstanding() {
+				return ErrExceedsOutstanding
+			}
+			receiptLine := model.GoodsReceiptLine{
+				PurchaseOrderLineID: line.ID,
+				ProductID:           line.ProductID,
+				Qty:                 received.Qty,
+				UnitCost:            line.UnitCost,
+			}
+			if received.UnitCost != nil {
+				receiptLine.UnitCost = *received.UnitCost
+			}
+			line.ReceivedQty += received.Qty
+			if err := tx.Model(line).Update("received_qty", line.ReceivedQty).Error; err != nil {
+				return err
+			}
+			if err := restock(tx, receiptLine, receivedBy, id); err != nil {
+				return err
+			}
+			receipt.Lines = append(receipt.Lines, receiptLine)
+		}
+		if err := tx.Create(&receipt).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"status": model.PurchasePartial}
+		if !slices.ContainsFunc(order.Lines, func(line model.PurchaseOrderLine) bool { return line.Outstanding() > 0 }) {
+			updates["status"] = model.PurchaseReceived
+			updates["received_at"] = time.Now()
+		}
+		return tx.Model(order).Omit(clause.Associations).Updates(updates).Error
+	})
+	if err := r.failure("Failed to receive purchase order", err); err != nil {
+		return nil, err
+	}
+	return r.FindOrder(id)
+}
+
+// DraftLowStock drafts purchase orders for the products below their
+// reorder point, or threshold for those without one, that have a preferred
+// supplier and are not already on an open purchase order. Products made
+// from a recipe are not bought. Products are added to the supplier's
+// pending automatic draft, or to a new one, at their reorder quantity and
+// cost price. It returns the drafts that got new lines.
+func (r *repositoryPurchase) DraftLowStock(threshold int) ([]model.PurchaseOrder, error) {
+	onOrder := r.DB.Table("purchase_order_lines AS l").
+		Select("l.product_id").
+		Joins("JOIN purchase_orders AS o ON o.id = l.purchase_order_id").
+		Where("o.status IN ? AND l.qty > l.received_qty", model.PurchaseOpen)
+
+	var products []model.Product
+	err := r.DB.Where("qty < COALESCE(NULLIF(reorder_point, 0), ?) AND supplier_id <> 0 AND deleted_at IS NULL", threshold).
+		Where("id NOT IN (?)", onOrder).
+		Where("id NOT IN (?)", r.DB.Model(&model.RecipeItem{}).Select("product_id")).
+		Order("supplier_id").Order("id").
+		Find(&products).Error
+	if err != nil {
+		r.Log.Error("Failed to find products to reorder", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	drafts := []model.PurchaseOrder{}
+	for supplierID, group := range groupBySupplier(products) {
+		draft := model.PurchaseOrder{}
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("supplier_id = ? AND status = ? AND auto = ?", supplierID, model.PurchaseDraft, true).
+				Order("id").First(&draft).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				draft = model.PurchaseOrder{SupplierID: supplierID, Status: model.PurchaseDraft, Auto: true, Notes: "Drafted for low stock"}
+				err = tx.Create(&draft).Error
+			}
+			if err != nil {
+				return err
+			}
+			for _, product := range group {
+				draft.Lines = append(draft.Lines, model.PurchaseOrderLine{
+					PurchaseOrderID: draft.ID,
+					ProductID:       product.ID,
+					Qty:             product.ReorderQuantity(threshold),
+					UnitCost:        product.CostPrice,
+				})
+			}
+			return tx.Create(&draft.Lines).Error
+		})
+		if err != nil {
+			r.Log.Error("Failed to draft purchase order", zap.Uint("supplier", supplierID), zap.Error(err))
+			continue
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, nil
+}
+
+func (r *repositoryPurchase) failure(message string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrOrderNotFound), errors.Is(err, ErrLineNotFound), errors.Is(err, ErrInvalidStatus),
+		errors.Is(err, ErrExceedsOutstanding), errors.Is(err, supplierrepository.ErrSupplierNotFound),
+		errors.Is(err, stockrepository.ErrProductNotFound):
+		return err
+	default:
+		r.Log.Error(message, zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+}
+
+func lockOrder(tx *gorm.DB, id uint) (*model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	return &order, err
+}
+
+// checkReferences makes sure the supplier and the products on the lines
+// exist.
+func checkReferences(tx *gorm.DB, order *model.PurchaseOrder) error {
+	var count int64
+	if err := tx.Model(&model.Supplier{}).Where("id = ?", order.SupplierID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return supplierrepository.ErrSupplierNotFound
+	}
+
+	productIDs := []uint{}
+	for _, line := range order.Lines {
+		if !slices.Contains(productIDs, line.ProductID) {
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	if err := tx.Model(&model.Product{}).Where("id IN ? AND deleted_at IS NULL", productIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(productIDs) {
+		return stockrepository.ErrProductNotFound
+	}
+	return nil
+}
+
+// restock posts a received line to the stock ledger and updates the
+// product's cost price.
+func restock(tx *gorm.DB, received model.GoodsReceiptLine, actorID, orderID uint) error {
+	movement := model.StockMovement{
+		ProductID: received.ProductID,
+		Type:      model.MovementRestock,
+		Qty:       received.Qty,
+		UnitCost:  received.UnitCost,
+		ActorID:   actorID,
+		Reason:    fmt.Sprintf("Goods receipt for purchase order #%d", orderID),
+	}
+	if err := stockrepository.Post(tx, &movement); err != nil {
+		return err
+	}
+
+	var product model.Product
+	if err := tx.Select("id", "cost_price").First(&product, received.ProductID).Error; err != nil {
+		return err
+	}
+	onHand := movement.Balance - received.Qty
+	cost := received.UnitCost
+	if onHand > 0 {
+		cost = (float64(onHand)*product.CostPrice + float64(received.Qty)*received.UnitCost) / float64(movement.Balance)
+	}
+	return tx.Model(&product).Update("cost_price", math.Round(cost*100)/100).Error
+}
+
+func groupBySupplier(products []model.Product) map[uint][]model.Product {
+	groups := map[uint][]model.Product{}
+	for _, product := range products {
+		groups[product.SupplierID] = append(groups[product.SupplierID], product)
+	}
+	return groups
+}
@@ -0,0 +1,125 @@
+package purchaserepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	purchaserepository "project_pos_app/repository/purchase_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestReceive(t *testing.T) {
+	orderQuery := `SELECT * FROM "purchase_orders" WHERE "purchase_orders"."id" = $1 ORDER BY "purchase_orders"."id" LIMIT $2 FOR UPDATE`
+	linesQuery := `SELECT * FROM "purchase_order_lines" WHERE purchase_order_id = $1 ORDER BY id`
+	lineColumns := []string{"id", "purchase_order_id", "product_id", "qty", "received_qty", "unit_cost"}
+
+	t.Run("Partial receipt restocks at the price paid and averages the cost price", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := purchaserepository.NewPurchaseRepository(db, zap.NewNop())
+		unitCost := 6.0
+		form := model.FormGoodsReceipt{Note: "First delivery", Lines: []model.FormGoodsReceiptLine{{LineID: 11, Qty: 10, UnitCost: &unitCost}}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(orderQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "supplier_id", "status"}).AddRow(3, 2, model.PurchaseOrdered))
+		mock.ExpectQuery(regexp.QuoteMeta(linesQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(11, 3, 7, 24, 0, 5.0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "purchase_order_lines" SET "received_qty"=$1 WHERE "id" = $2`)).
+			WithArgs(10, 11).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(7, 10))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(20, sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(7, model.MovementRestock, 10, 20, 6.0, "Goods receipt for purchase order #3", 0, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","cost_price" FROM "products" WHERE "products"."id" = $1`)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "cost_price"}).AddRow(7, 4.0))
+		// 10 on hand at 4.00 and 10 received at 6.00 average to 5.00.
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "cost_price"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(5.0, sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "goods_receipts"`)).
+			WithArgs(3, "First delivery", 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "goods_receipt_lines"`)).
+			WithArgs(1, 11, 7, 10, 6.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "purchase_orders" SET "status"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(model.PurchasePartial, sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "purchase_orders" WHERE "purchase_orders"."id" = $1`)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "supplier_id", "status"}).AddRow(3, 2, model.PurchasePartial))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "purchase_order_lines" WHERE "purchase_order_lines"."purchase_order_id" = $1`)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(11, 3, 7, 24, 10, 5.0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "goods_receipts" WHERE "goods_receipts"."purchase_order_id" = $1`)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "purchase_order_id"}).AddRow(1, 3))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "goods_receipt_lines" WHERE "goods_receipt_lines"."goods_receipt_id" = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "goods_receipt_id", "purchase_order_line_id", "product_id", "qty", "unit_cost"}).AddRow(1, 1, 11, 7, 10, 6.0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "suppliers" WHERE "suppliers"."id" = $1`)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Fresh Farm"))
+
+		order, err := repo.Receive(3, form, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PurchasePartial, order.Status)
+		assert.Equal(t, 14, order.Lines[0].Outstanding())
+	})
+
+	t.Run("Cannot receive more than is outstanding", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := purchaserepository.NewPurchaseRepository(db, zap.NewNop())
+		form := model.FormGoodsReceipt{Lines: []model.FormGoodsReceiptLine{{LineID: 11, Qty: 15}}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(orderQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "supplier_id", "status"}).AddRow(3, 2, model.PurchasePartial))
+		mock.ExpectQuery(regexp.QuoteMeta(linesQuery)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(11, 3, 7, 24, 10, 5.0))
+		mock.ExpectRollback()
+
+		_, err := repo.Receive(3, form, 0)
+
+		assert.ErrorIs(t, err, purchaserepository.ErrExceedsOutstanding)
+	})
+
+	t.Run("Drafts cannot be received", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := purchaserepository.NewPurchaseRepository(db, zap.NewNop())
+		form := model.FormGoodsReceipt{Lines: []model.FormGoodsReceiptLine{{LineID: 11, Qty: 1}}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(orderQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "supplier_id", "status"}).AddRow(3, 2, model.PurchaseDraft))
+		mock.ExpectRollback()
+
+		_, err := repo.Receive(3, form, 0)
+
+		assert.ErrorIs(t, err, purchaserepository.ErrInvalidStatus)
+	})
+}
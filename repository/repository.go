@@ -1,16 +1,29 @@
 package repository
 
 import (
+	"project_pos_app/model"
 	accessrepository "project_pos_app/repository/access_repository"
+	attendancerepository "project_pos_app/repository/attendance_repository"
 	authrepository "project_pos_app/repository/auth_repository"
+	bundlerepository "project_pos_app/repository/bundle_repository"
 	categoryrepository "project_pos_app/repository/category_repository"
+	customerrepository "project_pos_app/repository/customer_repository"
 	dashboardrepository "project_pos_app/repository/dashboard_repository"
+	drawerrepository "project_pos_app/repository/drawer_repository"
+	ingredientrepository "project_pos_app/repository/ingredient_repository"
+	loyaltyrepository "project_pos_app/repository/loyalty_repository"
+	menurepository "project_pos_app/repository/menu_repository"
 	"project_pos_app/repository/notification"
 	orderrepository "project_pos_app/repository/order_repository"
 	productrepository "project_pos_app/repository/product"
 	profilesuperadmin "project_pos_app/repository/profile_superadmin"
+	purchaserepository "project_pos_app/repository/purchase_repository"
 	reservationrepository "project_pos_app/repository/reservation_repository"
 	revenuerepository "project_pos_app/repository/revenue_repository"
+	stockrepository "project_pos_app/repository/stock_repository"
+	stocktakerepository "project_pos_app/repository/stocktake_repository"
+	supplierrepository "project_pos_app/repository/supplier_repository"
+	waitlistrepository "project_pos_app/repository/waitlist_repository"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -27,19 +40,43 @@ type AllRepository struct {
 	Superadmin  profilesuperadmin.SuperadminRepo
 	Reservation reservationrepository.RepositoryReservation
 	Dashboard   dashboardrepository.RepositoryDashboard
+	Waitlist    waitlistrepository.RepositoryWaitlist
+	Customer    customerrepository.RepositoryCustomer
+	Loyalty     loyaltyrepository.RepositoryLoyalty
+	Stock       stockrepository.RepositoryStock
+	Supplier    supplierrepository.RepositorySupplier
+	Purchase    purchaserepository.RepositoryPurchase
+	Ingredient  ingredientrepository.RepositoryIngredient
+	Stocktake   stocktakerepository.RepositoryStocktake
+	Menu        menurepository.RepositoryMenu
+	Bundle      bundlerepository.RepositoryBundle
+	Drawer      drawerrepository.RepositoryDrawer
+	Attendance  attendancerepository.RepositoryAttendance
 }
 
-func NewAllRepo(DB *gorm.DB, Log *zap.Logger) *AllRepository {
+func NewAllRepo(DB *gorm.DB, Log *zap.Logger, loyalty model.LoyaltySettings) *AllRepository {
 	return &AllRepository{
 		Auth:        authrepository.NewManagementVoucherRepo(DB, Log),
 		Notif:       notification.NewNotifRepo(DB, Log),
 		Revenue:     revenuerepository.NewRevenueRepository(DB, Log),
 		Product:     productrepository.NewProductRepo(DB, Log),
-		Order:       orderrepository.NewOrderRepo(DB, Log),
+		Order:       orderrepository.NewOrderRepo(DB, Log, loyalty),
 		Superadmin:  profilesuperadmin.NewSuperadmin(DB, Log),
 		Category:    categoryrepository.NewCategoryRepo(DB, Log),
 		Access:      accessrepository.NewAccessRepository(DB, Log),
 		Reservation: reservationrepository.NewReservationRepository(DB, Log),
 		Dashboard:   dashboardrepository.NewReservationRepository(DB, Log),
+		Waitlist:    waitlistrepository.NewWaitlistRepository(DB, Log),
+		Customer:    customerrepository.NewCustomerRepository(DB, Log),
+		Loyalty:     loyaltyrepository.NewLoyaltyRepository(DB, Log, loyalty),
+		Stock:       stockrepository.NewStockRepository(DB, Log),
+		Supplier:    supplierrepository.NewSupplierRepository(DB, Log),
+		Purchase:    purchaserepository.NewPurchaseRepository(DB, Log),
+		Ingredient:  ingredientrepository.NewIngredientRepository(DB, Log),
+		Stocktake:   stocktakerepository.NewStocktakeRepository(DB, Log),
+		Menu:        menurepository.NewMenuRepository(DB, Log),
+		Bundle:      bundlerepository.NewBundleRepository(DB, Log),
+		Drawer:      drawerrepository.NewDrawerRepository(DB, Log),
+		Attendance:  attendancerepository.NewAttendanceRepository(DB, Log),
 	}
 }
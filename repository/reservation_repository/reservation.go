@@ -2,20 +2,36 @@ package reservationrepository
 
 import (
 	"errors"
+	"math"
 	"project_pos_app/model"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RepositoryReservation interface {
 	FindReservations(date string) ([]model.Reservation, error)
 	FindReservationsByTable(date string, tableNumber uint) ([]model.Reservation, error)
+	SearchReservations(filter model.ReservationFilter) ([]model.Reservation, int, int, error)
 	FindReservation(reservation *model.Reservation) error
-	Insert(reservation *model.Reservation) error
-	Update(reservation *model.Reservation) error
+	FindTables() ([]model.Table, error)
+	Insert(reservation *model.Reservation, buffer time.Duration) error
+	Update(reservation *model.Reservation, buffer time.Duration, from string) error
+	UpdateStatus(reservation *model.Reservation, from ...string) error
+	UpdateDeposit(reservation *model.Reservation) error
+	FindPayment(id uint) (*model.Payment, error)
+	Seat(reservation *model.Reservation, order *model.Order, from ...string) error
+	FindOverdue(before time.Time) ([]model.Reservation, error)
+	FindHolding(from, to time.Time, buffer time.Duration) ([]model.Reservation, error)
 }
 
+var (
+	ErrAlreadyBooked = errors.New(" Already Booked")
+	ErrStatusChanged = errors.New(" Reservation Status Changed")
+)
+
 type repositoryReservation struct {
 	DB  *gorm.DB
 	Log *zap.Logger
@@ -46,6 +62,55 @@ func (r *repositoryReservation) FindReservationsByTable(date string, tableNumber
 	}
 	return reservations, nil
 }
+
+// SearchReservations returns the reservations matching the filter, the total
+// number of matches and the number of pages. The date range is compared on
+// reservation_date itself so its index can be used.
+func (r *repositoryReservation) SearchReservations(filter model.ReservationFilter) ([]model.Reservation, int, int, error) {
+	var reservations []model.Reservation
+	var totalRecords int64
+
+	query := r.DB.Model(&model.Reservation{})
+	if filter.StartDate != "" {
+		query = query.Where("reservation_date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("reservation_date < CAST(? AS date) + 1", filter.EndDate)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+	if filter.Search != "" {
+		search := "%" + filter.Search + "%"
+		query = query.Where("fullname ILIKE ? OR phone_number ILIKE ? OR email ILIKE ?", search, search, search)
+	}
+
+	if err := query.Count(&totalRecords).Error; err != nil {
+		r.Log.Error("Failed to count reservations", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	query = query.Order(filter.Sort + " " + filter.Order).Order("id")
+	totalPages := 1
+	if filter.Limit > 0 {
+		query = query.Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit)
+		totalPages = int(math.Ceil(float64(totalRecords) / float64(filter.Limit)))
+	}
+	if err := query.Find(&reservations).Error; err != nil {
+		r.Log.Error("Failed to search reservations", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return reservations, int(totalRecords), totalPages, nil
+}
+func (r *repositoryReservation) FindTables() ([]model.Table, error) {
+	var tables []model.Table
+	err := r.DB.Order("id").Find(&tables).Error
+	if err != nil {
+		r.Log.Error("Failed to find tables", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return tables, nil
+}
 func (r *repositoryReservation) FindReservation(reservation *model.Reservation) error {
 	err := r.DB.Where("id = ?", reservation.ID).Find(&reservation).Error
 	if err != nil {
@@ -54,19 +119,167 @@ func (r *repositoryReservation) FindReservation(reservation *model.Reservation)
 	}
 	return nil
 }
-func (r *repositoryReservation) Insert(reservation *model.Reservation) error {
-	err := r.DB.Create(&reservation).Error
+func (r *repositoryReservation) Insert(reservation *model.Reservation, buffer time.Duration) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := r.checkAvailability(tx, reservation, buffer); err != nil {
+			return err
+		}
+		if err := tx.Create(&reservation).Error; err != nil {
+			r.Log.Error("Failed to find insert reservation", zap.Error(err))
+			return errors.New(" Bad Request")
+		}
+		return nil
+	})
+}
+
+// Update saves the edited reservation, provided it is still in the from
+// status it was read in; one whose status changed meanwhile is left alone
+// with ErrStatusChanged.
+func (r *repositoryReservation) Update(reservation *model.Reservation, buffer time.Duration, from string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if reservation.HoldsTable() {
+			if err := r.checkAvailability(tx, reservation, buffer); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&reservation).Where("status = ?", from).Select("*").Updates(reservation)
+		if result.Error != nil {
+			r.Log.Error("Failed to find update reservation", zap.Error(result.Error))
+			return errors.New(" Bad Request")
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		return nil
+	})
+}
+
+// UpdateStatus saves the reservation status together with the deposit
+// settlement that goes with it, provided the reservation is still in one of
+// the from statuses. One that moved on meanwhile, such as a guest seated
+// while being marked as a no-show, is left alone with ErrStatusChanged.
+func (r *repositoryReservation) UpdateStatus(reservation *model.Reservation, from ...string) error {
+	result := r.DB.Model(&reservation).Where("status IN ?", from).
+		Select("status", "deposit_status", "deposit_settled_at").Updates(reservation)
+	if result.Error != nil {
+		r.Log.Error("Failed to update reservation status", zap.Error(result.Error))
+		return errors.New(" Internal Server Error")
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+func (r *repositoryReservation) UpdateDeposit(reservation *model.Reservation) error {
+	err := r.DB.Model(&reservation).Select("deposit_status", "deposit_payment", "deposit_paid_at").Updates(reservation).Error
 	if err != nil {
-		r.Log.Error("Failed to find insert reservation", zap.Error(err))
-		return errors.New(" Bad Request")
+		r.Log.Error("Failed to update reservation deposit", zap.Error(err))
+		return errors.New(" Internal Server Error")
 	}
 	return nil
 }
-func (r *repositoryReservation) Update(reservation *model.Reservation) error {
-	err := r.DB.Save(&reservation).Error
+func (r *repositoryReservation) FindPayment(id uint) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.DB.First(&payment, id).Error
+	if err != nil {
+		r.Log.Error("Failed to find payment method", zap.Error(err))
+		return nil, errors.New(" Payment Method Not Found")
+	}
+	return &payment, nil
+}
+
+// Seat marks the reservation as seated, occupies its table and opens the
+// order for it in a single transaction, provided the reservation is still
+// in one of the from statuses. One cancelled or marked as a no-show
+// meanwhile is left alone with ErrStatusChanged.
+func (r *repositoryReservation) Seat(reservation *model.Reservation, order *model.Order, from ...string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var table model.Table
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&table, reservation.TableNumber).Error
+		if err != nil {
+			r.Log.Error("Failed to find reservation table", zap.Error(err))
+			return errors.New(" Table Not Found")
+		}
+		if table.IsBook {
+			return errors.New(" Table Is Occupied")
+		}
+		if err := tx.Model(&table).Update("is_book", true).Error; err != nil {
+			r.Log.Error("Failed to occupy table", zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		if err := tx.Create(order).Error; err != nil {
+			r.Log.Error("Failed to open reservation order", zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		result := tx.Model(&reservation).Where("status IN ?", from).Updates(map[string]interface{}{
+			"status":         model.ReservationSeated,
+			"order_id":       order.ID,
+			"deposit_status": reservation.DepositStatus,
+		})
+		if result.Error != nil {
+			r.Log.Error("Failed to seat reservation", zap.Error(result.Error))
+			return errors.New(" Internal Server Error")
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		return nil
+	})
+}
+
+// FindOverdue returns pending and confirmed reservations that should have
+// started before the given time.
+func (r *repositoryReservation) FindOverdue(before time.Time) ([]model.Reservation, error) {
+	var reservations []model.Reservation
+	err := r.DB.Where("status IN ? AND reservation_date < ?", []string{model.ReservationPending, model.ReservationConfirmed}, before).
+		Find(&reservations).Error
+	if err != nil {
+		r.Log.Error("Failed to find overdue reservation", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return reservations, nil
+}
+
+// checkAvailability takes a per-table advisory lock for the rest of the
+// transaction, so two concurrent bookings of the same table are checked one
+// after the other, then rejects the reservation if it overlaps another one
+// on that table, widened by buffer on both sides.
+func (r *repositoryReservation) checkAvailability(tx *gorm.DB, reservation *model.Reservation, buffer time.Duration) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", reservation.TableNumber).Error; err != nil {
+		r.Log.Error("Failed to lock reservation table", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	var count int64
+	err := tx.Model(&model.Reservation{}).
+		Where("table_number = ? AND id <> ?", reservation.TableNumber, reservation.ID).
+		Where("status NOT IN ?", model.ReservationReleasedStatuses).
+		Where("reservation_date < ?", reservation.EndDate().Add(buffer)).
+		Where("reservation_date + duration * INTERVAL '1 minute' > ?", reservation.ReservationDate.Add(-buffer)).
+		Count(&count).Error
 	if err != nil {
-		r.Log.Error("Failed to find update reservation", zap.Error(err))
-		return errors.New(" Bad Request")
+		r.Log.Error("Failed to check reservation overlap", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	if count > 0 {
+		r.Log.Error("Failed to save reservation", zap.String("Error", "Already Booked"))
+		return ErrAlreadyBooked
 	}
 	return nil
 }
+
+// FindHolding returns the reservations still holding their table at some
+// point from from to to, counting the buffer kept free around each. A
+// reservation from the evening before that runs past midnight is one.
+func (r *repositoryReservation) FindHolding(from, to time.Time, buffer time.Duration) ([]model.Reservation, error) {
+	var reservations []model.Reservation
+	err := r.DB.Where("status NOT IN ?", model.ReservationReleasedStatuses).
+		Where("reservation_date < ?", to.Add(buffer)).
+		Where("reservation_date + duration * INTERVAL '1 minute' > ?", from.Add(-buffer)).
+		Order("reservation_date").
+		Find(&reservations).Error
+	if err != nil {
+		r.Log.Error("Failed to find reservations holding tables", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return reservations, nil
+}
@@ -0,0 +1,212 @@
+package reservationrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	reservationrepository "project_pos_app/repository/reservation_repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInsertReservation(t *testing.T) {
+	start := time.Date(2024, time.December, 21, 12, 5, 0, 0, time.UTC)
+	buffer := 15 * time.Minute
+
+	newReservation := func() *model.Reservation {
+		return &model.Reservation{
+			TableNumber:     1,
+			Pax:             2,
+			ReservationDate: start,
+			Duration:        60,
+			Fullname:        "John Doe",
+		}
+	}
+
+	overlapQuery := `SELECT count(*) FROM "reservations" WHERE (table_number = $1 AND id <> $2) AND status NOT IN ($3,$4,$5) AND reservation_date < $6 AND reservation_date + duration * INTERVAL '1 minute' > $7`
+
+	t.Run("Successfully insert reservation on a free table", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+		reservation := newReservation()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(overlapQuery)).
+			WithArgs(1, 0, "Completed", "No-Show", "Cancelled", start.Add(75*time.Minute), start.Add(-buffer)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reservations"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "Confirmed"))
+		mock.ExpectCommit()
+
+		err := repo.Insert(reservation, buffer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), reservation.ID)
+	})
+
+	t.Run("Reject reservation overlapping another one on the same table", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+		reservation := newReservation()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(overlapQuery)).
+			WithArgs(1, 0, "Completed", "No-Show", "Cancelled", start.Add(75*time.Minute), start.Add(-buffer)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := repo.Insert(reservation, buffer)
+
+		assert.ErrorIs(t, err, reservationrepository.ErrAlreadyBooked)
+	})
+}
+
+func TestSearchReservations(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+
+	filter := model.ReservationFilter{
+		StartDate: "2024-12-01",
+		EndDate:   "2024-12-31",
+		Status:    []string{"Confirmed"},
+		Search:    "doe",
+		Sort:      "reservation_date",
+		Order:     "desc",
+		Page:      2,
+		Limit:     1,
+	}
+	where := `WHERE reservation_date >= $1 AND reservation_date < CAST($2 AS date) + 1 AND status IN ($3) AND (fullname ILIKE $4 OR phone_number ILIKE $5 OR email ILIKE $6)`
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reservations" `+where)).
+		WithArgs("2024-12-01", "2024-12-31", "Confirmed", "%doe%", "%doe%", "%doe%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reservations" `+where+` ORDER BY reservation_date desc,id LIMIT $7 OFFSET $8`)).
+		WithArgs("2024-12-01", "2024-12-31", "Confirmed", "%doe%", "%doe%", "%doe%", 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fullname"}).AddRow(2, "John Doe"))
+
+	reservations, total, totalPages, err := repo.SearchReservations(filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 3, totalPages)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	updateQuery := `UPDATE "reservations" SET "deposit_status"=$1,"deposit_settled_at"=$2,"status"=$3 WHERE status IN ($4,$5) AND "id" = $6`
+
+	newReservation := func() *model.Reservation {
+		return &model.Reservation{ID: 4, Status: model.ReservationNoShow, DepositStatus: model.DepositForfeited}
+	}
+
+	t.Run("Status changes from one of the expected statuses", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+			WithArgs(model.DepositForfeited, nil, model.ReservationNoShow, model.ReservationPending, model.ReservationConfirmed, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateStatus(newReservation(), model.ReservationPending, model.ReservationConfirmed)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("A reservation that moved on meanwhile is left alone", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+			WithArgs(model.DepositForfeited, nil, model.ReservationNoShow, model.ReservationPending, model.ReservationConfirmed, 4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.UpdateStatus(newReservation(), model.ReservationPending, model.ReservationConfirmed)
+
+		assert.ErrorIs(t, err, reservationrepository.ErrStatusChanged)
+	})
+}
+
+func TestSeat(t *testing.T) {
+	t.Run("A reservation cancelled meanwhile is not seated", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+		reservation := &model.Reservation{ID: 4, TableNumber: 2, Status: model.ReservationConfirmed}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tables" WHERE "tables"."id" = $1`)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "is_book"}).AddRow(2, false))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tables" SET "is_book"=$1`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "reservations" SET "deposit_status"=$1,"order_id"=$2,"status"=$3 WHERE status IN ($4) AND "id" = $5`)).
+			WithArgs("", 11, model.ReservationSeated, model.ReservationConfirmed, 4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Seat(reservation, &model.Order{TableID: 2}, model.ReservationConfirmed)
+
+		assert.ErrorIs(t, err, reservationrepository.ErrStatusChanged)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("A reservation whose status changed meanwhile is not overwritten", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+		reservation := &model.Reservation{ID: 4, TableNumber: 2, Status: model.ReservationCancelled}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "reservations" SET .* WHERE status = \$\d+ AND "id" = \$\d+`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Update(reservation, 15*time.Minute, model.ReservationConfirmed)
+
+		assert.ErrorIs(t, err, reservationrepository.ErrStatusChanged)
+	})
+}
+
+func TestFindHolding(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	repo := reservationrepository.NewReservationRepository(db, zap.NewNop())
+
+	open := time.Date(2024, time.December, 21, 10, 0, 0, 0, time.UTC)
+	closing := open.Add(16 * time.Hour)
+	buffer := 15 * time.Minute
+
+	// By time range rather than by date, so bookings either side of
+	// midnight are found too.
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reservations" WHERE status NOT IN ($1,$2,$3) AND reservation_date < $4 AND reservation_date + duration * INTERVAL '1 minute' > $5 ORDER BY reservation_date`)).
+		WithArgs("Completed", "No-Show", "Cancelled", closing.Add(buffer), open.Add(-buffer)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table_number", "reservation_date", "duration"}).
+			AddRow(1, 2, open.Add(-12*time.Hour), 750))
+
+	reservations, err := repo.FindHolding(open, closing, buffer)
+
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+}
@@ -20,6 +20,7 @@ type RevenueRepositoryInterface interface {
 	SaveProductRevenue(product model.ProductRevenue) error
 	CalculateProductRevenue() ([]model.ProductRevenue, error)
 	FindLowStockProducts(threshold int) ([]model.Product, error)
+	GetForfeitedDeposits() (map[string]float64, error)
 }
 
 type RevenueRepository struct {
@@ -36,7 +37,10 @@ func NewRevenueRepository(db *gorm.DB, log *zap.Logger) RevenueRepositoryInterfa
 
 func (r *RevenueRepository) FindLowStockProducts(threshold int) ([]model.Product, error) {
 	var products []model.Product
-	result := r.DB.Where("qty < ?", threshold).Find(&products)
+	// Deleted products are left out. Products made from a recipe are
+	// covered by their ingredients' alerts, bundles by their components'; a
+	// product's own reorder point takes precedence over threshold.
+	result := r.DB.Where("deleted_at IS NULL AND qty < COALESCE(NULLIF(reorder_point, 0), ?) AND id NOT IN (SELECT product_id FROM recipe_items) AND id NOT IN (SELECT bundle_id FROM bundle_items)", threshold).Find(&products)
 	return products, result.Error
 }
 
@@ -100,6 +104,33 @@ func (r *RevenueRepository) GetMonthlyRevenue() (map[string]float64, error) {
 	return monthlyRevenue, nil
 }
 
+// GetForfeitedDeposits sums the deposits kept from late cancellations and
+// no-shows per month of the current year. They are not part of any order, so
+// they are reported apart from order revenue.
+func (r *RevenueRepository) GetForfeitedDeposits() (map[string]float64, error) {
+	var results []model.MonthlyRevenue
+
+	forfeited := make(map[string]float64)
+
+	err := r.DB.Model(&model.Reservation{}).
+		Select("TO_CHAR(deposit_settled_at, 'YYYY-MM') as month, SUM(deposit_fee) as revenue").
+		Where("deposit_status = ?", model.DepositForfeited).
+		Where("EXTRACT(YEAR FROM deposit_settled_at) = ?", time.Now().Year()).
+		Group("month").
+		Order("month").
+		Scan(&results).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range results {
+		forfeited[res.Month] = res.Revenue
+	}
+
+	return forfeited, nil
+}
+
 func (r *RevenueRepository) CalculateOrderRevenue() ([]model.OrderRevenue, error) {
 	var revenues []model.OrderRevenue
 
@@ -198,7 +229,9 @@ func (r *RevenueRepository) SaveOrderRevenue(order model.OrderRevenue) error {
 // 	return products, err
 // }
 
-// CalculateProductRevenue calculates revenue details for all products
+// CalculateProductRevenue calculates revenue details for all products.
+// Bundles sold are credited to their components, with the share of the
+// bundle's price each was allocated.
 func (r *RevenueRepository) CalculateProductRevenue() ([]model.ProductRevenue, error) {
 	var products []model.ProductRevenue
 
@@ -206,11 +239,23 @@ func (r *RevenueRepository) CalculateProductRevenue() ([]model.ProductRevenue, e
 		Select(`
 			products.name AS product_name, 
 			products.price AS sell_price, 
-			SUM(order_products.qty * products.price) AS total_revenue, 
+			SUM(sales.revenue) AS total_revenue, 
+			SUM(sales.qty * COALESCE(recipes.food_cost, products.cost_price)) AS food_cost, 
 			CURRENT_DATE AS revenue_date
 		`).
-		Joins("JOIN order_products ON products.id = order_products.product_id").
-		Joins("JOIN orders ON order_products.order_id = orders.id").
+		Joins(`JOIN (
+			SELECT order_products.order_id, order_products.product_id, order_products.qty, order_products.qty * products.price AS revenue
+			FROM order_products JOIN products ON products.id = order_products.product_id
+			WHERE NOT EXISTS (SELECT 1 FROM order_bundle_items WHERE order_bundle_items.order_id = order_products.order_id AND order_bundle_items.bundle_id = order_products.product_id)
+			UNION ALL
+			SELECT order_id, product_id, qty, revenue FROM order_bundle_items
+		) sales ON products.id = sales.product_id`).
+		Joins("JOIN orders ON sales.order_id = orders.id").
+		Joins(`LEFT JOIN (
+			SELECT recipe_items.product_id, SUM(recipe_items.qty * ingredients.cost_per_unit) AS food_cost
+			FROM recipe_items JOIN ingredients ON ingredients.id = recipe_items.ingredient_id
+			GROUP BY recipe_items.product_id
+		) recipes ON recipes.product_id = products.id`).
 		Where("orders.status = ?", "Completed").
 		Group("products.name, products.price").
 		Scan(&products).Error
@@ -219,10 +264,15 @@ func (r *RevenueRepository) CalculateProductRevenue() ([]model.ProductRevenue, e
 		return nil, errors.New("failed to calculate product revenue: " + err.Error())
 	}
 
-	// Calculate profit and profit margin for each product
+	// Calculate profit and profit margin for each product. Profit is what
+	// is left after food cost, from the product's recipe or else its cost
+	// price; without either, PROFIT_MARGIN is assumed.
 	ProfitMargin := viper.GetFloat64("PROFIT_MARGIN")
 	for i := range products {
-		products[i].Profit = calculateProfit(products[i].TotalRevenue, ProfitMargin)
+		products[i].Profit = products[i].TotalRevenue - products[i].FoodCost
+		if products[i].FoodCost == 0 {
+			products[i].Profit = calculateProfit(products[i].TotalRevenue, ProfitMargin)
+		}
 		products[i].ProfitMargin = calculateProfitMargin(products[i].TotalRevenue, products[i].Profit)
 	}
 
@@ -28,7 +28,7 @@ func TestFindLowStockProducts(t *testing.T) {
 			AddRow(1, "Product A", 3).
 			AddRow(2, "Product B", 2)
 
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE qty < $1`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE deleted_at IS NULL AND qty < COALESCE(NULLIF(reorder_point, 0), $1) AND id NOT IN (SELECT product_id FROM recipe_items) AND id NOT IN (SELECT bundle_id FROM bundle_items)`)).
 			WithArgs(5).
 			WillReturnRows(mockRows)
 
@@ -40,7 +40,7 @@ func TestFindLowStockProducts(t *testing.T) {
 	})
 
 	t.Run("Fail to find low stock products", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE qty < $1`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE deleted_at IS NULL AND qty < COALESCE(NULLIF(reorder_point, 0), $1) AND id NOT IN (SELECT product_id FROM recipe_items) AND id NOT IN (SELECT bundle_id FROM bundle_items)`)).
 			WithArgs(5).
 			WillReturnError(fmt.Errorf("database error"))
 
@@ -114,6 +114,42 @@ func TestGetTotalRevenueByStatus(t *testing.T) {
 	})
 }
 
+func TestGetForfeitedDeposits(t *testing.T) {
+	db, mock := helper.SetupTestDB()
+
+	logger := zap.NewNop()
+	repo := revenuerepository.NewRevenueRepository(db, logger)
+
+	query := `SELECT TO_CHAR(deposit_settled_at, 'YYYY-MM') as month, SUM(deposit_fee) as revenue FROM "reservations" WHERE deposit_status = $1 AND EXTRACT(YEAR FROM deposit_settled_at) = $2 GROUP BY "month" ORDER BY month`
+
+	t.Run("Successfully get forfeited deposits", func(t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{"month", "revenue"}).
+			AddRow("2024-11", 60.0).
+			AddRow("2024-12", 120.0)
+
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(model.DepositForfeited, time.Now().Year()).
+			WillReturnRows(mockRows)
+
+		deposits, err := repo.GetForfeitedDeposits()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 60.0, deposits["2024-11"])
+		assert.Equal(t, 120.0, deposits["2024-12"])
+	})
+
+	t.Run("Fail to get forfeited deposits", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(model.DepositForfeited, time.Now().Year()).
+			WillReturnError(fmt.Errorf("database error"))
+
+		deposits, err := repo.GetForfeitedDeposits()
+
+		assert.Error(t, err)
+		assert.Nil(t, deposits)
+	})
+}
+
 func TestSaveOrderRevenue(t *testing.T) {
 	db, mock := helper.SetupTestDB()
 
@@ -287,7 +323,7 @@ func TestCalculateProductRevenue(t *testing.T) {
 		mockRows := sqlmock.NewRows([]string{"product_name", "sell_price", "total_revenue", "profit_margin", "revenue_date"}).
 			AddRow("Product A", 100.0, 2000.0, 15.0, time.Now())
 
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT products.name AS product_name, products.price AS sell_price, SUM(order_products.qty * products.price) AS total_revenue, CURRENT_DATE AS revenue_date FROM "products" JOIN order_products ON products.id = order_products.product_id JOIN orders ON order_products.order_id = orders.id WHERE orders.status = $1 GROUP BY products.name, products.price`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT products.name AS product_name, products.price AS sell_price, SUM(sales.revenue) AS total_revenue, SUM(sales.qty * COALESCE(recipes.food_cost, products.cost_price)) AS food_cost, CURRENT_DATE AS revenue_date FROM "products" JOIN ( SELECT order_products.order_id, order_products.product_id, order_products.qty, order_products.qty * products.price AS revenue FROM order_products JOIN products ON products.id = order_products.product_id WHERE NOT EXISTS (SELECT 1 FROM order_bundle_items WHERE order_bundle_items.order_id = order_products.order_id AND order_bundle_items.bundle_id = order_products.product_id) UNION ALL SELECT order_id, product_id, qty, revenue FROM order_bundle_items ) sales ON products.id = sales.product_id JOIN orders ON sales.order_id = orders.id LEFT JOIN ( SELECT recipe_items.product_id, SUM(recipe_items.qty * ingredients.cost_per_unit) AS food_cost FROM recipe_items JOIN ingredients ON ingredients.id = recipe_items.ingredient_id GROUP BY recipe_items.product_id ) recipes ON recipes.product_id = products.id WHERE orders.status = $1 GROUP BY products.name, products.price`)).
 			WillReturnRows(mockRows)
 
 		products, err := repo.CalculateProductRevenue()
@@ -298,7 +334,7 @@ func TestCalculateProductRevenue(t *testing.T) {
 	})
 
 	t.Run("Fail to calculate product revenue due to database error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT products.name AS product_name, products.price AS sell_price, SUM(order_products.qty * products.price) AS total_revenue, 15.00 AS profit_margin, CURRENT_DATE AS revenue_date FROM "products"`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT products.name AS product_name, products.price AS sell_price, SUM(sales.revenue) AS total_revenue`)).
 			WillReturnError(errors.New("database error"))
 
 		products, err := repo.CalculateProductRevenue()
@@ -342,6 +378,7 @@ func TestSaveProductRevenue(t *testing.T) {
 				productRevenue.Profit,
 				productRevenue.ProfitMargin,
 				productRevenue.TotalRevenue,
+				productRevenue.FoodCost,
 				productRevenue.RevenueDate,
 			).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
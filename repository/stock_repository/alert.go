@@ -0,0 +1,132 @@
+package stockrepository
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EvaluateAlerts checks the given products, or every product when none are
+// given, against their low-stock level. A product that falls below it gets
+// an alert and a notification, unless an alert is already open for it; one
+// that runs out is made unavailable. Alerts close once stock is back, and
+// the status taken away is given back. It returns the notifications sent.
+func (r *repositoryStock) EvaluateAlerts(productIDs []uint, lowStock int) ([]model.Notification, error) {
+	notifications := []model.Notification{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "name", "qty", "reorder_point", "status").
+			Where("deleted_at IS NULL")
+		if len(productIDs) > 0 {
+			query = query.Where("id IN ?", productIDs)
+		}
+		var products []model.Product
+		if err := query.Order("id").Find(&products).Error; err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(products))
+		for i, product := range products {
+			ids[i] = product.ID
+		}
+		var alerts []model.StockAlert
+		if err := tx.Where("product_id IN ? AND resolved_at IS NULL", ids).Find(&alerts).Error; err != nil {
+			return err
+		}
+		open := map[uint]*model.StockAlert{}
+		for i := range alerts {
+			open[alerts[i].ProductID] = &alerts[i]
+		}
+
+		for _, product := range products {
+			notification, err := evaluate(tx, product, open[product.ID], product.LowStockLevel(lowStock))
+			if err != nil {
+				return err
+			}
+			if notification != nil {
+				notifications = append(notifications, *notification)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Log.Error("Failed to evaluate stock alerts", zap.Uints("products", productIDs), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return notifications, nil
+}
+
+// evaluate brings one product's alert in line with its stock, returning the
+// notification to send, if any.
+func evaluate(tx *gorm.DB, product model.Product, alert *model.StockAlert, reorderPoint int) (*model.Notification, error) {
+	if product.Qty >= reorderPoint {
+		if alert == nil {
+			return nil, nil
+		}
+		if err := restoreStatus(tx, product, alert); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		return nil, tx.Model(alert).Updates(map[string]interface{}{"qty": product.Qty, "resolved_at": &now}).Error
+	}
+
+	level := model.AlertLow
+	if product.Qty <= 0 {
+		level = model.AlertOut
+	}
+	if alert != nil && alert.Level == level {
+		return nil, nil
+	}
+
+	if alert == nil {
+		alert = &model.StockAlert{ProductID: product.ID, Level: level, Qty: float64(product.Qty), ReorderPoint: float64(reorderPoint)}
+		if err := tx.Create(alert).Error; err != nil {
+			return nil, err
+		}
+		if level == model.AlertLow {
+			notification := model.NotifLowStock(product, reorderPoint)
+			return &notification, tx.Create(&notification).Error
+		}
+	} else {
+		updates := map[string]interface{}{"level": level, "qty": product.Qty, "reorder_point": reorderPoint}
+		if err := tx.Model(alert).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		// Back above zero but still low: it can be sold again, and the
+		// shortage was already notified.
+		if level == model.AlertLow {
+			return nil, restoreStatus(tx, product, alert)
+		}
+	}
+
+	if product.Available() {
+		if err := tx.Model(&model.Product{}).Where("id = ?", product.ID).Update("status", model.ProductUnavailable).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(alert).Update("previous_status", product.Status).Error; err != nil {
+			return nil, err
+		}
+	}
+	notification := model.NotifStock(product.Name)
+	return &notification, tx.Create(&notification).Error
+}
+
+// restoreStatus gives a product back the status its alert took away.
+func restoreStatus(tx *gorm.DB, product model.Product, alert *model.StockAlert) error {
+	if alert.PreviousStatus == "" {
+		return nil
+	}
+	if product.Status == model.ProductUnavailable {
+		if err := tx.Model(&model.Product{}).Where("id = ?", product.ID).Update("status", alert.PreviousStatus).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(alert).Update("previous_status", "").Error
+}
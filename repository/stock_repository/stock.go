@@ -0,0 +1,137 @@
+package stockrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryStock interface {
+	FindHistory(productID uint, page, limit int) ([]model.StockMovement, int, int, error)
+	Record(movement *model.StockMovement) error
+	EvaluateAlerts(productIDs []uint, lowStock int) ([]model.Notification, error)
+	MovedProducts(orderID uint) ([]uint, error)
+}
+
+var (
+	ErrProductNotFound   = errors.New(" Product Not Found")
+	ErrInsufficientStock = errors.New(" Insufficient Stock")
+)
+
+type repositoryStock struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewStockRepository(db *gorm.DB, log *zap.Logger) RepositoryStock {
+	return &repositoryStock{
+		DB:  db,
+		Log: log,
+	}
+}
+
+// FindHistory returns a product's movements, newest first, paginated like
+// the product listing.
+func (r *repositoryStock) FindHistory(productID uint, page, limit int) ([]model.StockMovement, int, int, error) {
+	var product model.Product
+	err := r.DB.Select("id").First(&product, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, 0, ErrProductNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find product", zap.Uint("product", productID), zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	query := r.DB.Model(&model.StockMovement{}).Where("product_id = ?", productID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count stock movements", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	movements := []model.StockMovement{}
+	err = query.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&movements).Error
+	if err != nil {
+		r.Log.Error("Failed to find stock movements", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return movements, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+// MovedProducts returns the products whose stock an order moved, those made
+// from a recipe included.
+func (r *repositoryStock) MovedProducts(orderID uint) ([]uint, error) {
+	productIDs := []uint{}
+	err := r.DB.Raw(`SELECT product_id FROM stock_movements WHERE order_id = ?
+		UNION SELECT product_id FROM ingredient_movements WHERE order_id = ?`, orderID, orderID).
+		Scan(&productIDs).Error
+	if err != nil {
+		r.Log.Error("Failed to find products moved by order", zap.Uint("order", orderID), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return productIDs, nil
+}
+
+func (r *repositoryStock) Record(movement *model.StockMovement) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		return Post(tx, movement)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) && !errors.Is(err, ErrInsufficientStock) {
+			r.Log.Error("Failed to record stock movement", zap.Uint("product", movement.ProductID), zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		return err
+	}
+	return nil
+}
+
+// Post writes a movement to the ledger and moves the product's qty by it,
+// inside the caller's transaction. The product row stays locked until the
+// transaction ends, so concurrent movements apply one after another and
+// each balance follows from the one before. Stock cannot go below zero.
+func Post(tx *gorm.DB, movement *model.StockMovement) error {
+	var product model.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "qty").First(&product, movement.ProductID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	balance := product.Qty + movement.Qty
+	if movement.Qty < 0 && balance < 0 {
+		return ErrInsufficientStock
+	}
+	if err := tx.Model(&product).Update("qty", balance).Error; err != nil {
+		return err
+	}
+
+	movement.Balance = balance
+	if err := tx.Create(movement).Error; err != nil {
+		return err
+	}
+	return RefreshBundles(tx, []uint{movement.ProductID})
+}
+
+// RefreshBundles sets the qty of every bundle made with the given products
+// to how many can be made from their stock. A choice group counts its best
+// stocked option; a deleted component makes none.
+func RefreshBundles(tx *gorm.DB, productIDs []uint) error {
+	return tx.Exec(`UPDATE products SET qty = a.available FROM (
+			SELECT bundle_id, GREATEST(MIN(available), 0) AS available FROM (
+				SELECT b.bundle_id, MAX(CASE WHEN p.deleted_at IS NULL THEN p.qty / b.qty ELSE 0 END) AS available
+				FROM bundle_items b JOIN products p ON p.id = b.product_id
+				WHERE b.bundle_id IN (SELECT bundle_id FROM bundle_items WHERE product_id IN ?)
+				GROUP BY b.bundle_id, CASE WHEN b.group_name = '' THEN b.id::text ELSE b.group_name END
+			) slots GROUP BY bundle_id
+		) a WHERE products.id = a.bundle_id`, productIDs).Error
+}
@@ -0,0 +1,192 @@
+package stockrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRecordMovement(t *testing.T) {
+	productQuery := `SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`
+
+	t.Run("Waste is taken off the stock and the balance recorded", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+		movement := &model.StockMovement{ProductID: 4, Type: model.MovementWaste, Qty: -3, Reason: "Dropped tray", ActorID: 2}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(4, 10))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(7, sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(4, model.MovementWaste, -3, 7, 0.0, "Dropped tray", 2, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Record(movement)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, movement.Balance)
+		assert.Equal(t, uint(1), movement.ID)
+	})
+
+	t.Run("Stock cannot go below zero", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+		movement := &model.StockMovement{ProductID: 4, Type: model.MovementTransfer, Qty: -12, Reason: "To second outlet"}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(4, 10))
+		mock.ExpectRollback()
+
+		err := repo.Record(movement)
+
+		assert.ErrorIs(t, err, stockrepository.ErrInsufficientStock)
+	})
+
+	t.Run("Unknown product", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}))
+		mock.ExpectRollback()
+
+		err := repo.Record(&model.StockMovement{ProductID: 9, Type: model.MovementRestock, Qty: 5})
+
+		assert.ErrorIs(t, err, stockrepository.ErrProductNotFound)
+	})
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	productQuery := `SELECT "id","name","qty","reorder_point","status" FROM "products" WHERE deleted_at IS NULL AND id IN ($1) ORDER BY id FOR UPDATE`
+	alertQuery := `SELECT * FROM "stock_alerts" WHERE product_id IN ($1) AND resolved_at IS NULL`
+	productColumns := []string{"id", "name", "qty", "reorder_point", "status"}
+	alertColumns := []string{"id", "product_id", "level", "qty", "reorder_point", "previous_status"}
+
+	t.Run("Falling below the reorder point opens an alert and notifies once", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(4, "Latte", 3, 5, "available"))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(alertColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_alerts"`)).
+			WithArgs(4, 0, model.AlertLow, 3.0, 5.0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notifications"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{4}, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, "Product Latte has 3 items in stock, below its reorder point of 5.", notifications[0].Message)
+	})
+
+	t.Run("An open alert is not notified again", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(4, "Latte", 2, 5, "available"))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(alertColumns).AddRow(1, 4, model.AlertLow, 3, 5, ""))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{4}, 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+
+	t.Run("Running out makes the product unavailable", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(4, "Latte", 0, 0, "available"))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(alertColumns).AddRow(1, 4, model.AlertLow, 3, 10, ""))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stock_alerts" SET "level"=$1,"qty"=$2,"reorder_point"=$3,"updated_at"=$4 WHERE "id" = $5`)).
+			WithArgs(model.AlertOut, 0, 10, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "status"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(model.ProductUnavailable, sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stock_alerts" SET "previous_status"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs("available", sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notifications"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{4}, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, "Stock Alert", notifications[0].Title)
+	})
+
+	t.Run("Recovered stock closes the alert and restores the status", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stockrepository.NewStockRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow(4, "Latte", 12, 0, model.ProductUnavailable))
+		mock.ExpectQuery(regexp.QuoteMeta(alertQuery)).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(alertColumns).AddRow(1, 4, model.AlertOut, 0, 10, "available"))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "status"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs("available", sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stock_alerts" SET "previous_status"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs("", sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stock_alerts" SET "qty"=$1,"resolved_at"=$2,"updated_at"=$3 WHERE "id" = $4`)).
+			WithArgs(12, sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		notifications, err := repo.EvaluateAlerts([]uint{4}, 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+}
@@ -0,0 +1,279 @@
+package stocktakerepository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"project_pos_app/model"
+	stockrepository "project_pos_app/repository/stock_repository"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryStocktake interface {
+	FindStocktakes(status string, page, limit int) ([]model.Stocktake, int, int, error)
+	FindStocktake(id uint) (*model.Stocktake, error)
+	Open(stocktake *model.Stocktake, categoryIDs []uint) error
+	SaveCounts(id uint, form model.FormStocktakeCount, countedBy uint) (*model.Stocktake, error)
+	Commit(id, actorID uint) (*model.Stocktake, error)
+	Cancel(id uint) (*model.Stocktake, error)
+}
+
+var (
+	ErrStocktakeNotFound = errors.New(" Stocktake Not Found")
+	ErrStocktakeClosed   = errors.New(" Stocktake Is Not Open")
+	ErrNotInStocktake    = errors.New(" Product Not In Stocktake")
+	ErrStocktakeOverlap  = errors.New(" Products Already In An Open Stocktake")
+	ErrNothingToCount    = errors.New(" No Products To Count")
+)
+
+type repositoryStocktake struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewStocktakeRepository(db *gorm.DB, log *zap.Logger) RepositoryStocktake {
+	return &repositoryStocktake{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositoryStocktake) FindStocktakes(status string, page, limit int) ([]model.Stocktake, int, int, error) {
+	query := r.DB.Model(&model.Stocktake{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count stocktakes", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	stocktakes := []model.Stocktake{}
+	err := query.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&stocktakes).Error
+	if err != nil {
+		r.Log.Error("Failed to find stocktakes", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return stocktakes, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+func (r *repositoryStocktake) FindStocktake(id uint) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := r.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("category_id").Order("product_name").Order("id")
+	}).First(&stocktake, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrStocktakeNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find stocktake", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &stocktake, nil
+}
+
+// Open starts a count of the products in the given categories, or of all
+// of them, snapshotting their qty. Products made from a recipe have no
+// stock of their own to count, and a product can only be in one open
+// stocktake at a time.
+func (r *repositoryStocktake) Open(stocktake *model.Stocktake, categoryIDs []uint) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("deleted_at IS NULL").
+			Where("id NOT IN (?)", tx.Model(&model.RecipeItem{}).Select("product_id"))
+		if len(categoryIDs) > 0 {
+			query = query.Where("category_id IN ?", categoryIDs)
+		}
+		var products []model.Product
+		if err := query.Order("id").Find(&products).Error; err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			return ErrNothingToCount
+		}
+
+		ids := make([]uint, len(products))
+		for i, product := range products {
+			ids[i] = product.ID
+		}
+		var overlap int64
+		err := tx.Model(&model.StocktakeLine{}).
+			Joins("JOIN stocktakes ON stocktakes.id = stocktake_lines.stocktake_id").
+			Where("stocktakes.status = ? AND stocktake_lines.product_id IN ?", model.StocktakeOpen, ids).
+			Count(&overlap).Error
+		if err != nil {
+			return err
+		}
+		if overlap > 0 {
+			return ErrStocktakeOverlap
+		}
+
+		stocktake.Status = model.StocktakeOpen
+		stocktake.Lines = nil
+		if err := tx.Create(stocktake).Error; err != nil {
+			return err
+		}
+		for _, product := range products {
+			stocktake.Lines = append(stocktake.Lines, model.StocktakeLine{
+				StocktakeID: stocktake.ID,
+				ProductID:   product.ID,
+				ItemID:      product.ItemID,
+				ProductName: product.Name,
+				CategoryID:  product.CategoryID,
+				SystemQty:   product.Qty,
+				UnitCost:    product.CostPrice,
+			})
+		}
+		return tx.Create(&stocktake.Lines).Error
+	})
+	return r.failure("Failed to open stocktake", err)
+}
+
+// SaveCounts records what a device counted. Each device's latest count of
+// a product replaces its earlier one, and the line is counted as the sum
+// over all devices, so shelves can be split between counters.
+func (r *repositoryStocktake) SaveCounts(id uint, form model.FormStocktakeCount, countedBy uint) (*model.Stocktake, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeOpen {
+			return ErrStocktakeClosed
+		}
+
+		for _, entry := range form.Counts {
+			var line model.StocktakeLine
+			err := tx.Where("stocktake_id = ? AND product_id = ?", id, entry.ProductID).First(&line).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInStocktake
+			}
+			if err != nil {
+				return err
+			}
+
+			count := model.StocktakeCount{StocktakeID: id, ProductID: entry.ProductID, Device: form.Device, Qty: *entry.Qty, CountedBy: countedBy}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "stocktake_id"}, {Name: "product_id"}, {Name: "device"}},
+				DoUpdates: clause.AssignmentColumns([]string{"qty", "counted_by", "updated_at"}),
+			}).Create(&count).Error
+			if err != nil {
+				return err
+			}
+
+			var counted int
+			err = tx.Model(&model.StocktakeCount{}).
+				Where("stocktake_id = ? AND product_id = ?", id, entry.ProductID).
+				Select("COALESCE(SUM(qty), 0)").Scan(&counted).Error
+			if err != nil {
+				return err
+			}
+			updates := map[string]interface{}{"counted": true, "counted_qty": counted, "variance": counted - line.SystemQty}
+			if err := tx.Model(&line).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err := r.failure("Failed to save stocktake counts", err); err != nil {
+		return nil, err
+	}
+	return r.FindStocktake(id)
+}
+
+// Commit closes the count and posts each counted line's variance as an
+// adjustment. Stock sold since the stocktake was opened stays sold, so an
+// adjustment never takes a product below zero.
+func (r *repositoryStocktake) Commit(id, actorID uint) (*model.Stocktake, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeOpen {
+			return ErrStocktakeClosed
+		}
+
+		var lines []model.StocktakeLine
+		if err := tx.Where("stocktake_id = ? AND counted = ? AND variance <> 0", id, true).Order("product_id").Find(&lines).Error; err != nil {
+			return err
+		}
+		for _, line := range lines {
+			var product model.Product
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "qty").First(&product, line.ProductID).Error
+			if err != nil {
+				return err
+			}
+			qty := max(line.Variance, -product.Qty)
+			if qty != 0 {
+				adjustment := model.StockMovement{
+					ProductID: line.ProductID,
+					Type:      model.MovementAdjustment,
+					Qty:       qty,
+					Reason:    fmt.Sprintf("Stocktake #%d", id),
+					ActorID:   actorID,
+				}
+				if err := stockrepository.Post(tx, &adjustment); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&line).Update("adjusted_qty", qty).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": model.StocktakeCommitted, "committed_by": actorID, "committed_at": &now}
+		return tx.Model(stocktake).Updates(updates).Error
+	})
+	if err := r.failure("Failed to commit stocktake", err); err != nil {
+		return nil, err
+	}
+	return r.FindStocktake(id)
+}
+
+func (r *repositoryStocktake) Cancel(id uint) (*model.Stocktake, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeOpen {
+			return ErrStocktakeClosed
+		}
+		return tx.Model(stocktake).Update("status", model.StocktakeCanceled).Error
+	})
+	if err := r.failure("Failed to cancel stocktake", err); err != nil {
+		return nil, err
+	}
+	return r.FindStocktake(id)
+}
+
+func (r *repositoryStocktake) failure(message string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrStocktakeNotFound), errors.Is(err, ErrStocktakeClosed), errors.Is(err, ErrNotInStocktake),
+		errors.Is(err, ErrStocktakeOverlap), errors.Is(err, ErrNothingToCount):
+		return err
+	default:
+		r.Log.Error(message, zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+}
+
+func lockStocktake(tx *gorm.DB, id uint) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stocktake, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrStocktakeNotFound
+	}
+	return &stocktake, err
+}
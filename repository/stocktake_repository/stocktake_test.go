@@ -0,0 +1,112 @@
+package stocktakerepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	stocktakerepository "project_pos_app/repository/stocktake_repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCommit(t *testing.T) {
+	stocktakeQuery := `SELECT * FROM "stocktakes" WHERE "stocktakes"."id" = $1 ORDER BY "stocktakes"."id" LIMIT $2 FOR UPDATE`
+	productQuery := `SELECT "id","qty" FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`
+	lineColumns := []string{"id", "stocktake_id", "product_id", "system_qty", "counted", "counted_qty", "variance"}
+
+	t.Run("Variances are posted as adjustments, never below zero", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stocktakerepository.NewStocktakeRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(stocktakeQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(3, model.StocktakeOpen))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "stocktake_lines" WHERE stocktake_id = $1 AND counted = $2 AND variance <> 0 ORDER BY product_id`)).
+			WithArgs(3, true).
+			WillReturnRows(sqlmock.NewRows(lineColumns).
+				AddRow(10, 3, 4, 8, true, 11, 3).
+				AddRow(11, 3, 6, 5, true, 1, -4))
+
+		// Counted 3 more than the system had.
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(4, 7))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(4, 7))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(10, sqlmock.AnyArg(), 4).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(4, model.MovementAdjustment, 3, 10, 0.0, "Stocktake #3", 2, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stocktake_lines" SET "adjusted_qty"=$1 WHERE "id" = $2`)).
+			WithArgs(3, 10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Counted 4 short, but 2 were sold since, so only 2 are left to take.
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(6, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(6, 2))
+		mock.ExpectQuery(regexp.QuoteMeta(productQuery)).
+			WithArgs(6, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(6, 2))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "qty"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+			WithArgs(0, sqlmock.AnyArg(), 6).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "stock_movements"`)).
+			WithArgs(6, model.MovementAdjustment, -2, 0, 0.0, "Stocktake #3", 2, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE products SET qty = a.available`)).
+			WithArgs(6).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stocktake_lines" SET "adjusted_qty"=$1 WHERE "id" = $2`)).
+			WithArgs(-2, 11).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "stocktakes" SET "committed_at"=$1,"committed_by"=$2,"status"=$3,"updated_at"=$4 WHERE "id" = $5`)).
+			WithArgs(sqlmock.AnyArg(), 2, model.StocktakeCommitted, sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "stocktakes" WHERE "stocktakes"."id" = $1`)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(3, model.StocktakeCommitted))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "stocktake_lines" WHERE "stocktake_lines"."stocktake_id" = $1`)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(append(lineColumns, "unit_cost", "adjusted_qty")).
+				AddRow(10, 3, 4, 8, true, 11, 3, 2.5, 3).
+				AddRow(11, 3, 6, 5, true, 1, -4, 1.0, -2))
+
+		stocktake, err := repo.Commit(3, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.StocktakeCommitted, stocktake.Status)
+		assert.Equal(t, 7.5, stocktake.Lines[0].VarianceValue)
+		assert.Equal(t, -2, stocktake.Lines[1].AdjustedQty)
+	})
+
+	t.Run("A committed stocktake cannot be committed again", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := stocktakerepository.NewStocktakeRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(stocktakeQuery)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(3, model.StocktakeCommitted))
+		mock.ExpectRollback()
+
+		_, err := repo.Commit(3, 2)
+
+		assert.ErrorIs(t, err, stocktakerepository.ErrStocktakeClosed)
+	})
+}
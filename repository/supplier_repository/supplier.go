@@ -0,0 +1,87 @@
+package supplierrepository
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RepositorySupplier interface {
+	FindSuppliers(search string, page, limit int) ([]model.Supplier, int, int, error)
+	FindSupplier(id uint) (*model.Supplier, error)
+	Create(supplier *model.Supplier) error
+	Update(supplier *model.Supplier) error
+}
+
+var ErrSupplierNotFound = errors.New(" Supplier Not Found")
+
+type repositorySupplier struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewSupplierRepository(db *gorm.DB, log *zap.Logger) RepositorySupplier {
+	return &repositorySupplier{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *repositorySupplier) FindSuppliers(search string, page, limit int) ([]model.Supplier, int, int, error) {
+	query := r.DB.Model(&model.Supplier{})
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR contact_name ILIKE ? OR phone_number ILIKE ? OR email ILIKE ?", like, like, like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("Failed to count suppliers", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+
+	suppliers := []model.Supplier{}
+	if err := query.Order("name").Order("id").Offset((page - 1) * limit).Limit(limit).Find(&suppliers).Error; err != nil {
+		r.Log.Error("Failed to find suppliers", zap.Error(err))
+		return nil, 0, 0, errors.New(" Internal Server Error")
+	}
+	return suppliers, int(total), int(math.Ceil(float64(total) / float64(limit))), nil
+}
+
+func (r *repositorySupplier) FindSupplier(id uint) (*model.Supplier, error) {
+	var supplier model.Supplier
+	err := r.DB.First(&supplier, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSupplierNotFound
+	}
+	if err != nil {
+		r.Log.Error("Failed to find supplier", zap.Uint("id", id), zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return &supplier, nil
+}
+
+func (r *repositorySupplier) Create(supplier *model.Supplier) error {
+	if err := r.DB.Create(supplier).Error; err != nil {
+		r.Log.Error("Failed to create supplier", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+func (r *repositorySupplier) Update(supplier *model.Supplier) error {
+	result := r.DB.Model(supplier).
+		Select("name", "contact_name", "phone_number", "email", "address", "lead_time_days").
+		Updates(supplier)
+	if result.Error != nil {
+		r.Log.Error("Failed to update supplier", zap.Uint("id", supplier.ID), zap.Error(result.Error))
+		return errors.New(" Internal Server Error")
+	}
+	if result.RowsAffected == 0 {
+		return ErrSupplierNotFound
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package waitlistrepository
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepositoryWaitlist interface {
+	FindQueue() ([]model.Waitlist, error)
+	FindEntry(entry *model.Waitlist) error
+	Insert(entry *model.Waitlist) error
+	UpdateStatus(entry *model.Waitlist) error
+	FindOccupancy() ([]model.TableOccupancy, error)
+	AverageDiningMinutes(since time.Time) (float64, error)
+	Seat(entry *model.Waitlist, order *model.Order) error
+}
+
+// closedOrderStatuses are the (lower-cased) statuses of orders whose guests
+// have left the table.
+var closedOrderStatuses = []string{"completed", "cancelled"}
+
+type repositoryWaitlist struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewWaitlistRepository(db *gorm.DB, log *zap.Logger) RepositoryWaitlist {
+	return &repositoryWaitlist{
+		DB:  db,
+		Log: log,
+	}
+}
+
+// FindQueue returns the parties still waiting, first come first.
+func (r *repositoryWaitlist) FindQueue() ([]model.Waitlist, error) {
+	var entries []model.Waitlist
+	err := r.DB.Where("status IN ?", model.WaitlistQueued).Order("created_at").Order("id").Find(&entries).Error
+	if err != nil {
+		r.Log.Error("Failed to find waitlist", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return entries, nil
+}
+func (r *repositoryWaitlist) FindEntry(entry *model.Waitlist) error {
+	err := r.DB.First(entry, entry.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New(" Waitlist Entry Not Found")
+	}
+	if err != nil {
+		r.Log.Error("Failed to find waitlist entry", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+func (r *repositoryWaitlist) Insert(entry *model.Waitlist) error {
+	if err := r.DB.Create(entry).Error; err != nil {
+		r.Log.Error("Failed to insert waitlist entry", zap.Error(err))
+		return errors.New(" Bad Request")
+	}
+	return nil
+}
+func (r *repositoryWaitlist) UpdateStatus(entry *model.Waitlist) error {
+	err := r.DB.Model(entry).Select("status", "called_at").Updates(entry).Error
+	if err != nil {
+		r.Log.Error("Failed to update waitlist entry", zap.Error(err))
+		return errors.New(" Internal Server Error")
+	}
+	return nil
+}
+
+// FindOccupancy returns every table with the time the oldest open order on
+// it was created, which is when its current guests sat down.
+func (r *repositoryWaitlist) FindOccupancy() ([]model.TableOccupancy, error) {
+	var occupancy []model.TableOccupancy
+	err := r.DB.Model(&model.Table{}).
+		Select("tables.*, open_orders.seated_at").
+		Joins("LEFT JOIN (SELECT table_id, MIN(created_at) AS seated_at FROM orders WHERE deleted_at IS NULL AND LOWER(status) NOT IN ? GROUP BY table_id) AS open_orders ON open_orders.table_id = tables.id", closedOrderStatuses).
+		Where("tables.deleted_at IS NULL").
+		Order("tables.id").
+		Scan(&occupancy).Error
+	if err != nil {
+		r.Log.Error("Failed to find table occupancy", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+	return occupancy, nil
+}
+
+// AverageDiningMinutes is the average time between opening and completing
+// an order, over the orders opened since the given time. It is zero when
+// there is no such order.
+func (r *repositoryWaitlist) AverageDiningMinutes(since time.Time) (float64, error) {
+	var average float64
+	err := r.DB.Model(&model.Order{}).
+		Select("COALESCE(AVG(EXTRACT(EPOCH FROM (updated_at - created_at)) / 60), 0)").
+		Where("LOWER(status) = ? AND created_at >= ?", "completed", since).
+		Scan(&average).Error
+	if err != nil {
+		r.Log.Error("Failed to compute average dining duration", zap.Error(err))
+		return 0, errors.New(" Internal Server Error")
+	}
+	return average, nil
+}
+
+// Seat occupies the party's table, opens its order and marks the party as
+// seated in a single transaction.
+func (r *repositoryWaitlist) Seat(entry *model.Waitlist, order *model.Order) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var table model.Table
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&table, entry.TableID).Error
+		if err != nil {
+			r.Log.Error("Failed to find waitlist table", zap.Error(err))
+			return errors.New(" Table Not Found")
+		}
+		if table.IsBook {
+			return errors.New(" Table Is Occupied")
+		}
+		if table.Capacity < entry.Pax {
+			return errors.New(" Table Too Small")
+		}
+		if err := tx.Model(&table).Update("is_book", true).Error; err != nil {
+			r.Log.Error("Failed to occupy table", zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		if err := tx.Create(order).Error; err != nil {
+			r.Log.Error("Failed to open waitlist order", zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		entry.OrderID = order.ID
+		err = tx.Model(entry).Select("status", "table_id", "order_id", "seated_at").Updates(entry).Error
+		if err != nil {
+			r.Log.Error("Failed to seat waitlist entry", zap.Error(err))
+			return errors.New(" Internal Server Error")
+		}
+		return nil
+	})
+}
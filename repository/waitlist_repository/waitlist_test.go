@@ -0,0 +1,82 @@
+package waitlistrepository_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	waitlistrepository "project_pos_app/repository/waitlist_repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSeatWaitlistEntry(t *testing.T) {
+	newEntry := func() *model.Waitlist {
+		now := time.Now()
+		return &model.Waitlist{ID: 3, Fullname: "Jane Doe", Pax: 4, Status: model.WaitlistSeated, TableID: 2, SeatedAt: &now}
+	}
+	tableQuery := `SELECT * FROM "tables" WHERE "tables"."id" = $1 AND "tables"."deleted_at" IS NULL ORDER BY "tables"."id" LIMIT $2 FOR UPDATE`
+	tableColumns := []string{"id", "name", "is_book", "capacity"}
+
+	t.Run("Successfully seat party at a free table", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := waitlistrepository.NewWaitlistRepository(db, zap.NewNop())
+		entry := newEntry()
+		order := &model.Order{TableID: 2, CustomerName: entry.Fullname, Status: "Draft", Tax: 12}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(tableQuery)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows(tableColumns).AddRow(2, "Table 1", false, 4))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tables" SET "is_book"=$1`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "waitlists" SET "status"=$1,"table_id"=$2,"order_id"=$3,"seated_at"=$4`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Seat(entry, order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), entry.OrderID)
+	})
+
+	t.Run("Reject seating at an occupied table", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := waitlistrepository.NewWaitlistRepository(db, zap.NewNop())
+		entry := newEntry()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(tableQuery)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows(tableColumns).AddRow(2, "Table 1", true, 4))
+		mock.ExpectRollback()
+
+		err := repo.Seat(entry, &model.Order{})
+
+		assert.EqualError(t, err, " Table Is Occupied")
+	})
+
+	t.Run("Reject seating at a table too small for the party", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { _ = mock.ExpectationsWereMet() }()
+		repo := waitlistrepository.NewWaitlistRepository(db, zap.NewNop())
+		entry := newEntry()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(tableQuery)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows(tableColumns).AddRow(2, "Table 5", false, 2))
+		mock.ExpectRollback()
+
+		err := repo.Seat(entry, &model.Order{})
+
+		assert.EqualError(t, err, " Table Too Small")
+	})
+}
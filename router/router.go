@@ -3,6 +3,8 @@ package router
 import (
 	"project_pos_app/helper"
 	"project_pos_app/infra"
+	"project_pos_app/storage"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -18,6 +20,9 @@ func NewRoutes(ctx *infra.IntegrationContext) *gin.Engine {
 
 	r := gin.Default()
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if driver := strings.ToLower(ctx.Cfg.Storage.Driver); driver == "" || driver == storage.DriverLocal {
+		r.Static(storage.LocalPath, ctx.Cfg.Storage.Dir)
+	}
 
 	r.POST("/login", ctx.Ctl.Auth.Login)
 	r.PATCH("/logout", ctx.Ctl.Superadmin.Logout)
@@ -27,6 +32,14 @@ func NewRoutes(ctx *infra.IntegrationContext) *gin.Engine {
 	ProductRoutes(r, ctx)
 	CategoryRoutes(r, ctx)
 	ReservationRoutes(r, ctx)
+	WaitlistRoutes(r, ctx)
+	CustomerRoutes(r, ctx)
+	PurchaseRoutes(r, ctx)
+	IngredientRoutes(r, ctx)
+	StocktakeRoutes(r, ctx)
+	MenuRoutes(r, ctx)
+	DrawerRoutes(r, ctx)
+	AttendanceRoutes(r, ctx)
 	OrderRoutes(r, ctx)
 	SuperAdmin(r, ctx)
 	DashboardRoutes(r, ctx)
@@ -54,6 +67,7 @@ func RevenueRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 		revenueRoute.GET("/month", ctx.Ctl.Revenue.GetMonthlyRevenue)
 		revenueRoute.GET("/products", ctx.Ctl.Revenue.GetProductRevenues)
 		revenueRoute.GET("/status", ctx.Ctl.Revenue.GetTotalRevenueByStatus)
+		revenueRoute.GET("/deposits", ctx.Ctl.Revenue.GetForfeitedDeposits)
 	}
 }
 
@@ -62,10 +76,24 @@ func ProductRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 	{
 		productRoute.Use(ctx.Middleware.Access.AccessMiddleware())
 		productRoute.GET("/", ctx.Ctl.Product.GetAllProducts)
+		productRoute.GET("/export", ctx.Ctl.Product.ExportProducts)
+		productRoute.GET("/trash", ctx.Ctl.Product.GetTrash)
+		productRoute.GET("/lookup", ctx.Ctl.Product.LookupBarcode)
+		productRoute.POST("/labels", ctx.Ctl.Product.PrintBarcodeLabels)
+		productRoute.POST("/import", ctx.Ctl.Product.ImportProducts)
 		productRoute.GET("/:id", ctx.Ctl.Product.GetProductByID)
+		productRoute.GET("/:id/stock-history", ctx.Ctl.Product.GetStockHistory)
+		productRoute.POST("/:id/stock", ctx.Ctl.Product.RecordStockMovement)
+		productRoute.GET("/:id/recipe", ctx.Ctl.Ingredient.GetRecipe)
+		productRoute.PUT("/:id/recipe", ctx.Ctl.Ingredient.SaveRecipe)
+		productRoute.GET("/:id/bundle", ctx.Ctl.Bundle.GetBundle)
+		productRoute.PUT("/:id/bundle", ctx.Ctl.Bundle.SaveBundle)
 		productRoute.POST("/", ctx.Ctl.Product.CreateProduct)
 		productRoute.PUT("/:id", ctx.Ctl.Product.UpdateProduct)
 		productRoute.DELETE("/:id", ctx.Ctl.Product.DeleteProduct)
+		productRoute.POST("/:id/restore", ctx.Ctl.Product.RestoreProduct)
+		productRoute.POST("/:id/barcodes", ctx.Ctl.Product.AddBarcode)
+		productRoute.DELETE("/:id/barcodes/:code", ctx.Ctl.Product.RemoveBarcode)
 	}
 }
 
@@ -74,9 +102,15 @@ func ReservationRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 	{
 		reservationRoute.Use(ctx.Middleware.Access.AccessMiddleware())
 		reservationRoute.GET("/", ctx.Ctl.Reservation.GetAll)
+		reservationRoute.GET("/availability", ctx.Ctl.Reservation.GetAvailability)
+		reservationRoute.GET("/export.ics", ctx.Ctl.Reservation.ExportCalendar)
 		reservationRoute.GET("/:id", ctx.Ctl.Reservation.GetById)
 		reservationRoute.POST("/", ctx.Ctl.Reservation.Create)
 		reservationRoute.PUT("/:id", ctx.Ctl.Reservation.Edit)
+		reservationRoute.POST("/:id/seat", ctx.Ctl.Reservation.Seat)
+		reservationRoute.POST("/:id/cancel", ctx.Ctl.Reservation.Cancel)
+		reservationRoute.POST("/:id/no-show", ctx.Ctl.Reservation.NoShow)
+		reservationRoute.POST("/:id/deposit", ctx.Ctl.Reservation.PayDeposit)
 	}
 }
 
@@ -108,14 +142,90 @@ func CategoryRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 	{
 		categoryRoute.Use(ctx.Middleware.Access.AccessMiddleware())
 		categoryRoute.GET("/", ctx.Ctl.Category.GetAllCategory)
-		categoryRoute.GET("/products", func(c *gin.Context) {
-			ctx.Ctl.Product.GetAllProducts(c)
-		})
+		categoryRoute.GET("/products", ctx.Ctl.Product.GetCategoryProducts)
+		categoryRoute.GET("/tree", ctx.Ctl.Category.GetCategoryTree)
 		categoryRoute.GET("/:id", ctx.Ctl.Category.GetCategoryByID)
 		categoryRoute.POST("/", ctx.Ctl.Category.CreateCategory)
+		categoryRoute.PUT("/reorder", ctx.Ctl.Category.ReorderCategories)
 		categoryRoute.PUT("/:id", ctx.Ctl.Category.UpdateCategory)
+		categoryRoute.DELETE("/:id", ctx.Ctl.Category.DeleteCategory)
+	}
+}
+func WaitlistRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	waitlistRoute := r.Group("/waitlist")
+	{
+		waitlistRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		waitlistRoute.GET("/", ctx.Ctl.Waitlist.GetQueue)
+		waitlistRoute.GET("/quote", ctx.Ctl.Waitlist.Quote)
+		waitlistRoute.POST("/", ctx.Ctl.Waitlist.Add)
+		waitlistRoute.POST("/:id/call", ctx.Ctl.Waitlist.Call)
+		waitlistRoute.POST("/:id/seat", ctx.Ctl.Waitlist.Seat)
+		waitlistRoute.DELETE("/:id", ctx.Ctl.Waitlist.Remove)
+	}
+}
+
+func CustomerRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	customerRoute := r.Group("/customers")
+	{
+		customerRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		customerRoute.GET("/", ctx.Ctl.Customer.GetAll)
+		customerRoute.GET("/:id", ctx.Ctl.Customer.GetProfile)
+		customerRoute.GET("/:id/orders", ctx.Ctl.Customer.GetOrders)
+		customerRoute.GET("/:id/points", ctx.Ctl.Customer.GetPoints)
+		customerRoute.POST("/:id/points/adjust", ctx.Ctl.Customer.AdjustPoints)
+	}
+}
+
+func IngredientRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	ingredientRoute := r.Group("/ingredients")
+	{
+		ingredientRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		ingredientRoute.GET("/", ctx.Ctl.Ingredient.GetAll)
+		ingredientRoute.GET("/:id", ctx.Ctl.Ingredient.Get)
+		ingredientRoute.POST("/", ctx.Ctl.Ingredient.Create)
+		ingredientRoute.PUT("/:id", ctx.Ctl.Ingredient.Update)
+		ingredientRoute.GET("/:id/stock-history", ctx.Ctl.Ingredient.GetStockHistory)
+		ingredientRoute.POST("/:id/stock", ctx.Ctl.Ingredient.RecordStockMovement)
+	}
+}
+
+func StocktakeRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	stocktakeRoute := r.Group("/stocktakes")
+	{
+		stocktakeRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		stocktakeRoute.GET("/", ctx.Ctl.Stocktake.GetAll)
+		stocktakeRoute.GET("/:id", ctx.Ctl.Stocktake.Get)
+		stocktakeRoute.POST("/", ctx.Ctl.Stocktake.Open)
+		stocktakeRoute.POST("/:id/counts", ctx.Ctl.Stocktake.Count)
+		stocktakeRoute.POST("/:id/commit", ctx.Ctl.Stocktake.Commit)
+		stocktakeRoute.POST("/:id/cancel", ctx.Ctl.Stocktake.Cancel)
+		stocktakeRoute.GET("/:id/report", ctx.Ctl.Stocktake.Report)
+	}
+}
+
+func PurchaseRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	supplierRoute := r.Group("/suppliers")
+	{
+		supplierRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		supplierRoute.GET("/", ctx.Ctl.Purchase.GetSuppliers)
+		supplierRoute.GET("/:id", ctx.Ctl.Purchase.GetSupplier)
+		supplierRoute.POST("/", ctx.Ctl.Purchase.CreateSupplier)
+		supplierRoute.PUT("/:id", ctx.Ctl.Purchase.UpdateSupplier)
+	}
+
+	purchaseRoute := r.Group("/purchase-orders")
+	{
+		purchaseRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		purchaseRoute.GET("/", ctx.Ctl.Purchase.GetPurchaseOrders)
+		purchaseRoute.GET("/:id", ctx.Ctl.Purchase.GetPurchaseOrder)
+		purchaseRoute.POST("/", ctx.Ctl.Purchase.CreatePurchaseOrder)
+		purchaseRoute.PUT("/:id", ctx.Ctl.Purchase.UpdatePurchaseOrder)
+		purchaseRoute.POST("/:id/submit", ctx.Ctl.Purchase.SubmitPurchaseOrder)
+		purchaseRoute.POST("/:id/cancel", ctx.Ctl.Purchase.CancelPurchaseOrder)
+		purchaseRoute.POST("/:id/receive", ctx.Ctl.Purchase.ReceiveGoods)
 	}
 }
+
 func DashboardRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 	reservationRoute := r.Group("/api")
 	{
@@ -123,5 +233,50 @@ func DashboardRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
 		reservationRoute.GET("/dashboard/new", ctx.Ctl.Dashboard.GetNewProduct)
 		reservationRoute.GET("/dashboard/summary", ctx.Ctl.Dashboard.GetSummary)
 		reservationRoute.GET("/dashboard/report", ctx.Ctl.Dashboard.GetReport)
+		reservationRoute.GET("/dashboard/staff", ctx.Ctl.Dashboard.GetStaffSales)
+		reservationRoute.GET("/dashboard/staff/report", ctx.Ctl.Dashboard.GetStaffSalesReport)
+	}
+}
+
+func MenuRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	menuRoute := r.Group("/menu")
+	{
+		menuRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		menuRoute.GET("/", ctx.Ctl.Menu.GetAll)
+		menuRoute.GET("/current", ctx.Ctl.Menu.Current)
+		menuRoute.GET("/:id", ctx.Ctl.Menu.Get)
+		menuRoute.POST("/", ctx.Ctl.Menu.Create)
+		menuRoute.PUT("/:id", ctx.Ctl.Menu.Update)
+		menuRoute.DELETE("/:id", ctx.Ctl.Menu.Delete)
+		menuRoute.PUT("/86/:productId", ctx.Ctl.Menu.EightySix)
+	}
+}
+
+func DrawerRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	drawerRoute := r.Group("/drawer")
+	{
+		drawerRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		drawerRoute.GET("/current", ctx.Ctl.Drawer.Current)
+		drawerRoute.POST("/open", ctx.Ctl.Drawer.Open)
+		drawerRoute.POST("/movements", ctx.Ctl.Drawer.Move)
+		drawerRoute.POST("/close", ctx.Ctl.Drawer.Close)
+		drawerRoute.GET("/sessions", ctx.Ctl.Drawer.GetAll)
+		drawerRoute.GET("/sessions/:id/report", ctx.Ctl.Drawer.Report)
+	}
+}
+
+func AttendanceRoutes(r *gin.Engine, ctx *infra.IntegrationContext) {
+	attendanceRoute := r.Group("/attendance")
+	{
+		attendanceRoute.Use(ctx.Middleware.Access.AccessMiddleware())
+		attendanceRoute.POST("/clock-in", ctx.Ctl.Attendance.ClockIn)
+		attendanceRoute.POST("/clock-out", ctx.Ctl.Attendance.ClockOut)
+		attendanceRoute.POST("/break/start", ctx.Ctl.Attendance.StartBreak)
+		attendanceRoute.POST("/break/end", ctx.Ctl.Attendance.EndBreak)
+		attendanceRoute.GET("/roster", ctx.Ctl.Attendance.Roster)
+		attendanceRoute.POST("/roster", ctx.Ctl.Attendance.AssignShifts)
+		attendanceRoute.DELETE("/roster/:id", ctx.Ctl.Attendance.RemoveShift)
+		attendanceRoute.GET("/report", ctx.Ctl.Attendance.Report)
+		attendanceRoute.GET("/payroll", ctx.Ctl.Attendance.Payroll)
 	}
 }
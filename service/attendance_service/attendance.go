@@ -0,0 +1,278 @@
+package attendanceservice
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotManager  = errors.New(" Only Managers Can Change The Roster")
+	ErrInvalidDate = errors.New(" Invalid Date")
+)
+
+type ServiceAttendance interface {
+	ClockIn(userID uint) (*model.TimeEntry, error)
+	ClockOut(userID uint) (*model.TimeEntry, error)
+	StartBreak(userID uint) (*model.TimeEntry, error)
+	EndBreak(userID uint) (*model.TimeEntry, error)
+	Roster(week string) (*model.Roster, error)
+	AssignShifts(form model.FormRoster, actorID uint, role string) ([]model.Shift, error)
+	RemoveShift(id uint, role string) error
+	Report(from, to string, employeeID uint) ([]model.AttendanceRecord, error)
+	Payroll(from, to string) ([]model.PayrollExcel, error)
+}
+
+type serviceAttendance struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+	// lateGrace is how late a clock-in can be before it counts as late.
+	lateGrace time.Duration
+	// monthlyHours are the standard hours worked in a month, which a
+	// monthly salary is paid for.
+	monthlyHours float64
+}
+
+func NewAttendanceService(repo *repository.AllRepository, log *zap.Logger, lateGrace time.Duration, monthlyHours float64) ServiceAttendance {
+	return &serviceAttendance{
+		Repo:         repo,
+		Log:          log,
+		lateGrace:    lateGrace,
+		monthlyHours: monthlyHours,
+	}
+}
+
+func (s *serviceAttendance) ClockIn(userID uint) (*model.TimeEntry, error) {
+	employee, err := s.Repo.Attendance.FindEmployee(userID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.Repo.Attendance.ClockIn(employee.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Clocked in", zap.Uint("employee", employee.ID), zap.Uint("shift", entry.ShiftID))
+	return entry, nil
+}
+
+func (s *serviceAttendance) ClockOut(userID uint) (*model.TimeEntry, error) {
+	employee, err := s.Repo.Attendance.FindEmployee(userID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.Repo.Attendance.ClockOut(employee.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Clocked out", zap.Uint("employee", employee.ID), zap.Uint("entry", entry.ID))
+	return entry, nil
+}
+
+func (s *serviceAttendance) StartBreak(userID uint) (*model.TimeEntry, error) {
+	employee, err := s.Repo.Attendance.FindEmployee(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.Attendance.StartBreak(employee.ID, time.Now())
+}
+
+func (s *serviceAttendance) EndBreak(userID uint) (*model.TimeEntry, error) {
+	employee, err := s.Repo.Attendance.FindEmployee(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.Attendance.EndBreak(employee.ID, time.Now())
+}
+
+// Roster returns the shifts of the week, Monday to Sunday, that the date
+// week falls in, this week when it is empty.
+func (s *serviceAttendance) Roster(week string) (*model.Roster, error) {
+	day := time.Now()
+	if week != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", week, time.Local); err != nil {
+			return nil, ErrInvalidDate
+		}
+	}
+	start := weekStart(day)
+	end := start.AddDate(0, 0, 7)
+
+	shifts, err := s.Repo.Attendance.FindShifts(start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Roster{
+		WeekStart: start.Format("2006-01-02"),
+		WeekEnd:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Shifts:    shifts,
+	}, nil
+}
+
+func (s *serviceAttendance) AssignShifts(form model.FormRoster, actorID uint, role string) ([]model.Shift, error) {
+	if !model.IsManager(role) {
+		return nil, ErrNotManager
+	}
+	shifts := []model.Shift{}
+	for _, entry := range form.Shifts {
+		start, err := time.ParseInLocation("2006-01-02 15:04", entry.Date+" "+entry.Start, time.Local)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		end, err := time.ParseInLocation("2006-01-02 15:04", entry.Date+" "+entry.End, time.Local)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		shifts = append(shifts, model.Shift{
+			EmployeeID: entry.EmployeeID,
+			StartAt:    start,
+			EndAt:      end,
+			Note:       strings.TrimSpace(entry.Note),
+			AssignedBy: actorID,
+		})
+	}
+	if err := s.Repo.Attendance.AddShifts(shifts); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Assigned shifts", zap.Uint("actor", actorID), zap.Int("shifts", len(shifts)))
+	return shifts, nil
+}
+
+func (s *serviceAttendance) RemoveShift(id uint, role string) error {
+	if !model.IsManager(role) {
+		return ErrNotManager
+	}
+	return s.Repo.Attendance.DeleteShift(id)
+}
+
+// Report compares the time entries and rostered shifts from from to to,
+// this week by default, with what was scheduled. Entries off the roster
+// are held to the employee's usual shift; rostered shifts already started
+// without an entry are absences.
+func (s *serviceAttendance) Report(from, to string, employeeID uint) ([]model.AttendanceRecord, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.Repo.Attendance.FindEmployees(employeeID)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := s.Repo.Attendance.FindShifts(start, end, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.Repo.Attendance.FindEntries(start, end, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[uint]model.Employee{}
+	for _, employee := range employees {
+		byID[employee.ID] = employee
+	}
+	now := time.Now()
+
+	records := []model.AttendanceRecord{}
+	worked := map[uint]bool{}
+	for i, entry := range entries {
+		employee := byID[entry.EmployeeID]
+		var scheduledStart, scheduledEnd time.Time
+		rostered := false
+		for _, shift := range shifts {
+			if shift.ID == entry.ShiftID {
+				scheduledStart, scheduledEnd, rostered = shift.StartAt, shift.EndAt, true
+				worked[shift.ID] = true
+			}
+		}
+		if !rostered {
+			scheduledStart, scheduledEnd, _ = employee.DefaultShift(entry.ClockIn.In(time.Local))
+		}
+		record := model.NewAttendanceRecord(employee, scheduledStart, scheduledEnd, &entries[i], s.lateGrace, now)
+		record.Rostered = rostered
+		records = append(records, record)
+	}
+	for _, shift := range shifts {
+		if worked[shift.ID] || shift.StartAt.After(now) {
+			continue
+		}
+		record := model.NewAttendanceRecord(byID[shift.EmployeeID], shift.StartAt, shift.EndAt, nil, s.lateGrace, now)
+		record.Rostered = true
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Date != records[j].Date {
+			return records[i].Date < records[j].Date
+		}
+		return records[i].Name < records[j].Name
+	})
+	return records, nil
+}
+
+// Payroll works out each employee's pay from from to to, this week by
+// default: the hours of the entries clocked out of, less breaks, at the
+// hourly rate of the employee's monthly Salary over monthlyHours.
+func (s *serviceAttendance) Payroll(from, to string) ([]model.PayrollExcel, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.Repo.Attendance.FindEmployees(0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.Repo.Attendance.FindEntries(start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	payroll := []model.PayrollExcel{}
+	for i, employee := range employees {
+		rate := employee.Salary / s.monthlyHours
+		row := model.PayrollExcel{No: i + 1, EmployeeID: employee.ID, Name: employee.Name, Salary: employee.Salary, HourlyRate: math.Round(rate*100) / 100}
+		var worked time.Duration
+		for _, entry := range entries {
+			if entry.EmployeeID == employee.ID && entry.ClockOut != nil {
+				worked += entry.Worked(*entry.ClockOut)
+				row.Shifts++
+			}
+		}
+		row.Hours = math.Round(worked.Hours()*100) / 100
+		row.Pay = math.Round(row.Hours*rate*100) / 100
+		payroll = append(payroll, row)
+	}
+	return payroll, nil
+}
+
+// period turns the dates from and to into the times from the start of
+// from to the end of to, the current week when either is missing.
+func period(from, to string) (time.Time, time.Time, error) {
+	if from == "" || to == "" {
+		start := weekStart(time.Now())
+		return start, start.AddDate(0, 0, 7), nil
+	}
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidDate
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil || end.Before(start) {
+		return time.Time{}, time.Time{}, ErrInvalidDate
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// weekStart is midnight on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
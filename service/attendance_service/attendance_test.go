@@ -0,0 +1,40 @@
+package attendanceservice_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	attendanceservice "project_pos_app/service/attendance_service"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPayroll(t *testing.T) {
+	t.Run("Hours are paid at the monthly salary spread over the standard hours", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := attendanceservice.NewAttendanceService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 5*time.Minute, 160)
+
+		clockIn := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "employees" ORDER BY name,id`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "salary"}).AddRow(1, "Ann", 4000.0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "time_entries" WHERE clock_in >= $1 AND clock_in < $2 ORDER BY clock_in,id`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "employee_id", "clock_in", "clock_out"}).
+				AddRow(7, 1, clockIn, clockIn.Add(8*time.Hour)).
+				AddRow(8, 1, clockIn.Add(24*time.Hour), nil))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "time_breaks" WHERE "time_breaks"."entry_id" IN ($1,$2) ORDER BY start_at`)).
+			WithArgs(7, 8).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "entry_id", "start_at", "end_at"}).
+				AddRow(1, 7, clockIn.Add(4*time.Hour), clockIn.Add(4*time.Hour+30*time.Minute)))
+
+		payroll, err := service.Payroll("2026-03-02", "2026-03-08")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.PayrollExcel{{No: 1, EmployeeID: 1, Name: "Ann", Salary: 4000, HourlyRate: 25, Shifts: 1, Hours: 7.5, Pay: 187.5}}, payroll)
+	})
+}
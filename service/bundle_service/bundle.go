@@ -0,0 +1,38 @@
+package bundleservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+
+	"go.uber.org/zap"
+)
+
+type ServiceBundle interface {
+	GetBundle(productID uint) (*model.Bundle, error)
+	SaveBundle(productID uint, form model.FormBundle) (*model.Bundle, error)
+}
+
+type serviceBundle struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewBundleService(repo *repository.AllRepository, log *zap.Logger) ServiceBundle {
+	return &serviceBundle{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceBundle) GetBundle(productID uint) (*model.Bundle, error) {
+	return s.Repo.Bundle.FindBundle(productID)
+}
+
+func (s *serviceBundle) SaveBundle(productID uint, form model.FormBundle) (*model.Bundle, error) {
+	bundle, err := s.Repo.Bundle.SaveBundle(productID, form)
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Saved bundle", zap.Uint("product", productID), zap.Int("items", len(bundle.Items)))
+	return bundle, nil
+}
@@ -12,6 +12,9 @@ type CategoryService interface {
 	GetCategoryByID(id int) (*model.Category, error)
 	CreateCategory(category *model.Category) error
 	UpdateCategory(categoryID uint, category *model.Category) error
+	CategoryTree() ([]model.Category, error)
+	DeleteCategory(categoryID uint, reassignTo *uint) error
+	ReorderCategories(form model.FormCategoryReorder) error
 }
 
 type categoryService struct {
@@ -73,3 +76,54 @@ func (ps *categoryService) UpdateCategory(categoryID uint, category *model.Categ
 	ps.log.Info("Successfully updated category", zap.Uint("categoryID", categoryID))
 	return nil
 }
+
+// CategoryTree nests every category under its parent, each level in
+// display order.
+func (cs *categoryService) CategoryTree() ([]model.Category, error) {
+	categories, err := cs.repo.Category.AllCategories()
+	if err != nil {
+		cs.log.Error("Error fetching categories", zap.Error(err))
+		return nil, err
+	}
+
+	children := make(map[uint][]model.Category)
+	live := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		live[category.ID] = true
+	}
+	var roots []model.Category
+	for _, category := range categories {
+		if category.ParentID == nil || !live[*category.ParentID] {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var nest func(level []model.Category) []model.Category
+	nest = func(level []model.Category) []model.Category {
+		for i := range level {
+			level[i].Children = nest(children[level[i].ID])
+		}
+		return level
+	}
+	return nest(roots), nil
+}
+
+func (cs *categoryService) DeleteCategory(categoryID uint, reassignTo *uint) error {
+	cs.log.Info("Deleting category", zap.Uint("categoryID", categoryID))
+
+	if err := cs.repo.Category.DeleteCategory(categoryID, reassignTo); err != nil {
+		cs.log.Error("Error deleting category", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (cs *categoryService) ReorderCategories(form model.FormCategoryReorder) error {
+	if err := cs.repo.Category.ReorderCategories(form.ParentID, form.IDs); err != nil {
+		cs.log.Error("Error reordering categories", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package customerservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+
+	"go.uber.org/zap"
+)
+
+type ServiceCustomer interface {
+	GetAll(search string, page, limit int) ([]model.Customer, int, int, error)
+	GetProfile(id uint) (*model.CustomerProfile, error)
+	GetOrders(id uint) ([]*model.OrderResponse, error)
+}
+
+type serviceCustomer struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewCustomerService(repo *repository.AllRepository, log *zap.Logger) ServiceCustomer {
+	return &serviceCustomer{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceCustomer) GetAll(search string, page, limit int) ([]model.Customer, int, int, error) {
+	return s.Repo.Customer.FindCustomers(search, page, limit)
+}
+
+func (s *serviceCustomer) GetProfile(id uint) (*model.CustomerProfile, error) {
+	return s.Repo.Customer.FindProfile(id)
+}
+
+func (s *serviceCustomer) GetOrders(id uint) ([]*model.OrderResponse, error) {
+	customer := model.Customer{ID: id}
+	if err := s.Repo.Customer.FindCustomer(&customer); err != nil {
+		return nil, err
+	}
+	orders, err := s.Repo.Order.GetOrdersByCustomer(id)
+	if err != nil {
+		s.Log.Error("Failed to find customer orders", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	return orders, nil
+}
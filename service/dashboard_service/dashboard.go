@@ -1,8 +1,11 @@
 package dashboardservice
 
 import (
+	"errors"
+	"math"
 	"project_pos_app/model"
 	"project_pos_app/repository"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -12,25 +15,42 @@ type ServiceDashboard interface {
 	GetNewProduct() ([]model.Product, error)
 	GetSummary(summary *model.Summary) error
 	GetReport(report *[]model.ReportExcel) error
+	GetStaffSales(from, to string) ([]model.StaffSales, error)
 }
 
+var ErrInvalidDate = errors.New(" Invalid Date")
+
 type serviceDashboard struct {
 	Repo *repository.AllRepository
 	Log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
 }
 
-func NewRevenueService(repo *repository.AllRepository, log *zap.Logger) ServiceDashboard {
+func NewRevenueService(repo *repository.AllRepository, log *zap.Logger, lowStock int) ServiceDashboard {
 	return &serviceDashboard{
-		Repo: repo,
-		Log:  log,
+		Repo:     repo,
+		Log:      log,
+		lowStock: lowStock,
 	}
 }
 
 func (s *serviceDashboard) GetPopularProduct() ([]model.Product, error) {
-	return s.Repo.Dashboard.FindPopularProduct()
+	products, err := s.Repo.Dashboard.FindPopularProduct()
+	if err != nil {
+		return nil, err
+	}
+	model.SetStockStatuses(products, s.lowStock)
+	return products, nil
 }
 func (s *serviceDashboard) GetNewProduct() ([]model.Product, error) {
-	return s.Repo.Dashboard.FindNewProduct()
+	products, err := s.Repo.Dashboard.FindNewProduct()
+	if err != nil {
+		return nil, err
+	}
+	model.SetStockStatuses(products, s.lowStock)
+	return products, nil
 }
 func (s *serviceDashboard) GetSummary(summary *model.Summary) error {
 	err := s.Repo.Dashboard.FindSummary(summary)
@@ -47,3 +67,42 @@ func (s *serviceDashboard) GetReport(report *[]model.ReportExcel) error {
 
 	return nil
 }
+
+// GetStaffSales reports each staff member's sales from from to to, both
+// dates included, or for the current month when either is missing.
+func (s *serviceDashboard) GetStaffSales(from, to string) ([]model.StaffSales, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := s.Repo.Dashboard.FindStaffSales(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i, member := range staff {
+		if member.OrdersServed > 0 {
+			staff[i].AverageTicket = math.Round(member.Sales/float64(member.OrdersServed)*100) / 100
+			staff[i].ItemsPerOrder = math.Round(float64(member.Items)/float64(member.OrdersServed)*100) / 100
+		}
+	}
+	return staff, nil
+}
+
+// period turns the dates from and to into the times from the start of
+// from to the end of to.
+func period(from, to string) (time.Time, time.Time, error) {
+	if from == "" || to == "" {
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidDate
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil || end.Before(start) {
+		return time.Time{}, time.Time{}, ErrInvalidDate
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,52 @@
+package dashboardservice_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	dashboardservice "project_pos_app/service/dashboard_service"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGetStaffSales(t *testing.T) {
+	columns := []string{"user_id", "name", "orders_taken", "orders_served", "sales", "items", "voids", "voided_amount", "discounts", "discounted_orders"}
+
+	t.Run("Averages are per order served", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := dashboardservice.NewRevenueService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 10)
+
+		from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT u.id AS user_id`)).
+			WithArgs(from, from.AddDate(0, 0, 7), from, from.AddDate(0, 0, 7)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, "Rina", 5, 3, 100.0, 7, 1, 12.5, 4.0, 1).
+				AddRow(3, "kitchen@pos.com", 2, 0, 0.0, 0, 0, 0.0, 0.0, 0))
+
+		staff, err := service.GetStaffSales("2026-10-01", "2026-10-07")
+
+		assert.NoError(t, err)
+		assert.Len(t, staff, 2)
+		assert.Equal(t, 33.33, staff[0].AverageTicket)
+		assert.Equal(t, 2.33, staff[0].ItemsPerOrder)
+		assert.Equal(t, 1, staff[0].Voids)
+		assert.Zero(t, staff[1].AverageTicket)
+	})
+
+	t.Run("A range ending before it starts is refused", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := dashboardservice.NewRevenueService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 10)
+
+		staff, err := service.GetStaffSales("2026-10-07", "2026-10-01")
+
+		assert.ErrorIs(t, err, dashboardservice.ErrInvalidDate)
+		assert.Nil(t, staff)
+	})
+}
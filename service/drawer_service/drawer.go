@@ -0,0 +1,104 @@
+package drawerservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ServiceDrawer interface {
+	GetAll(page, limit int) ([]model.DrawerSession, int, int, error)
+	Current() (*model.DrawerReport, error)
+	Report(id uint) (*model.DrawerReport, error)
+	Open(form model.FormOpenDrawer, actorID uint) (*model.DrawerSession, error)
+	Move(form model.FormDrawerMovement, actorID uint) (*model.DrawerMovement, error)
+	Close(form model.FormCloseDrawer, actorID uint) (*model.DrawerReport, error)
+}
+
+type serviceDrawer struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewDrawerService(repo *repository.AllRepository, log *zap.Logger) ServiceDrawer {
+	return &serviceDrawer{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceDrawer) GetAll(page, limit int) ([]model.DrawerSession, int, int, error) {
+	return s.Repo.Drawer.FindSessions(page, limit)
+}
+
+// Current is the X report of the open session.
+func (s *serviceDrawer) Current() (*model.DrawerReport, error) {
+	session, err := s.Repo.Drawer.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+	return s.reading(session)
+}
+
+// Report is a session's Z report, or an X report while it is still open.
+func (s *serviceDrawer) Report(id uint) (*model.DrawerReport, error) {
+	session, err := s.Repo.Drawer.FindSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == model.DrawerOpen {
+		return s.reading(session)
+	}
+	return &model.DrawerReport{Type: model.DrawerReportZ, Session: *session, GeneratedAt: time.Now()}, nil
+}
+
+func (s *serviceDrawer) Open(form model.FormOpenDrawer, actorID uint) (*model.DrawerSession, error) {
+	session := model.DrawerSession{
+		OpeningFloat: *form.OpeningFloat,
+		Note:         strings.TrimSpace(form.Note),
+		OpenedBy:     actorID,
+	}
+	if err := s.Repo.Drawer.Open(&session); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Opened drawer session", zap.Uint("session", session.ID), zap.Float64("float", session.OpeningFloat), zap.Uint("actor", actorID))
+	return &session, nil
+}
+
+func (s *serviceDrawer) Move(form model.FormDrawerMovement, actorID uint) (*model.DrawerMovement, error) {
+	movement := model.DrawerMovement{
+		Type:    form.Type,
+		Amount:  form.Amount,
+		Reason:  strings.TrimSpace(form.Reason),
+		ActorID: actorID,
+	}
+	if err := s.Repo.Drawer.Move(&movement); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// Close counts and closes the open session, returning its Z report.
+func (s *serviceDrawer) Close(form model.FormCloseDrawer, actorID uint) (*model.DrawerReport, error) {
+	form.Note = strings.TrimSpace(form.Note)
+	session, err := s.Repo.Drawer.Close(form, actorID)
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Closed drawer session", zap.Uint("session", session.ID), zap.Float64("variance", session.Variance), zap.Uint("actor", actorID))
+	return &model.DrawerReport{Type: model.DrawerReportZ, Session: *session, GeneratedAt: time.Now()}, nil
+}
+
+// reading takes an X report of an open session: what its drawer should
+// hold so far, without closing it.
+func (s *serviceDrawer) reading(session *model.DrawerSession) (*model.DrawerReport, error) {
+	tenders, open, err := s.Repo.Drawer.Tally(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	session.Expect(tenders)
+	return &model.DrawerReport{Type: model.DrawerReportX, Session: *session, OpenOrders: open, GeneratedAt: time.Now()}, nil
+}
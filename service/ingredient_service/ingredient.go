@@ -0,0 +1,145 @@
+package ingredientservice
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type ServiceIngredient interface {
+	GetAll(search string, page, limit int) ([]model.Ingredient, int, int, error)
+	Get(id uint) (*model.Ingredient, error)
+	Create(form model.FormIngredient) (*model.Ingredient, error)
+	Update(id uint, form model.FormIngredient) (*model.Ingredient, error)
+	StockHistory(id uint, page, limit int) ([]model.IngredientMovement, int, int, error)
+	RecordMovement(id uint, form model.FormIngredientMovement, actorID uint) (*model.IngredientMovement, error)
+	GetRecipe(productID uint) (*model.Recipe, error)
+	SaveRecipe(productID uint, form model.FormRecipe) (*model.Recipe, error)
+	EvaluateStockAlerts(ingredientIDs ...uint) ([]model.Notification, error)
+}
+
+type serviceIngredient struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewIngredientService(repo *repository.AllRepository, log *zap.Logger) ServiceIngredient {
+	return &serviceIngredient{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceIngredient) GetAll(search string, page, limit int) ([]model.Ingredient, int, int, error) {
+	return s.Repo.Ingredient.FindIngredients(search, page, limit)
+}
+
+func (s *serviceIngredient) Get(id uint) (*model.Ingredient, error) {
+	return s.Repo.Ingredient.FindIngredient(id)
+}
+
+func (s *serviceIngredient) Create(form model.FormIngredient) (*model.Ingredient, error) {
+	unit := strings.ToLower(strings.TrimSpace(form.Unit))
+	if _, ok := model.Units[unit]; !ok {
+		return nil, model.ErrUnitMismatch
+	}
+	ingredient := model.Ingredient{
+		Name:         strings.TrimSpace(form.Name),
+		Unit:         unit,
+		CostPerUnit:  form.CostPerUnit,
+		ReorderLevel: form.ReorderLevel,
+	}
+	if err := s.Repo.Ingredient.Create(&ingredient); err != nil {
+		return nil, err
+	}
+	return &ingredient, nil
+}
+
+func (s *serviceIngredient) Update(id uint, form model.FormIngredient) (*model.Ingredient, error) {
+	ingredient, err := s.Repo.Ingredient.FindIngredient(id)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(form.Unit), ingredient.Unit) {
+		return nil, model.ErrUnitMismatch
+	}
+	ingredient.Name = strings.TrimSpace(form.Name)
+	ingredient.CostPerUnit = form.CostPerUnit
+	ingredient.ReorderLevel = form.ReorderLevel
+	if err := s.Repo.Ingredient.Update(ingredient); err != nil {
+		return nil, err
+	}
+	if _, err := s.EvaluateStockAlerts(id); err != nil {
+		s.Log.Error("Failed to evaluate ingredient alerts", zap.Uint("ingredient", id), zap.Error(err))
+	}
+	return ingredient, nil
+}
+
+func (s *serviceIngredient) StockHistory(id uint, page, limit int) ([]model.IngredientMovement, int, int, error) {
+	return s.Repo.Ingredient.FindHistory(id, page, limit)
+}
+
+// RecordMovement posts an ingredient stock change made by hand, with the
+// same sign rules as product stock movements.
+func (s *serviceIngredient) RecordMovement(id uint, form model.FormIngredientMovement, actorID uint) (*model.IngredientMovement, error) {
+	qty := form.Qty
+	switch form.Type {
+	case model.MovementRestock:
+		qty = math.Abs(qty)
+	case model.MovementWaste:
+		qty = -math.Abs(qty)
+	}
+	if qty == 0 {
+		return nil, errors.New(" Bad Request")
+	}
+
+	movement := model.IngredientMovement{
+		IngredientID: id,
+		Type:         form.Type,
+		Qty:          qty,
+		Reason:       strings.TrimSpace(form.Reason),
+		ActorID:      actorID,
+	}
+	if err := s.Repo.Ingredient.Record(&movement); err != nil {
+		return nil, err
+	}
+	if _, err := s.EvaluateStockAlerts(id); err != nil {
+		s.Log.Error("Failed to evaluate ingredient alerts", zap.Uint("ingredient", id), zap.Error(err))
+	}
+	return &movement, nil
+}
+
+func (s *serviceIngredient) GetRecipe(productID uint) (*model.Recipe, error) {
+	return s.Repo.Ingredient.FindRecipe(productID)
+}
+
+func (s *serviceIngredient) SaveRecipe(productID uint, form model.FormRecipe) (*model.Recipe, error) {
+	if len(form.Items) > 0 {
+		bundle, err := s.Repo.Bundle.HasBundle(productID)
+		if err != nil {
+			return nil, err
+		}
+		if bundle {
+			return nil, errors.New(" A Bundle Cannot Have A Recipe")
+		}
+	}
+	for i := range form.Items {
+		form.Items[i].Unit = strings.ToLower(strings.TrimSpace(form.Items[i].Unit))
+	}
+	recipe, err := s.Repo.Ingredient.SaveRecipe(productID, form)
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Saved recipe", zap.Uint("product", productID), zap.Int("items", len(recipe.Items)))
+	return recipe, nil
+}
+
+// EvaluateStockAlerts checks the given ingredients, or all of them,
+// against their reorder level, and returns the notifications sent.
+func (s *serviceIngredient) EvaluateStockAlerts(ingredientIDs ...uint) ([]model.Notification, error) {
+	return s.Repo.Ingredient.EvaluateAlerts(ingredientIDs)
+}
@@ -0,0 +1,48 @@
+package loyaltyservice
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ServiceLoyalty interface {
+	GetAccount(customerID uint) (*model.LoyaltyAccount, error)
+	Adjust(customerID uint, form model.FormPointsAdjust) (*model.LoyaltyLedger, error)
+	ExpirePoints() ([]model.LoyaltyLedger, error)
+}
+
+type serviceLoyalty struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewLoyaltyService(repo *repository.AllRepository, log *zap.Logger) ServiceLoyalty {
+	return &serviceLoyalty{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceLoyalty) GetAccount(customerID uint) (*model.LoyaltyAccount, error) {
+	return s.Repo.Loyalty.FindAccount(customerID)
+}
+
+func (s *serviceLoyalty) Adjust(customerID uint, form model.FormPointsAdjust) (*model.LoyaltyLedger, error) {
+	if form.Points == 0 {
+		return nil, errors.New(" Bad Request")
+	}
+	entry, err := s.Repo.Loyalty.Adjust(customerID, form)
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Adjusted loyalty points", zap.Uint("customer", customerID), zap.Int("points", form.Points), zap.String("reason", form.Reason))
+	return entry, nil
+}
+
+func (s *serviceLoyalty) ExpirePoints() ([]model.LoyaltyLedger, error) {
+	return s.Repo.Loyalty.ExpirePoints(time.Now())
+}
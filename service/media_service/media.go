@@ -0,0 +1,131 @@
+package mediaservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"mime/multipart"
+	"project_pos_app/imaging"
+	"project_pos_app/model"
+	"project_pos_app/storage"
+
+	"go.uber.org/zap"
+)
+
+type ServiceMedia interface {
+	// UploadImage checks an uploaded image against the limits of its kind,
+	// strips its metadata and stores it in every variant size.
+	UploadImage(ctx context.Context, kind string, file *multipart.FileHeader) (*model.ImageVariants, error)
+}
+
+var (
+	ErrUploadFailed  = errors.New(" Failed To Upload Image")
+	ErrImageTooLarge = errors.New(" Image Too Large")
+)
+
+// variants are the sizes an image is kept in, by their longest side. The
+// original keeps its size.
+var variants = []struct {
+	name string
+	size int
+}{
+	{"thumbnail", 320},
+	{"medium", 960},
+	{"original", 0},
+}
+
+type serviceMedia struct {
+	Storage storage.Storage
+	Log     *zap.Logger
+	// maxMB is each kind's size limit in megabytes.
+	maxMB map[string]int64
+}
+
+func NewMediaService(store storage.Storage, log *zap.Logger, maxMB map[string]int64) ServiceMedia {
+	return &serviceMedia{
+		Storage: store,
+		Log:     log,
+		maxMB:   maxMB,
+	}
+}
+
+func (s *serviceMedia) UploadImage(ctx context.Context, kind string, file *multipart.FileHeader) (*model.ImageVariants, error) {
+	limit := s.maxMB[kind] << 20
+	if limit <= 0 {
+		limit = 5 << 20
+	}
+	if file.Size > limit {
+		return nil, ErrImageTooLarge
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		s.Log.Error("Failed to open uploaded image", zap.Error(err))
+		return nil, ErrUploadFailed
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		s.Log.Error("Failed to read uploaded image", zap.Error(err))
+		return nil, ErrUploadFailed
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrImageTooLarge
+	}
+
+	img, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, ErrImageTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := storage.NewKey(kind)
+	if err != nil {
+		s.Log.Error("Failed to name uploaded image", zap.Error(err))
+		return nil, ErrUploadFailed
+	}
+	objects := make([]storage.Object, len(variants))
+	for i, variant := range variants {
+		resized := img
+		if variant.size > 0 {
+			resized = imaging.Fit(img, variant.size)
+		}
+		data, err := encode(resized)
+		if errors.Is(err, imaging.ErrWebPTooLarge) {
+			return nil, ErrImageTooLarge
+		}
+		if err != nil {
+			s.Log.Error("Failed to encode image", zap.String("variant", variant.name), zap.Error(err))
+			return nil, ErrUploadFailed
+		}
+		objects[i] = storage.Object{
+			Key:         key + "-" + variant.name + ".webp",
+			Data:        data,
+			ContentType: "image/webp",
+		}
+	}
+
+	urls, err := storage.PutAll(ctx, s.Storage, objects)
+	if err != nil {
+		s.Log.Error("Failed to store image", zap.String("kind", kind), zap.Error(err))
+		return nil, ErrUploadFailed
+	}
+	return &model.ImageVariants{
+		Thumbnail: urls[0],
+		Medium:    urls[1],
+		Original:  urls[2],
+	}, nil
+}
+
+// encode writes img as WebP.
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := imaging.EncodeWebP(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package menuservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ServiceMenu interface {
+	GetAll() ([]model.Menu, error)
+	Get(id uint) (*model.Menu, error)
+	Create(form model.FormMenu) (*model.Menu, error)
+	Update(id uint, form model.FormMenu) (*model.Menu, error)
+	Delete(id uint) error
+	Current(at time.Time) (*model.CurrentMenu, error)
+	EightySix(productID uint, eightySixed bool) error
+	ClearEightySixed() (int64, error)
+}
+
+type serviceMenu struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
+}
+
+func NewMenuService(repo *repository.AllRepository, log *zap.Logger, lowStock int) ServiceMenu {
+	return &serviceMenu{
+		Repo:     repo,
+		Log:      log,
+		lowStock: lowStock,
+	}
+}
+
+func (s *serviceMenu) GetAll() ([]model.Menu, error) {
+	return s.Repo.Menu.FindMenus()
+}
+
+func (s *serviceMenu) Get(id uint) (*model.Menu, error) {
+	return s.Repo.Menu.FindMenu(id)
+}
+
+func (s *serviceMenu) Create(form model.FormMenu) (*model.Menu, error) {
+	menu := menuFromForm(form)
+	if err := s.Repo.Menu.Create(&menu); err != nil {
+		return nil, err
+	}
+	return s.Repo.Menu.FindMenu(menu.ID)
+}
+
+func (s *serviceMenu) Update(id uint, form model.FormMenu) (*model.Menu, error) {
+	menu := menuFromForm(form)
+	menu.ID = id
+	if err := s.Repo.Menu.Update(&menu); err != nil {
+		return nil, err
+	}
+	return s.Repo.Menu.FindMenu(id)
+}
+
+func (s *serviceMenu) Delete(id uint) error {
+	return s.Repo.Menu.Delete(id)
+}
+
+// Current returns the menus open at the given time and the products that
+// can be sold then.
+func (s *serviceMenu) Current(at time.Time) (*model.CurrentMenu, error) {
+	menus, err := s.Repo.Menu.FindMenus()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.Repo.Category.AllCategories()
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.Repo.Menu.FindSellableProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	board := model.NewMenuBoard(menus, categories)
+	current := &model.CurrentMenu{At: at, Menus: board.Open(at), Products: []model.Product{}}
+	for _, product := range products {
+		if board.OnSale(product, at) {
+			product.SetStockStatus(s.lowStock)
+			current.Products = append(current.Products, product)
+		}
+	}
+	return current, nil
+}
+
+func (s *serviceMenu) EightySix(productID uint, eightySixed bool) error {
+	if err := s.Repo.Menu.SetEightySixed(productID, eightySixed); err != nil {
+		return err
+	}
+	s.Log.Info("Product 86 status changed", zap.Uint("product", productID), zap.Bool("eightySixed", eightySixed))
+	return nil
+}
+
+// ClearEightySixed puts everything 86'd back on sale for the next service.
+func (s *serviceMenu) ClearEightySixed() (int64, error) {
+	return s.Repo.Menu.ClearEightySixed()
+}
+
+func menuFromForm(form model.FormMenu) model.Menu {
+	menu := model.Menu{
+		Name:        strings.TrimSpace(form.Name),
+		Active:      form.Active == nil || *form.Active,
+		Schedules:   []model.MenuSchedule{},
+		CategoryIDs: unique(form.CategoryIDs),
+		ProductIDs:  unique(form.ProductIDs),
+	}
+	for _, schedule := range form.Schedules {
+		days := []string{}
+		for _, day := range schedule.Days {
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+		menu.Schedules = append(menu.Schedules, model.MenuSchedule{Days: days, Start: schedule.Start, End: schedule.End})
+	}
+	return menu
+}
+
+func unique(ids []uint) []uint {
+	out := []uint{}
+	for _, id := range ids {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package menuservice_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	menuservice "project_pos_app/service/menu_service"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCurrent(t *testing.T) {
+	// Breakfast runs 06:00-11:00 every day and holds the Breakfast
+	// category (1); Late Night runs 22:00-02:00 on Fridays and Saturdays
+	// and holds product 12. Coffee (3) is a subcategory of Breakfast.
+	expectBoard := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "menus" ORDER BY name,id`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "active"}).
+				AddRow(1, "Breakfast", true).
+				AddRow(2, "Late Night", true))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "menu_schedules" WHERE "menu_schedules"."menu_id" IN ($1,$2)`)).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "menu_id", "days", "start", "end"}).
+				AddRow(1, 1, `[]`, "06:00", "11:00").
+				AddRow(2, 2, `["fri","sat"]`, "22:00", "02:00"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "menu_categories" WHERE menu_id IN ($1,$2)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"menu_id", "category_id"}).AddRow(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "menu_products" WHERE menu_id IN ($1,$2)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"menu_id", "product_id"}).AddRow(2, 12))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT categories.*, COUNT(products.id) AS product_count FROM "categories"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "name"}).
+				AddRow(1, nil, "Breakfast").
+				AddRow(3, 1, "Coffee").
+				AddRow(4, nil, "Drinks"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE deleted_at IS NULL AND eighty_sixed = $1 AND LOWER(status) IN ($2,$3)`)).
+			WithArgs(false, "available", "active").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_id", "status"}).
+				AddRow(10, "Pancakes", 1, "Active").
+				AddRow(11, "Latte", 3, "Active").
+				AddRow(12, "Nachos", 4, "Active").
+				AddRow(13, "Water", 4, "Active"))
+	}
+
+	names := func(t *testing.T, at time.Time) []string {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := menuservice.NewMenuService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 10)
+		expectBoard(mock)
+
+		current, err := service.Current(at)
+		assert.NoError(t, err)
+		products := []string{}
+		for _, product := range current.Products {
+			products = append(products, product.Name)
+		}
+		return products
+	}
+
+	t.Run("Breakfast on a Tuesday morning", func(t *testing.T) {
+		at := time.Date(2024, time.December, 17, 8, 30, 0, 0, time.Local)
+		assert.Equal(t, []string{"Pancakes", "Latte", "Water"}, names(t, at))
+	})
+
+	t.Run("Late night menu runs past midnight into Saturday", func(t *testing.T) {
+		at := time.Date(2024, time.December, 21, 1, 15, 0, 0, time.Local)
+		assert.Equal(t, []string{"Nachos", "Water"}, names(t, at))
+	})
+
+	t.Run("Late night menu is not on after a Sunday", func(t *testing.T) {
+		at := time.Date(2024, time.December, 23, 1, 15, 0, 0, time.Local)
+		assert.Equal(t, []string{"Water"}, names(t, at))
+	})
+}
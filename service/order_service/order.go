@@ -1,12 +1,22 @@
 package orderservice
 
 import (
+	"errors"
+	"fmt"
+	"project_pos_app/barcode"
 	"project_pos_app/model"
 	"project_pos_app/repository"
+	"slices"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+var (
+	ErrItemUnavailable    = errors.New(" Item Not Available")
+	ErrOverrideNotAllowed = errors.New(" Only Managers Can Override The Menu")
+)
+
 type OrderService interface {
 	GetAllOrder(search, status string) ([]*model.OrderResponse, error)
 	CreateOrder(order *model.Order) error
@@ -19,10 +29,13 @@ type OrderService interface {
 type orderService struct {
 	Repo *repository.AllRepository
 	Log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
 }
 
-func NewOrderService(Repo *repository.AllRepository, Log *zap.Logger) OrderService {
-	return &orderService{Repo, Log}
+func NewOrderService(Repo *repository.AllRepository, Log *zap.Logger, lowStock int) OrderService {
+	return &orderService{Repo, Log, lowStock}
 }
 
 func (os *orderService) GetAllOrder(search, status string) ([]*model.OrderResponse, error) {
@@ -39,26 +52,64 @@ func (os *orderService) CreateOrder(order *model.Order) error {
 
 	order.Tax = 12
 	order.Status = "In Process"
+	order.ReservationID = 0
+	order.Credit = 0
+	order.ServedBy = 0
+
+	if err := os.resolveBarcodes(order); err != nil {
+		return err
+	}
+
+	if err := os.checkMenu(order, nil); err != nil {
+		return err
+	}
+
+	if err := os.linkCustomer(order); err != nil {
+		return err
+	}
 
 	if err := os.Repo.Order.CreateOrder(order); err != nil {
 		return err
 	}
 
+	os.evaluateStock(order.ID)
 	return nil
 }
 
 func (os *orderService) UpdateOrder(id int, order *model.Order) error {
 
 	order.Tax = 12
+	order.ReservationID = 0
+	order.Credit = 0
+	order.CustomerID = 0
+	order.Discount = 0
+	order.PointsEarned = 0
+	order.PointsRedeemed = 0
+	order.DrawerSessionID = 0
+	order.CreatedBy = 0
+	order.ServedBy = 0
 
 	if order.PaymentMethod != 0 && order.Status != "cancelled" {
 		order.Status = "completed"
 	}
 
+	if order.RedeemPoints < 0 || (order.RedeemPoints > 0 && order.Status != "completed") {
+		return errors.New(" Points Can Only Be Redeemed At Payment")
+	}
+
+	onOrder, err := os.Repo.Order.FindOrderProductIDs(id)
+	if err != nil {
+		return err
+	}
+	if err := os.checkMenu(order, onOrder); err != nil {
+		return err
+	}
+
 	if err := os.Repo.Order.UpdateOrder(id, order); err != nil {
 		return err
 	}
 
+	os.evaluateStock(uint(id))
 	return nil
 }
 
@@ -70,3 +121,138 @@ func (os *orderService) DeleteOrder(id int) error {
 
 	return nil
 }
+
+// evaluateStock raises or clears the stock alerts of the products and
+// ingredients an order moved. The order is saved by then, so a failure is
+// only logged; the nightly check catches up.
+func (os *orderService) evaluateStock(orderID uint) {
+	productIDs, err := os.Repo.Stock.MovedProducts(orderID)
+	if err == nil && len(productIDs) > 0 {
+		_, err = os.Repo.Stock.EvaluateAlerts(productIDs, os.lowStock)
+	}
+	if err != nil {
+		os.Log.Error("Failed to evaluate stock alerts", zap.Uint("order", orderID), zap.Error(err))
+	}
+
+	ingredientIDs, err := os.Repo.Ingredient.MovedIngredients(orderID)
+	if err == nil && len(ingredientIDs) > 0 {
+		_, err = os.Repo.Ingredient.EvaluateAlerts(ingredientIDs)
+	}
+	if err != nil {
+		os.Log.Error("Failed to evaluate ingredient alerts", zap.Uint("order", orderID), zap.Error(err))
+	}
+}
+
+// resolveBarcodes turns the scanned lines of an order into product lines,
+// one of the item each unless a qty is given. Scanning an item again adds
+// to its line rather than starting another.
+func (os *orderService) resolveBarcodes(order *model.Order) error {
+	lines := make([]model.OrderProduct, 0, len(order.OrderProducts))
+	for _, op := range order.OrderProducts {
+		if op.ProductID == 0 && op.Barcode != "" {
+			product, err := os.productByBarcode(op.Barcode)
+			if err != nil {
+				return err
+			}
+			op.ProductID = product.ID
+			if op.Qty == 0 {
+				op.Qty = 1
+			}
+		}
+		op.Barcode = ""
+
+		i := slices.IndexFunc(lines, func(line model.OrderProduct) bool { return line.ProductID == op.ProductID })
+		if i >= 0 {
+			lines[i].Qty += op.Qty
+			continue
+		}
+		lines = append(lines, op)
+	}
+	order.OrderProducts = lines
+	return nil
+}
+
+func (os *orderService) productByBarcode(code string) (*model.Product, error) {
+	normalized, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, code)
+	}
+	product, err := os.Repo.Product.FindByBarcode(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, code)
+	}
+	return product, nil
+}
+
+// checkMenu rejects items that are 86'd or outside the schedules of
+// their menus, other than those in onOrder, already on the order being
+// edited. A manager can override it to sell them anyway.
+func (os *orderService) checkMenu(order *model.Order, onOrder []uint) error {
+	if order.OverrideMenu {
+		if !model.IsManager(order.ActorRole) {
+			return ErrOverrideNotAllowed
+		}
+		os.Log.Info("Menu overridden for order", zap.Uint("actor", order.ActorID), zap.String("customer", order.CustomerName))
+		return nil
+	}
+	ids := []uint{}
+	for _, op := range order.OrderProducts {
+		if !slices.Contains(onOrder, op.ProductID) {
+			ids = append(ids, op.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	products, err := os.Repo.Menu.FindProducts(ids)
+	if err != nil {
+		return err
+	}
+	menus, err := os.Repo.Menu.FindMenus()
+	if err != nil {
+		return err
+	}
+	categories, err := os.Repo.Category.AllCategories()
+	if err != nil {
+		return err
+	}
+
+	board := model.NewMenuBoard(menus, categories)
+	now := time.Now()
+	for _, product := range products {
+		if product.EightySixed {
+			return fmt.Errorf("%w: %s is 86'd", ErrItemUnavailable, product.Name)
+		}
+		if !board.OnSale(product, now) {
+			return fmt.Errorf("%w: %s is not on the current menu", ErrItemUnavailable, product.Name)
+		}
+	}
+	return nil
+}
+
+// linkCustomer attaches the order to a customer: the one picked by
+// customer_id, or the one matching customer_phone or customer_email, created
+// when there is none yet. Orders without any of these stay anonymous.
+func (os *orderService) linkCustomer(order *model.Order) error {
+	if order.CustomerID != 0 {
+		customer := model.Customer{ID: order.CustomerID}
+		if err := os.Repo.Customer.FindCustomer(&customer); err != nil {
+			return err
+		}
+		if order.CustomerName == "" {
+			order.CustomerName = customer.Name
+		}
+		return nil
+	}
+
+	customer := model.Customer{Name: order.CustomerName, PhoneNumber: order.CustomerPhone, Email: order.CustomerEmail}
+	customer.Normalize()
+	if !customer.Identifiable() {
+		return nil
+	}
+	if err := os.Repo.Customer.FindOrCreate(&customer); err != nil {
+		return err
+	}
+	order.CustomerID = customer.ID
+	return nil
+}
@@ -0,0 +1,68 @@
+package orderservice_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	orderservice "project_pos_app/service/order_service"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestUpdateOrderChecksMenu(t *testing.T) {
+	onOrderQuery := `SELECT DISTINCT "product_id" FROM "order_products" WHERE order_id = $1`
+
+	// Product 1 is already on order 7; product 2 is 86'd.
+	newOrder := func() *model.Order {
+		return &model.Order{
+			TableID:      1,
+			CustomerName: "John Doe",
+			ActorRole:    "cashier",
+			OrderProducts: []model.OrderProduct{
+				{ProductID: 1, Qty: 2},
+				{ProductID: 2, Qty: 1},
+			},
+		}
+	}
+
+	t.Run("Items added to an order are checked against the menu", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := orderservice.NewOrderService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 10)
+
+		mock.ExpectQuery(regexp.QuoteMeta(onOrderQuery)).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE id IN ($1)`)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "eighty_sixed"}).AddRow(2, "Soup", true))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "menus"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "active"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT categories.*`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		err := service.UpdateOrder(7, newOrder())
+
+		assert.ErrorIs(t, err, orderservice.ErrItemUnavailable)
+	})
+
+	t.Run("Only managers can override the menu when editing", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := orderservice.NewOrderService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 10)
+
+		mock.ExpectQuery(regexp.QuoteMeta(onOrderQuery)).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow(1))
+
+		order := newOrder()
+		order.OverrideMenu = true
+		err := service.UpdateOrder(7, order)
+
+		assert.ErrorIs(t, err, orderservice.ErrOverrideNotAllowed)
+	})
+}
@@ -0,0 +1,76 @@
+package productservice
+
+import (
+	"project_pos_app/barcode"
+	"project_pos_app/model"
+	"slices"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// LookupBarcode finds the product a scanned EAN-13 or UPC-A code is on.
+func (ps *productService) LookupBarcode(code string) (*model.Product, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	product, err := ps.repo.Product.FindByBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+	product.SetStockStatus(ps.lowStock)
+	return product, nil
+}
+
+func (ps *productService) AddBarcode(productID uint, code string) (*model.ProductBarcode, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	productBarcode, err := ps.repo.Product.AddBarcode(productID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.log.Info("Barcode added", zap.Uint("productID", productID), zap.String("code", code))
+	return productBarcode, nil
+}
+
+func (ps *productService) RemoveBarcode(productID uint, code string) error {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return err
+	}
+	return ps.repo.Product.RemoveBarcode(productID, code)
+}
+
+// BarcodeLabels lays out copies of a label for each product picked, with
+// its first barcode. Products without one are given an in-store code.
+func (ps *productService) BarcodeLabels(form model.FormBarcodeLabels) ([]barcode.Label, error) {
+	ids := []uint{}
+	for _, id := range form.ProductIDs {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	copies := max(form.Copies, 1)
+
+	products, err := ps.repo.Product.LabelProducts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []barcode.Label{}
+	for _, product := range products {
+		label := barcode.Label{
+			Name:  product.Name,
+			Code:  product.Barcodes[0].Code,
+			Price: strconv.FormatFloat(product.Price, 'f', 2, 64),
+		}
+		for i := 0; i < copies; i++ {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,195 @@
+package productservice
+
+import (
+	"errors"
+	"fmt"
+	"project_pos_app/barcode"
+	"project_pos_app/model"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ImportProducts reads products from the rows of an import file, a header
+// row naming model.ProductColumns in any order, and upserts them by id or
+// item_id. Rows that cannot be parsed are reported with the rest; nothing
+// is written unless every row is valid and dryRun is false.
+func (ps *productService) ImportProducts(table [][]string, dryRun bool, actorID uint) (*model.ProductImportResult, error) {
+	if len(table) == 0 {
+		return nil, errors.New(" Invalid Import File")
+	}
+
+	columns := map[string]int{}
+	for i, name := range table[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	result := &model.ProductImportResult{DryRun: dryRun, Errors: []model.ProductImportError{}}
+	for _, column := range []string{"name", "category", "price"} {
+		_, ok := columns[column]
+		if !ok && column == "category" {
+			_, ok = columns["category_id"]
+		}
+		if !ok {
+			result.Errors = append(result.Errors, model.ProductImportError{Row: 1, Column: column, Message: "missing column"})
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	rows := []model.ProductImportRow{}
+	seen := map[string]int{}
+	seenIDs := map[uint]int{}
+	for i, record := range table[1:] {
+		row, rowErrors := parseImportRow(i+2, record, columns)
+		if row.Given == nil {
+			continue
+		}
+		if first, ok := seen[row.Product.ItemID]; ok && row.Product.ItemID != "" {
+			rowErrors = append(rowErrors, model.ProductImportError{Row: row.Row, Column: "item_id", Message: fmt.Sprintf("item_id is also on row %d", first)})
+		}
+		seen[row.Product.ItemID] = row.Row
+		if first, ok := seenIDs[row.Product.ID]; ok && row.Given["id"] {
+			rowErrors = append(rowErrors, model.ProductImportError{Row: row.Row, Column: "id", Message: fmt.Sprintf("id is also on row %d", first)})
+		}
+		if row.Given["id"] {
+			seenIDs[row.Product.ID] = row.Row
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	imported, err := ps.repo.Product.ImportProducts(rows, dryRun || len(result.Errors) > 0, actorID)
+	if err != nil {
+		return nil, err
+	}
+	// Rows that failed to parse never reached the repository; when there
+	// are any, it only checked the others.
+	imported.DryRun = dryRun
+	imported.Rows += countRows(result.Errors)
+	imported.Errors = append(result.Errors, imported.Errors...)
+	if len(imported.Errors) > 0 {
+		ps.log.Info("Product import has errors", zap.Int("errors", len(imported.Errors)))
+	}
+	return imported, nil
+}
+
+// ExportProducts lays out every product as rows that ImportProducts reads
+// back unchanged: each row carries the product's id and category_id, so
+// neither a missing item_id nor a repeated category name gets in the way.
+func (ps *productService) ExportProducts() ([][]string, error) {
+	products, categories, err := ps.repo.Product.ExportProducts()
+	if err != nil {
+		return nil, err
+	}
+	table := [][]string{model.ProductColumns}
+	for _, product := range products {
+		codes := make([]string, 0, len(product.Barcodes))
+		for _, productBarcode := range product.Barcodes {
+			codes = append(codes, productBarcode.Code)
+		}
+		table = append(table, []string{
+			strconv.FormatUint(uint64(product.ID), 10),
+			product.ItemID,
+			product.Name,
+			strconv.FormatUint(uint64(product.CategoryID), 10),
+			categories[product.CategoryID],
+			strconv.FormatFloat(product.Price, 'f', -1, 64),
+			strconv.FormatFloat(product.CostPrice, 'f', -1, 64),
+			strconv.Itoa(product.Qty),
+			product.Status,
+			strconv.FormatUint(uint64(product.SupplierID), 10),
+			strconv.Itoa(product.ReorderQty),
+			strconv.Itoa(product.ReorderPoint),
+			product.ImageURL,
+			strings.Join(codes, " "),
+		})
+	}
+	return table, nil
+}
+
+// parseImportRow reads one record. A record with no values at all is
+// skipped, which it reports by leaving Given nil.
+func parseImportRow(line int, record []string, columns map[string]int) (model.ProductImportRow, []model.ProductImportError) {
+	row := model.ProductImportRow{Row: line}
+	rowErrors := []model.ProductImportError{}
+	given := map[string]bool{}
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		v := strings.TrimSpace(record[i])
+		if v != "" {
+			given[column] = true
+		}
+		return v
+	}
+	fail := func(column, message string) {
+		rowErrors = append(rowErrors, model.ProductImportError{Row: line, Column: column, Message: message})
+	}
+	number := func(column string) int {
+		v := value(column)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fail(column, column+" must be a whole number of at least 0")
+		}
+		return n
+	}
+	amount := func(column string) float64 {
+		v := value(column)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			fail(column, column+" must be a number of at least 0")
+		}
+		return n
+	}
+
+	row.Product = model.Product{
+		ID:           uint(number("id")),
+		ItemID:       value("item_id"),
+		Name:         value("name"),
+		CategoryID:   uint(number("category_id")),
+		Price:        amount("price"),
+		CostPrice:    amount("cost_price"),
+		Qty:          number("qty"),
+		Status:       value("status"),
+		SupplierID:   uint(number("supplier_id")),
+		ReorderQty:   number("reorder_qty"),
+		ReorderPoint: number("reorder_point"),
+		ImageURL:     value("image_url"),
+	}
+	row.Category = value("category")
+	for _, code := range strings.Fields(value("barcodes")) {
+		normalized, err := barcode.Normalize(code)
+		if err != nil {
+			fail("barcodes", fmt.Sprintf("%s is not a valid barcode", code))
+			continue
+		}
+		row.Barcodes = append(row.Barcodes, normalized)
+	}
+	if len(given) == 0 {
+		return row, nil
+	}
+	row.Given = given
+	return row, rowErrors
+}
+
+// countRows counts the distinct rows with errors.
+func countRows(rowErrors []model.ProductImportError) int {
+	rows := map[int]bool{}
+	for _, rowError := range rowErrors {
+		rows[rowError.Row] = true
+	}
+	return len(rows)
+}
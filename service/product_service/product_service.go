@@ -1,37 +1,67 @@
 package productservice
 
 import (
+	"errors"
+	"project_pos_app/barcode"
 	"project_pos_app/model"
 	"project_pos_app/repository"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type ProductService interface {
-	ShowAllProduct(page, limit int) (*[]model.Product, int, int, error)
+	ShowAllProduct(filter model.ProductFilter) (*[]model.Product, int, int, error)
 	GetProductByID(id int) (*model.Product, error)
-	CreateProduct(product *model.Product) error
+	CreateProduct(product *model.Product, actorID uint) error
 	DeleteProduct(id int) error
+	TrashProducts(page, limit int) ([]model.Product, int, int, error)
+	RestoreProduct(id uint) error
+	PurgeProducts() ([]uint, error)
 	UpdateProduct(productID uint, product *model.Product) error
+	StockHistory(productID uint, page, limit int) ([]model.StockMovement, int, int, error)
+	RecordMovement(productID uint, form model.FormStockMovement, actorID uint) (*model.StockMovement, error)
+	EvaluateStockAlerts(productIDs ...uint) ([]model.Notification, error)
+	ImportProducts(table [][]string, dryRun bool, actorID uint) (*model.ProductImportResult, error)
+	ExportProducts() ([][]string, error)
+	LookupBarcode(code string) (*model.Product, error)
+	AddBarcode(productID uint, code string) (*model.ProductBarcode, error)
+	RemoveBarcode(productID uint, code string) error
+	BarcodeLabels(form model.FormBarcodeLabels) ([]barcode.Label, error)
 }
 
+var (
+	ErrRecipeStock = errors.New(" Product Stock Comes From Its Recipe")
+	ErrBundleStock = errors.New(" Bundle Stock Comes From Its Components")
+)
+
 type productService struct {
 	repo *repository.AllRepository
 	log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
+	// purgeDays is how long a product stays in the trash before it can be
+	// purged.
+	purgeDays int
 }
 
-func NewProductService(repo *repository.AllRepository, log *zap.Logger) ProductService {
-	return &productService{repo: repo, log: log}
+func NewProductService(repo *repository.AllRepository, log *zap.Logger, lowStock, purgeDays int) ProductService {
+	return &productService{repo: repo, log: log, lowStock: lowStock, purgeDays: purgeDays}
 }
 
-func (ps *productService) ShowAllProduct(page, limit int) (*[]model.Product, int, int, error) {
-	ps.log.Info("Fetching all products", zap.Int("page", page), zap.Int("limit", limit))
+func (ps *productService) ShowAllProduct(filter model.ProductFilter) (*[]model.Product, int, int, error) {
+	ps.log.Info("Fetching all products", zap.Int("page", filter.Page), zap.Int("limit", filter.Limit))
 
-	products, count, totalPages, err := ps.repo.Product.ShowAllProducts(page, limit)
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.LowStock = ps.lowStock
+	products, count, totalPages, err := ps.repo.Product.ShowAllProducts(filter)
 	if err != nil {
 		ps.log.Error("Error fetching products", zap.Error(err))
 		return nil, 0, 0, err
 	}
+	model.SetStockStatuses(*products, ps.lowStock)
 
 	ps.log.Info("Successfully fetched products", zap.Int("count", count), zap.Int("totalPages", totalPages))
 	return products, count, totalPages, nil
@@ -45,19 +75,21 @@ func (ps *productService) GetProductByID(id int) (*model.Product, error) {
 		ps.log.Error("Error fetching product", zap.Error(err))
 		return nil, err
 	}
+	product.SetStockStatus(ps.lowStock)
 
 	ps.log.Info("Successfully fetched product", zap.Int("id", id))
 	return product, nil
 }
 
-func (ps *productService) CreateProduct(product *model.Product) error {
+func (ps *productService) CreateProduct(product *model.Product, actorID uint) error {
 	ps.log.Info("Creating product", zap.String("name", product.Name))
 
-	err := ps.repo.Product.CreateProduct(product)
+	err := ps.repo.Product.CreateProduct(product, actorID)
 	if err != nil {
 		ps.log.Error("Error creating product", zap.Error(err))
 		return err
 	}
+	product.SetStockStatus(ps.lowStock)
 
 	ps.log.Info("Successfully created product", zap.String("name", product.Name))
 	return nil
@@ -66,7 +98,7 @@ func (ps *productService) CreateProduct(product *model.Product) error {
 func (ps *productService) DeleteProduct(id int) error {
 	ps.log.Info("Deleting product", zap.Int("id", id))
 
-	err := ps.repo.Notif.Delete(id)
+	err := ps.repo.Product.DeleteProduct(uint(id))
 	if err != nil {
 		ps.log.Error("Error deleting product", zap.Error(err))
 		return err
@@ -76,6 +108,34 @@ func (ps *productService) DeleteProduct(id int) error {
 	return nil
 }
 
+func (ps *productService) TrashProducts(page, limit int) ([]model.Product, int, int, error) {
+	products, total, totalPages, err := ps.repo.Product.TrashProducts(page, limit)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	model.SetStockStatuses(products, ps.lowStock)
+	return products, total, totalPages, nil
+}
+
+func (ps *productService) RestoreProduct(id uint) error {
+	if err := ps.repo.Product.RestoreProduct(id); err != nil {
+		ps.log.Error("Error restoring product", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+
+	// It may have run low while it was deleted.
+	if _, err := ps.EvaluateStockAlerts(id); err != nil {
+		ps.log.Error("Error evaluating stock alerts", zap.Uint("productID", id), zap.Error(err))
+	}
+	return nil
+}
+
+// PurgeProducts permanently removes products that have been deleted for
+// purgeDays and were never sold, bought or counted.
+func (ps *productService) PurgeProducts() ([]uint, error) {
+	return ps.repo.Product.PurgeProducts(time.Now().AddDate(0, 0, -ps.purgeDays))
+}
+
 func (ps *productService) UpdateProduct(productID uint, product *model.Product) error {
 	ps.log.Info("Updating product", zap.Uint("productID", productID), zap.String("name", product.Name))
 
@@ -84,6 +144,73 @@ func (ps *productService) UpdateProduct(productID uint, product *model.Product)
 		return err
 	}
 
+	// The reorder point may have moved.
+	if _, err := ps.EvaluateStockAlerts(productID); err != nil {
+		ps.log.Error("Error evaluating stock alerts", zap.Uint("productID", productID), zap.Error(err))
+	}
+
 	ps.log.Info("Successfully updated product", zap.Uint("productID", productID))
 	return nil
 }
+
+func (ps *productService) StockHistory(productID uint, page, limit int) ([]model.StockMovement, int, int, error) {
+	return ps.repo.Stock.FindHistory(productID, page, limit)
+}
+
+// RecordMovement posts a stock change made by hand. Restocks always add and
+// waste always removes, whatever the sign given; adjustments and transfers
+// keep theirs.
+func (ps *productService) RecordMovement(productID uint, form model.FormStockMovement, actorID uint) (*model.StockMovement, error) {
+	qty := form.Qty
+	switch form.Type {
+	case model.MovementRestock:
+		qty = abs(qty)
+	case model.MovementWaste:
+		qty = -abs(qty)
+	}
+
+	made, err := ps.repo.Ingredient.HasRecipe(productID)
+	if err != nil {
+		return nil, err
+	}
+	if made {
+		return nil, ErrRecipeStock
+	}
+	bundle, err := ps.repo.Bundle.HasBundle(productID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle {
+		return nil, ErrBundleStock
+	}
+
+	movement := model.StockMovement{
+		ProductID: productID,
+		Type:      form.Type,
+		Qty:       qty,
+		Reason:    strings.TrimSpace(form.Reason),
+		ActorID:   actorID,
+	}
+	if err := ps.repo.Stock.Record(&movement); err != nil {
+		ps.log.Error("Error recording stock movement", zap.Uint("productID", productID), zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := ps.EvaluateStockAlerts(productID); err != nil {
+		ps.log.Error("Error evaluating stock alerts", zap.Uint("productID", productID), zap.Error(err))
+	}
+	return &movement, nil
+}
+
+// EvaluateStockAlerts checks the given products, or all of them, against
+// their reorder points, returning the notifications it sent.
+func (ps *productService) EvaluateStockAlerts(productIDs ...uint) ([]model.Notification, error) {
+	return ps.repo.Stock.EvaluateAlerts(productIDs, ps.lowStock)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
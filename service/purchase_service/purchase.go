@@ -0,0 +1,121 @@
+package purchaseservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+
+	"go.uber.org/zap"
+)
+
+type ServicePurchase interface {
+	GetAll(filter model.PurchaseFilter) ([]model.PurchaseOrder, int, int, error)
+	Get(id uint) (*model.PurchaseOrder, error)
+	Create(form model.FormPurchaseOrder, actorID uint) (*model.PurchaseOrder, error)
+	Update(id uint, form model.FormPurchaseOrder) (*model.PurchaseOrder, error)
+	Submit(id uint) (*model.PurchaseOrder, error)
+	Cancel(id uint) (*model.PurchaseOrder, error)
+	Receive(id uint, form model.FormGoodsReceipt, actorID uint) (*model.PurchaseOrder, error)
+	DraftLowStock() ([]model.PurchaseOrder, error)
+}
+
+type servicePurchase struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
+	// autoDraft turns on drafting purchase orders for low stock.
+	autoDraft bool
+}
+
+func NewPurchaseService(repo *repository.AllRepository, log *zap.Logger, lowStock int, autoDraft bool) ServicePurchase {
+	return &servicePurchase{
+		Repo:      repo,
+		Log:       log,
+		lowStock:  lowStock,
+		autoDraft: autoDraft,
+	}
+}
+
+func (s *servicePurchase) GetAll(filter model.PurchaseFilter) ([]model.PurchaseOrder, int, int, error) {
+	return s.Repo.Purchase.FindOrders(filter)
+}
+
+func (s *servicePurchase) Get(id uint) (*model.PurchaseOrder, error) {
+	return s.Repo.Purchase.FindOrder(id)
+}
+
+func (s *servicePurchase) Create(form model.FormPurchaseOrder, actorID uint) (*model.PurchaseOrder, error) {
+	order := orderFromForm(form)
+	order.Status = model.PurchaseDraft
+	order.CreatedBy = actorID
+	if err := s.Repo.Purchase.CreateOrder(&order); err != nil {
+		return nil, err
+	}
+	return s.Repo.Purchase.FindOrder(order.ID)
+}
+
+func (s *servicePurchase) Update(id uint, form model.FormPurchaseOrder) (*model.PurchaseOrder, error) {
+	order := orderFromForm(form)
+	order.ID = id
+	if err := s.Repo.Purchase.UpdateOrder(&order); err != nil {
+		return nil, err
+	}
+	return s.Repo.Purchase.FindOrder(id)
+}
+
+// Submit marks a draft as sent to the supplier; only then can it be
+// received.
+func (s *servicePurchase) Submit(id uint) (*model.PurchaseOrder, error) {
+	return s.Repo.Purchase.Transition(id, []string{model.PurchaseDraft}, model.PurchaseOrdered)
+}
+
+// Cancel closes a purchase order that is not fully received. Goods already
+// received on a partial order stay in stock.
+func (s *servicePurchase) Cancel(id uint) (*model.PurchaseOrder, error) {
+	return s.Repo.Purchase.Transition(id, model.PurchaseOpen, model.PurchaseCanceled)
+}
+
+func (s *servicePurchase) Receive(id uint, form model.FormGoodsReceipt, actorID uint) (*model.PurchaseOrder, error) {
+	order, err := s.Repo.Purchase.Receive(id, form, actorID)
+	if err != nil {
+		return nil, err
+	}
+	s.Log.Info("Received goods", zap.Uint("purchaseOrder", id), zap.String("status", order.Status))
+
+	productIDs := []uint{}
+	for _, line := range order.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	if _, err := s.Repo.Stock.EvaluateAlerts(productIDs, s.lowStock); err != nil {
+		s.Log.Error("Failed to evaluate stock alerts", zap.Uint("purchaseOrder", id), zap.Error(err))
+	}
+	return order, nil
+}
+
+// DraftLowStock drafts purchase orders for products below their reorder
+// point, or the low-stock level without one, with a preferred supplier,
+// unless auto drafting is turned off.
+func (s *servicePurchase) DraftLowStock() ([]model.PurchaseOrder, error) {
+	if !s.autoDraft {
+		return []model.PurchaseOrder{}, nil
+	}
+	return s.Repo.Purchase.DraftLowStock(s.lowStock)
+}
+
+func orderFromForm(form model.FormPurchaseOrder) model.PurchaseOrder {
+	order := model.PurchaseOrder{
+		SupplierID: form.SupplierID,
+		Notes:      form.Notes,
+		ExpectedAt: form.ExpectedAt,
+		Lines:      []model.PurchaseOrderLine{},
+	}
+	for _, line := range form.Lines {
+		order.Lines = append(order.Lines, model.PurchaseOrderLine{
+			ProductID: line.ProductID,
+			Qty:       line.Qty,
+			UnitCost:  line.UnitCost,
+		})
+	}
+	return order
+}
@@ -0,0 +1,223 @@
+package reservationservice
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxCombinationSize is the largest number of tables pushed together for one
+// party, and maxCombinations caps how many such suggestions are returned.
+const (
+	maxCombinationSize = 3
+	maxCombinations    = 5
+)
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+func (s *serviceReservation) GetAvailability(date string, pax, duration int) (*model.ReservationAvailability, error) {
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil || pax <= 0 {
+		s.Log.Error("Invalid availability query", zap.String("date", date), zap.Int("pax", pax))
+		return nil, errors.New(" Bad Request")
+	}
+	if duration <= 0 {
+		duration = model.DefaultReservationDuration(pax)
+	}
+	open, closing, err := s.openingHours(day)
+	if err != nil {
+		s.Log.Error("Invalid opening hours", zap.Error(err))
+		return nil, errors.New(" Internal Server Error")
+	}
+
+	tables, err := s.Repo.Reservation.FindTables()
+	if err != nil {
+		return nil, err
+	}
+	buffer := s.settings.Buffer
+	reservations, err := s.Repo.Reservation.FindHolding(open, closing, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	length := time.Duration(duration) * time.Minute
+	busy := map[uint][]interval{}
+	for _, v := range reservations {
+		busy[v.TableNumber] = append(busy[v.TableNumber], interval{v.ReservationDate.Add(-buffer), v.EndDate().Add(buffer)})
+	}
+
+	wallNow := model.WallClockNow()
+
+	free := map[uint][]interval{}
+	for _, table := range tables {
+		blocked := busy[table.ID]
+		if wallNow.After(open) && wallNow.Before(closing) {
+			blocked = append(blocked, interval{open, wallNow})
+			if table.IsBook {
+				seated := time.Duration(model.DefaultReservationDuration(table.Capacity)) * time.Minute
+				blocked = append(blocked, interval{wallNow, wallNow.Add(seated + buffer)})
+			}
+		} else if !wallNow.Before(closing) {
+			blocked = append(blocked, interval{open, closing})
+		}
+		free[table.ID] = freeWindows(interval{open, closing}, blocked, length)
+	}
+
+	result := &model.ReservationAvailability{
+		Date:         date,
+		Pax:          pax,
+		Duration:     duration,
+		Tables:       []model.TableAvailability{},
+		Combinations: []model.TableAvailability{},
+	}
+	fits := false
+	for _, table := range tables {
+		if table.Capacity < pax {
+			continue
+		}
+		result.Tables = append(result.Tables, model.TableAvailability{
+			TableIDs: []uint{table.ID},
+			Names:    []string{table.Name},
+			Capacity: table.Capacity,
+			Slots:    toSlots(free[table.ID]),
+		})
+		if len(free[table.ID]) > 0 {
+			fits = true
+		}
+	}
+	if !fits {
+		result.Combinations = combineTables(tables, free, pax, length)
+	}
+	return result, nil
+}
+
+// combineTables suggests groups of tables that are free at the same time and
+// together seat the party, smallest total capacity first.
+func combineTables(tables []model.Table, free map[uint][]interval, pax int, length time.Duration) []model.TableAvailability {
+	candidates := []model.Table{}
+	for _, table := range tables {
+		if len(free[table.ID]) > 0 {
+			candidates = append(candidates, table)
+		}
+	}
+
+	combinations := []model.TableAvailability{}
+	var walk func(start int, picked []model.Table, capacity int, windows []interval)
+	walk = func(start int, picked []model.Table, capacity int, windows []interval) {
+		if len(picked) > 1 && capacity >= pax {
+			combination := model.TableAvailability{Capacity: capacity, Slots: toSlots(windows)}
+			for _, table := range picked {
+				combination.TableIDs = append(combination.TableIDs, table.ID)
+				combination.Names = append(combination.Names, table.Name)
+			}
+			combinations = append(combinations, combination)
+			return
+		}
+		if len(picked) == maxCombinationSize {
+			return
+		}
+		for i := start; i < len(candidates); i++ {
+			next := free[candidates[i].ID]
+			if len(picked) > 0 {
+				next = intersectWindows(windows, next, length)
+				if len(next) == 0 {
+					continue
+				}
+			}
+			walk(i+1, append(picked[:len(picked):len(picked)], candidates[i]), capacity+candidates[i].Capacity, next)
+		}
+	}
+	walk(0, nil, 0, nil)
+
+	sort.SliceStable(combinations, func(i, j int) bool {
+		if combinations[i].Capacity != combinations[j].Capacity {
+			return combinations[i].Capacity < combinations[j].Capacity
+		}
+		return len(combinations[i].TableIDs) < len(combinations[j].TableIDs)
+	})
+	if len(combinations) > maxCombinations {
+		combinations = combinations[:maxCombinations]
+	}
+	return combinations
+}
+
+// freeWindows subtracts the blocked intervals from day and keeps the gaps
+// that are at least length long.
+func freeWindows(day interval, blocked []interval, length time.Duration) []interval {
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i].start.Before(blocked[j].start) })
+	windows := []interval{}
+	cursor := day.start
+	for _, b := range blocked {
+		if b.end.Before(cursor) || !b.start.Before(day.end) {
+			continue
+		}
+		if b.start.Sub(cursor) >= length {
+			windows = append(windows, interval{cursor, b.start})
+		}
+		if b.end.After(cursor) {
+			cursor = b.end
+		}
+	}
+	if day.end.Sub(cursor) >= length {
+		windows = append(windows, interval{cursor, day.end})
+	}
+	return windows
+}
+
+func intersectWindows(a, b []interval, length time.Duration) []interval {
+	windows := []interval{}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].start, a[i].end
+		if b[j].start.After(start) {
+			start = b[j].start
+		}
+		if b[j].end.Before(end) {
+			end = b[j].end
+		}
+		if end.Sub(start) >= length {
+			windows = append(windows, interval{start, end})
+		}
+		if a[i].end.Before(b[j].end) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return windows
+}
+
+func toSlots(windows []interval) []model.TimeSlot {
+	slots := []model.TimeSlot{}
+	for _, w := range windows {
+		slots = append(slots, model.TimeSlot{Start: w.start.Format("15:04"), End: w.end.Format("15:04")})
+	}
+	return slots
+}
+
+// openingHours returns the opening and closing hours for day. A closing
+// hour before the opening hour means the restaurant closes after midnight.
+func (s *serviceReservation) openingHours(day time.Time) (time.Time, time.Time, error) {
+	open, err := time.Parse("15:04", s.settings.OpeningHour)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	closing, err := time.Parse("15:04", s.settings.ClosingHour)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start := day.Add(time.Duration(open.Hour())*time.Hour + time.Duration(open.Minute())*time.Minute)
+	end := day.Add(time.Duration(closing.Hour())*time.Hour + time.Duration(closing.Minute())*time.Minute)
+	if !end.After(start) {
+		end = end.Add(24 * time.Hour)
+	}
+	return start, end, nil
+}
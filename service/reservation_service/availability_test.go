@@ -0,0 +1,171 @@
+package reservationservice_test
+
+import (
+	"project_pos_app/config"
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	reservationservice "project_pos_app/service/reservation_service"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGetAvailability(t *testing.T) {
+	// Open from 10:00 to 02:00 the next night, with 15 minutes kept free
+	// around each booking. The day is far enough ahead that nothing is
+	// blocked for having gone by already.
+	settings := config.Reservation{OpeningHour: "10:00", ClosingHour: "02:00", Buffer: 15 * time.Minute}
+
+	day := time.Date(2099, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	type booking struct {
+		table    uint
+		start    time.Time
+		duration int
+	}
+	slot := func(start, end string) model.TimeSlot { return model.TimeSlot{Start: start, End: end} }
+	twos := []model.Table{{ID: 1, Name: "T1", Capacity: 2}, {ID: 2, Name: "T2", Capacity: 2}, {ID: 3, Name: "T3", Capacity: 2}}
+
+	tests := []struct {
+		name         string
+		date         string
+		pax          int
+		tables       []model.Table
+		bookings     []booking
+		slots        map[uint][]model.TimeSlot
+		combinations [][]uint
+		capacities   []int
+	}{
+		{
+			name:   "A free table is open all day",
+			pax:    2,
+			tables: []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			slots:  map[uint][]model.TimeSlot{1: {slot("10:00", "02:00")}},
+		},
+		{
+			name:     "The buffer is kept on both sides of a booking",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(12, 0), 60}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("10:00", "11:45"), slot("13:15", "02:00")}},
+		},
+		{
+			name:     "A gap as long as the stay between buffers is offered",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(10, 0), 60}, {1, at(12, 30), 60}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("11:15", "12:15"), slot("13:45", "02:00")}},
+		},
+		{
+			name:     "A gap a minute short is not",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(10, 0), 60}, {1, at(12, 29), 60}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("13:44", "02:00")}},
+		},
+		{
+			name:     "Back-to-back bookings leave no gap between them",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(12, 0), 60}, {1, at(13, 0), 60}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("10:00", "11:45"), slot("14:15", "02:00")}},
+		},
+		{
+			name:     "A booking after midnight still holds the table",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(24, 30), 60}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("10:00", "00:15")}},
+		},
+		{
+			name:     "A booking from the night before holds the table into the day",
+			pax:      2,
+			tables:   []model.Table{{ID: 1, Name: "T1", Capacity: 4}},
+			bookings: []booking{{1, at(-2, 0), 750}},
+			slots:    map[uint][]model.TimeSlot{1: {slot("10:45", "02:00")}},
+		},
+		{
+			name:         "Tables too small on their own are combined",
+			pax:          4,
+			tables:       twos,
+			slots:        map[uint][]model.TimeSlot{},
+			combinations: [][]uint{{1, 2}, {1, 3}, {2, 3}},
+			capacities:   []int{4, 4, 4},
+		},
+		{
+			name:         "Combinations may seat more than the party, smallest first",
+			pax:          5,
+			tables:       []model.Table{{ID: 1, Name: "T1", Capacity: 4}, {ID: 2, Name: "T2", Capacity: 3}, {ID: 3, Name: "T3", Capacity: 2}},
+			slots:        map[uint][]model.TimeSlot{},
+			combinations: [][]uint{{2, 3}, {1, 3}, {1, 2}},
+			capacities:   []int{5, 6, 7},
+		},
+		{
+			name:         "A table booked all day is left out of combinations",
+			pax:          4,
+			tables:       twos,
+			bookings:     []booking{{1, at(9, 0), 1080}},
+			slots:        map[uint][]model.TimeSlot{},
+			combinations: [][]uint{{2, 3}},
+			capacities:   []int{4},
+		},
+		{
+			name:   "Nothing is offered on a day already over",
+			date:   "2020-01-01",
+			pax:    4,
+			tables: twos,
+			slots:  map[uint][]model.TimeSlot{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := helper.SetupTestDB()
+			defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+			service := reservationservice.NewRevenueService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), settings)
+
+			date := tt.date
+			if date == "" {
+				date = day.Format("2006-01-02")
+			}
+			tables := sqlmock.NewRows([]string{"id", "name", "capacity"})
+			for _, table := range tt.tables {
+				tables.AddRow(table.ID, table.Name, table.Capacity)
+			}
+			bookings := sqlmock.NewRows([]string{"id", "table_number", "reservation_date", "duration", "status"})
+			for i, b := range tt.bookings {
+				bookings.AddRow(i+1, b.table, b.start, b.duration, model.ReservationConfirmed)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tables"`)).WillReturnRows(tables)
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reservations" WHERE status NOT IN`)).WillReturnRows(bookings)
+
+			result, err := service.GetAvailability(date, tt.pax, 60)
+
+			assert.NoError(t, err)
+			for _, table := range result.Tables {
+				want := tt.slots[table.TableIDs[0]]
+				if want == nil {
+					want = []model.TimeSlot{}
+				}
+				assert.Equal(t, want, table.Slots, "table %d", table.TableIDs[0])
+			}
+			combinations, capacities := [][]uint{}, []int{}
+			for _, combination := range result.Combinations {
+				combinations = append(combinations, combination.TableIDs)
+				capacities = append(capacities, combination.Capacity)
+			}
+			if tt.combinations == nil {
+				tt.combinations, tt.capacities = [][]uint{}, []int{}
+			}
+			assert.Equal(t, tt.combinations, combinations)
+			assert.Equal(t, tt.capacities, capacities)
+		})
+	}
+}
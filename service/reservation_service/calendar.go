@@ -0,0 +1,108 @@
+package reservationservice
+
+import (
+	"fmt"
+	"project_pos_app/model"
+	"strings"
+	"time"
+)
+
+// calendarDays is the range exported when no dates are given.
+const calendarDays = 30
+
+// ExportCalendar renders the reservations matching the filter as an
+// iCalendar (RFC 5545) document. Reservation times are wall-clock times, so
+// they are written as floating times and land at the same hour in any
+// calendar app.
+func (s *serviceReservation) ExportCalendar(filter *model.ReservationFilter) ([]byte, error) {
+	if filter.StartDate == "" && filter.EndDate == "" {
+		now := time.Now()
+		filter.StartDate = now.Format("2006-01-02")
+		filter.EndDate = now.AddDate(0, 0, calendarDays).Format("2006-01-02")
+	}
+	if err := s.normalizeFilter(filter); err != nil {
+		return nil, err
+	}
+	filter.Page, filter.Limit = 1, 0
+	reservations, _, _, err := s.Repo.Reservation.SearchReservations(*filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	writeCalendarLine(&b, "BEGIN:VCALENDAR")
+	writeCalendarLine(&b, "VERSION:2.0")
+	writeCalendarLine(&b, "PRODID:-//project_pos_app//Reservations//EN")
+	writeCalendarLine(&b, "CALSCALE:GREGORIAN")
+	writeCalendarLine(&b, "METHOD:PUBLISH")
+	writeCalendarLine(&b, "X-WR-CALNAME:Reservations")
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, reservation := range reservations {
+		writeCalendarLine(&b, "BEGIN:VEVENT")
+		writeCalendarLine(&b, fmt.Sprintf("UID:reservation-%d@project_pos_app", reservation.ID))
+		writeCalendarLine(&b, "DTSTAMP:"+stamp)
+		writeCalendarLine(&b, "DTSTART:"+reservation.ReservationDate.Format("20060102T150405"))
+		writeCalendarLine(&b, "DTEND:"+reservation.EndDate().Format("20060102T150405"))
+		writeCalendarLine(&b, "SUMMARY:"+escapeCalendarText(calendarSummary(reservation)))
+		writeCalendarLine(&b, "DESCRIPTION:"+escapeCalendarText(calendarDescription(reservation)))
+		writeCalendarLine(&b, fmt.Sprintf("LOCATION:Table %d", reservation.TableNumber))
+		writeCalendarLine(&b, "STATUS:"+calendarStatus(reservation.Status))
+		writeCalendarLine(&b, "END:VEVENT")
+	}
+	writeCalendarLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+func calendarSummary(reservation model.Reservation) string {
+	name := strings.TrimSpace(reservation.Title + " " + reservation.Fullname)
+	return fmt.Sprintf("%s (%d pax)", name, reservation.Pax)
+}
+
+func calendarDescription(reservation model.Reservation) string {
+	lines := []string{"Status: " + reservation.Status}
+	if reservation.PhoneNumber != "" {
+		lines = append(lines, "Phone: "+reservation.PhoneNumber)
+	}
+	if reservation.Email != "" {
+		lines = append(lines, "Email: "+reservation.Email)
+	}
+	if reservation.DepositFee > 0 {
+		lines = append(lines, fmt.Sprintf("Deposit: %d (%s)", reservation.DepositFee, reservation.DepositStatus))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func calendarStatus(status string) string {
+	switch status {
+	case model.ReservationPending:
+		return "TENTATIVE"
+	case model.ReservationCancelled, model.ReservationNoShow:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+var calendarEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeCalendarText(text string) string {
+	return calendarEscaper.Replace(text)
+}
+
+// writeCalendarLine ends the line with CRLF and folds it so no line is
+// longer than 75 octets, without splitting a UTF-8 character.
+func writeCalendarLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
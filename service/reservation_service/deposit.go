@@ -0,0 +1,79 @@
+package reservationservice
+
+import (
+	"errors"
+	"project_pos_app/model"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PayDeposit records the deposit of a reservation as paid with one of the
+// existing payment methods.
+func (s *serviceReservation) PayDeposit(reservation *model.Reservation, form model.FormDeposit) error {
+	if err := s.Repo.Reservation.FindReservation(reservation); err != nil {
+		return err
+	}
+	if reservation.DepositFee <= 0 {
+		return errors.New(" No Deposit Required")
+	}
+	if reservation.DepositStatus != model.DepositUnpaid || !reservation.HoldsTable() {
+		s.Log.Error("Failed to pay deposit", zap.Uint("id", reservation.ID), zap.String("deposit", reservation.DepositStatus))
+		return errors.New(" Deposit Cannot Be Paid")
+	}
+	if _, err := s.Repo.Reservation.FindPayment(form.PaymentMethod); err != nil {
+		return err
+	}
+	markDepositPaid(reservation, form.PaymentMethod)
+	return s.Repo.Reservation.UpdateDeposit(reservation)
+}
+
+// prepareDeposit sets the deposit state of a new reservation. A payment
+// method sent along with the booking means the deposit was taken up front.
+func (s *serviceReservation) prepareDeposit(reservation *model.Reservation) error {
+	reservation.DepositPaidAt = nil
+	reservation.DepositSettledAt = nil
+	switch {
+	case reservation.DepositFee <= 0:
+		reservation.DepositStatus = model.DepositNone
+		reservation.DepositPayment = 0
+	case reservation.DepositPayment != 0:
+		if _, err := s.Repo.Reservation.FindPayment(reservation.DepositPayment); err != nil {
+			return err
+		}
+		markDepositPaid(reservation, reservation.DepositPayment)
+	default:
+		reservation.DepositStatus = model.DepositUnpaid
+	}
+	return nil
+}
+
+func markDepositPaid(reservation *model.Reservation, paymentMethod uint) {
+	now := time.Now()
+	reservation.DepositStatus = model.DepositPaid
+	reservation.DepositPayment = paymentMethod
+	reservation.DepositPaidAt = &now
+}
+
+// settleDeposit decides what happens to a paid deposit when the reservation
+// moves to status. Cancelling at least the refund notice before the
+// reservation refunds it; later cancellations and no-shows forfeit it.
+func (s *serviceReservation) settleDeposit(reservation *model.Reservation, status string) {
+	if reservation.DepositStatus != model.DepositPaid {
+		return
+	}
+	now := model.WallClockNow()
+	switch status {
+	case model.ReservationCancelled:
+		if reservation.ReservationDate.Sub(now) >= s.settings.RefundNotice {
+			reservation.DepositStatus = model.DepositRefunded
+		} else {
+			reservation.DepositStatus = model.DepositForfeited
+		}
+	case model.ReservationNoShow:
+		reservation.DepositStatus = model.DepositForfeited
+	default:
+		return
+	}
+	reservation.DepositSettledAt = &now
+}
@@ -0,0 +1,87 @@
+package reservationservice
+
+import (
+	"errors"
+	"project_pos_app/model"
+	reservationrepository "project_pos_app/repository/reservation_repository"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidTransition = errors.New(" Invalid Status Transition")
+
+// Seat marks the reservation as seated, occupies its table and opens a draft
+// order for the guest. A paid deposit becomes a credit on that order.
+func (s *serviceReservation) Seat(reservation *model.Reservation) error {
+	if err := s.Repo.Reservation.FindReservation(reservation); err != nil {
+		return err
+	}
+	if !model.CanTransition(reservation.Status, model.ReservationSeated) {
+		s.Log.Error("Failed to seat reservation", zap.Uint("id", reservation.ID), zap.String("status", reservation.Status))
+		return ErrInvalidTransition
+	}
+	order := model.Order{
+		TableID:       reservation.TableNumber,
+		CustomerName:  reservation.Fullname,
+		Status:        "Draft",
+		Tax:           12,
+		ReservationID: reservation.ID,
+		CustomerID:    reservation.CustomerID,
+	}
+	if reservation.DepositStatus == model.DepositPaid {
+		order.Credit = float64(reservation.DepositFee)
+		reservation.DepositStatus = model.DepositApplied
+	}
+	if err := s.Repo.Reservation.Seat(reservation, &order, reservation.Status); err != nil {
+		return err
+	}
+	reservation.Status = model.ReservationSeated
+	reservation.OrderID = order.ID
+	return nil
+}
+
+func (s *serviceReservation) Cancel(reservation *model.Reservation) error {
+	return s.changeStatus(reservation, model.ReservationCancelled)
+}
+
+func (s *serviceReservation) NoShow(reservation *model.Reservation) error {
+	return s.changeStatus(reservation, model.ReservationNoShow)
+}
+
+// MarkNoShows moves reservations whose start time is more than the
+// no-show grace period in the past to no-show and returns them.
+func (s *serviceReservation) MarkNoShows() ([]model.Reservation, error) {
+	reservations, err := s.Repo.Reservation.FindOverdue(model.WallClockNow().Add(-s.settings.NoShowGrace))
+	if err != nil {
+		return nil, err
+	}
+	marked := []model.Reservation{}
+	for _, reservation := range reservations {
+		s.settleDeposit(&reservation, model.ReservationNoShow)
+		reservation.Status = model.ReservationNoShow
+		err := s.Repo.Reservation.UpdateStatus(&reservation, model.ReservationPending, model.ReservationConfirmed)
+		if errors.Is(err, reservationrepository.ErrStatusChanged) {
+			continue
+		}
+		if err != nil {
+			s.Log.Error("Failed to mark no-show", zap.Uint("id", reservation.ID), zap.Error(err))
+			continue
+		}
+		marked = append(marked, reservation)
+	}
+	return marked, nil
+}
+
+func (s *serviceReservation) changeStatus(reservation *model.Reservation, status string) error {
+	if err := s.Repo.Reservation.FindReservation(reservation); err != nil {
+		return err
+	}
+	if !model.CanTransition(reservation.Status, status) {
+		s.Log.Error("Failed to change reservation status", zap.Uint("id", reservation.ID), zap.String("from", reservation.Status), zap.String("to", status))
+		return ErrInvalidTransition
+	}
+	from := reservation.Status
+	s.settleDeposit(reservation, status)
+	reservation.Status = status
+	return s.Repo.Reservation.UpdateStatus(reservation, from)
+}
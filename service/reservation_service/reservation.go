@@ -2,45 +2,117 @@ package reservationservice
 
 import (
 	"errors"
+	"project_pos_app/config"
 	"project_pos_app/model"
 	"project_pos_app/repository"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
 )
 
 type ServiceReservation interface {
-	GetAll(date string) ([]model.Reservation, error)
+	GetAll(filter *model.ReservationFilter) ([]model.Reservation, int, int, error)
+	ExportCalendar(filter *model.ReservationFilter) ([]byte, error)
 	GetById(reservation *model.Reservation) error
 	Create(reservation *model.Reservation) error
 	Edit(reservation *model.Reservation, form model.FormUpdate) error
+	GetAvailability(date string, pax, duration int) (*model.ReservationAvailability, error)
+	Seat(reservation *model.Reservation) error
+	Cancel(reservation *model.Reservation) error
+	NoShow(reservation *model.Reservation) error
+	MarkNoShows() ([]model.Reservation, error)
+	PayDeposit(reservation *model.Reservation, form model.FormDeposit) error
 }
 
 type serviceReservation struct {
 	Repo *repository.AllRepository
 	Log  *zap.Logger
+	// settings are the booking rules.
+	settings config.Reservation
 }
 
-func NewRevenueService(repo *repository.AllRepository, log *zap.Logger) ServiceReservation {
+func NewRevenueService(repo *repository.AllRepository, log *zap.Logger, settings config.Reservation) ServiceReservation {
 	return &serviceReservation{
-		Repo: repo,
-		Log:  log,
+		Repo:     repo,
+		Log:      log,
+		settings: settings,
 	}
 }
 
-func (s *serviceReservation) GetAll(date string) ([]model.Reservation, error) {
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+func (s *serviceReservation) GetAll(filter *model.ReservationFilter) ([]model.Reservation, int, int, error) {
+	if err := s.normalizeFilter(filter); err != nil {
+		return nil, 0, 0, err
 	}
-	reservations, err := s.Repo.Reservation.FindReservations(date)
+	reservations, total, totalPages, err := s.Repo.Reservation.SearchReservations(*filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, 0, err
 	}
-	for i, _ := range reservations {
+	for i := range reservations {
 		reservations[i].Date = reservations[i].ReservationDate.Format("2006-01-02")
 		reservations[i].Time = reservations[i].ReservationDate.Format("15:04:05")
 	}
-	return reservations, nil
+	return reservations, total, totalPages, nil
+}
+
+// normalizeFilter validates the listing filter and fills in its defaults:
+// today when neither a range nor a search is given, date ascending, page 1
+// and 10 per page.
+func (s *serviceReservation) normalizeFilter(filter *model.ReservationFilter) error {
+	if filter.StartDate == "" && filter.EndDate == "" && filter.Search == "" {
+		filter.StartDate = time.Now().Format("2006-01-02")
+		filter.EndDate = filter.StartDate
+	}
+	var start, end time.Time
+	var err error
+	if filter.StartDate != "" {
+		if start, err = time.Parse("2006-01-02", filter.StartDate); err != nil {
+			s.Log.Error("Invalid reservation start date", zap.String("start", filter.StartDate))
+			return errors.New(" Bad Request")
+		}
+	}
+	if filter.EndDate != "" {
+		if end, err = time.Parse("2006-01-02", filter.EndDate); err != nil {
+			s.Log.Error("Invalid reservation end date", zap.String("end", filter.EndDate))
+			return errors.New(" Bad Request")
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		s.Log.Error("Invalid reservation date range", zap.String("start", filter.StartDate), zap.String("end", filter.EndDate))
+		return errors.New(" Bad Request")
+	}
+	for _, status := range filter.Status {
+		if !slices.Contains(model.ReservationStatuses, status) {
+			s.Log.Error("Invalid reservation status filter", zap.String("status", status))
+			return errors.New(" Bad Request")
+		}
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "date"
+	}
+	column, ok := model.ReservationSortColumns[filter.Sort]
+	if !ok {
+		s.Log.Error("Invalid reservation sort", zap.String("sort", filter.Sort))
+		return errors.New(" Bad Request")
+	}
+	filter.Sort = column
+	switch filter.Order {
+	case "":
+		filter.Order = "asc"
+	case "asc", "desc":
+	default:
+		s.Log.Error("Invalid reservation order", zap.String("order", filter.Order))
+		return errors.New(" Bad Request")
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+	return nil
 }
 func (s *serviceReservation) GetById(reservation *model.Reservation) error {
 	err := s.Repo.Reservation.FindReservation(reservation)
@@ -57,40 +129,57 @@ func (s *serviceReservation) Create(reservation *model.Reservation) error {
 		s.Log.Error("Failed to parsed time", zap.Error(err))
 		return errors.New(" Bad Request")
 	}
-	reservations, err := s.Repo.Reservation.FindReservationsByTable(reservation.Date, reservation.TableNumber)
-	if err != nil {
-		s.Log.Error("Failed to get data reservation", zap.Error(err))
-		return errors.New(" Bad Request")
-	}
 	reservation.ReservationDate = parsedTime
-	for _, v := range reservations {
-		if v.ReservationDate.Hour() == reservation.ReservationDate.Hour() {
-			s.Log.Error("Failed to Create Reservation", zap.String("Error", "Already Booked"))
-			return errors.New(" Already Booked")
-		}
+	if reservation.Status != model.ReservationPending {
+		reservation.Status = model.ReservationConfirmed
+	}
+	if reservation.Duration <= 0 {
+		reservation.Duration = model.DefaultReservationDuration(reservation.Pax)
+	}
+	if err := s.prepareDeposit(reservation); err != nil {
+		return err
+	}
+	if err := s.linkCustomer(reservation); err != nil {
+		return err
 	}
-	return s.Repo.Reservation.Insert(reservation)
+	return s.Repo.Reservation.Insert(reservation, s.settings.Buffer)
 }
 func (s *serviceReservation) Edit(reservation *model.Reservation, form model.FormUpdate) error {
 	err := s.Repo.Reservation.FindReservation(reservation)
 	if err != nil {
 		return err
 	}
-	date := reservation.ReservationDate.Format("2006-01-02")
-	reservations, err := s.Repo.Reservation.FindReservationsByTable(date, form.TableNumber)
-	if err != nil {
-		s.Log.Error("Failed to get data reservation", zap.Error(err))
-		return errors.New(" Bad Request")
+	from := reservation.Status
+	if form.TableNumber != 0 {
+		reservation.TableNumber = form.TableNumber
 	}
-	for _, v := range reservations {
-		if v.ReservationDate.Hour() == reservation.ReservationDate.Hour() {
-			s.Log.Error("Failed to Update Reservation", zap.String("Error", "Already Booked"))
-			return errors.New(" Already Booked")
-		}
+	if form.Duration > 0 {
+		reservation.Duration = form.Duration
 	}
-	reservation.TableNumber = form.TableNumber
-	if form.Status != "" {
+	if form.Status != "" && form.Status != reservation.Status {
+		// Seating also opens an order, so it only goes through Seat.
+		if form.Status == model.ReservationSeated || !model.CanTransition(reservation.Status, form.Status) {
+			s.Log.Error("Failed to Update Reservation", zap.String("from", reservation.Status), zap.String("to", form.Status))
+			return ErrInvalidTransition
+		}
+		s.settleDeposit(reservation, form.Status)
 		reservation.Status = form.Status
 	}
-	return s.Repo.Reservation.Update(reservation)
+	return s.Repo.Reservation.Update(reservation, s.settings.Buffer, from)
+}
+
+// linkCustomer finds or creates the customer with the reservation's phone
+// number or email.
+func (s *serviceReservation) linkCustomer(reservation *model.Reservation) error {
+	reservation.CustomerID = 0
+	customer := model.Customer{Name: reservation.Fullname, PhoneNumber: reservation.PhoneNumber, Email: reservation.Email}
+	customer.Normalize()
+	if !customer.Identifiable() {
+		return nil
+	}
+	if err := s.Repo.Customer.FindOrCreate(&customer); err != nil {
+		return err
+	}
+	reservation.CustomerID = customer.ID
+	return nil
 }
@@ -17,6 +17,7 @@ type RevenueServiceInterface interface {
 	SaveProductRevenue(product model.ProductRevenue) error
 	CalculateProductRevenue() ([]model.ProductRevenue, error)
 	GetLowStockProducts(threshold int) ([]model.Product, error)
+	FetchForfeitedDeposits() (map[string]float64, error)
 }
 
 type revenueService struct {
@@ -43,11 +44,20 @@ func (s *revenueService) FetchProductRevenues() ([]model.ProductRevenue, error)
 	return s.Repo.Revenue.GetProductRevenues()
 }
 
+func (s *revenueService) FetchForfeitedDeposits() (map[string]float64, error) {
+	return s.Repo.Revenue.GetForfeitedDeposits()
+}
+
 func (s *revenueService) GetLowStockProducts(threshold int) ([]model.Product, error) {
 	if threshold <= 0 {
 		return nil, errors.New("threshold must be a positive number")
 	}
-	return s.Repo.Revenue.FindLowStockProducts(threshold)
+	products, err := s.Repo.Revenue.FindLowStockProducts(threshold)
+	if err != nil {
+		return nil, err
+	}
+	model.SetStockStatuses(products, threshold)
+	return products, nil
 }
 
 func (s *revenueService) CalculateProductRevenue() ([]model.ProductRevenue, error) {
@@ -11,6 +11,7 @@ import (
 type MockRevenueServiceInterface interface {
 	FetchTotalRevenueByStatus() (map[string]float64, error)
 	FetchMonthlyRevenue() (map[string]float64, error)
+	FetchForfeitedDeposits() (map[string]float64, error)
 	FetchProductRevenues() ([]model.ProductRevenue, error)
 	SaveOrderRevenue(order model.OrderRevenue) error
 	CalculateOrderRevenue() ([]model.OrderRevenue, error)
@@ -55,6 +56,17 @@ func (m *MockRevenueService) FetchMonthlyRevenue() (map[string]float64, error) {
 	return result, nil
 }
 
+// FetchForfeitedDeposits mengambil deposit reservasi yang hangus per bulan
+func (m *MockRevenueService) FetchForfeitedDeposits() (map[string]float64, error) {
+	m.Log.Info("Fetching forfeited deposits")
+	result, err := m.Repo.GetForfeitedDeposits()
+	if err != nil {
+		m.Log.Error("Failed to fetch forfeited deposits", zap.Error(err))
+		return nil, err
+	}
+	return result, nil
+}
+
 // FetchProductRevenues mengambil revenue per produk
 func (m *MockRevenueService) FetchProductRevenues() ([]model.ProductRevenue, error) {
 	m.Log.Info("Fetching product revenues")
@@ -71,6 +71,30 @@ func TestFetchMonthlyRevenue(t *testing.T) {
 	})
 }
 
+func TestFetchForfeitedDeposits(t *testing.T) {
+	mockDB, service := helper.InitService()
+
+	t.Run("Successfully fetch forfeited deposits", func(t *testing.T) {
+		expectedResult := map[string]float64{"2024-12": 120.0}
+		mockDB.On("GetForfeitedDeposits").Once().Return(expectedResult, nil)
+
+		result, err := service.Revenue.FetchForfeitedDeposits()
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResult, result)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Failed to fetch forfeited deposits", func(t *testing.T) {
+		mockDB.On("GetForfeitedDeposits").Return(nil, errors.New("failed to fetch data"))
+		result, err := service.Revenue.FetchForfeitedDeposits()
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mockDB.AssertExpectations(t)
+	})
+}
+
 func TestGetLowStockProducts(t *testing.T) {
 	mockDB, service := helper.InitService()
 
@@ -1,17 +1,31 @@
 package service
 
 import (
+	"project_pos_app/config"
 	"project_pos_app/repository"
 	accessservice "project_pos_app/service/access_service"
+	attendanceservice "project_pos_app/service/attendance_service"
 	authservice "project_pos_app/service/auth_service"
+	bundleservice "project_pos_app/service/bundle_service"
 	categoryservice "project_pos_app/service/category_service"
+	customerservice "project_pos_app/service/customer_service"
 	dashboardservice "project_pos_app/service/dashboard_service"
+	drawerservice "project_pos_app/service/drawer_service"
+	ingredientservice "project_pos_app/service/ingredient_service"
+	loyaltyservice "project_pos_app/service/loyalty_service"
+	mediaservice "project_pos_app/service/media_service"
+	menuservice "project_pos_app/service/menu_service"
 	notifservice "project_pos_app/service/notif_service"
 	orderservice "project_pos_app/service/order_service"
 	productservice "project_pos_app/service/product_service"
+	purchaseservice "project_pos_app/service/purchase_service"
 	reservationservice "project_pos_app/service/reservation_service"
 	revenueservice "project_pos_app/service/revenue_service"
+	stocktakeservice "project_pos_app/service/stocktake_service"
 	superadminservice "project_pos_app/service/superadmin_service"
+	supplierservice "project_pos_app/service/supplier_service"
+	waitlistservice "project_pos_app/service/waitlist_service"
+	"project_pos_app/storage"
 
 	"go.uber.org/zap"
 )
@@ -27,19 +41,43 @@ type AllService struct {
 	Access      accessservice.AccessService
 	Reservation reservationservice.ServiceReservation
 	Dashboard   dashboardservice.ServiceDashboard
+	Waitlist    waitlistservice.ServiceWaitlist
+	Customer    customerservice.ServiceCustomer
+	Loyalty     loyaltyservice.ServiceLoyalty
+	Supplier    supplierservice.ServiceSupplier
+	Purchase    purchaseservice.ServicePurchase
+	Ingredient  ingredientservice.ServiceIngredient
+	Stocktake   stocktakeservice.ServiceStocktake
+	Media       mediaservice.ServiceMedia
+	Menu        menuservice.ServiceMenu
+	Bundle      bundleservice.ServiceBundle
+	Drawer      drawerservice.ServiceDrawer
+	Attendance  attendanceservice.ServiceAttendance
 }
 
-func NewAllService(repo *repository.AllRepository, log *zap.Logger) *AllService {
+func NewAllService(repo *repository.AllRepository, log *zap.Logger, store storage.Storage, cfg config.Config) *AllService {
 	return &AllService{
 		Auth:        authservice.NewManagementVoucherService(repo, log),
 		Notif:       notifservice.NewNotifService(repo, log),
 		Revenue:     revenueservice.NewRevenueService(repo, log),
-		Product:     productservice.NewProductService(repo, log),
-		Order:       orderservice.NewOrderService(repo, log),
+		Product:     productservice.NewProductService(repo, log, cfg.LowStock, cfg.ProductPurgeDays),
+		Order:       orderservice.NewOrderService(repo, log, cfg.LowStock),
 		Superadmin:  superadminservice.NewSuperadminService(repo, log),
 		Category:    categoryservice.NewCategoryService(repo, log),
 		Access:      accessservice.NewAccessService(repo, log),
-		Reservation: reservationservice.NewRevenueService(repo, log),
-		Dashboard:   dashboardservice.NewRevenueService(repo, log),
+		Reservation: reservationservice.NewRevenueService(repo, log, cfg.Reservation),
+		Dashboard:   dashboardservice.NewRevenueService(repo, log, cfg.LowStock),
+		Waitlist:    waitlistservice.NewWaitlistService(repo, log, cfg.Reservation.Buffer, cfg.WaitlistHistoryDays),
+		Customer:    customerservice.NewCustomerService(repo, log),
+		Loyalty:     loyaltyservice.NewLoyaltyService(repo, log),
+		Supplier:    supplierservice.NewSupplierService(repo, log),
+		Purchase:    purchaseservice.NewPurchaseService(repo, log, cfg.LowStock, cfg.PurchaseAutoDraft),
+		Ingredient:  ingredientservice.NewIngredientService(repo, log),
+		Stocktake:   stocktakeservice.NewStocktakeService(repo, log, cfg.LowStock),
+		Media:       mediaservice.NewMediaService(store, log, cfg.ImageMaxMB),
+		Menu:        menuservice.NewMenuService(repo, log, cfg.LowStock),
+		Bundle:      bundleservice.NewBundleService(repo, log),
+		Drawer:      drawerservice.NewDrawerService(repo, log),
+		Attendance:  attendanceservice.NewAttendanceService(repo, log, cfg.LateGrace, cfg.MonthlyHours),
 	}
 }
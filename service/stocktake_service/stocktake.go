@@ -0,0 +1,109 @@
+package stocktakeservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type ServiceStocktake interface {
+	GetAll(status string, page, limit int) ([]model.Stocktake, int, int, error)
+	Get(id uint) (*model.Stocktake, error)
+	Open(form model.FormStocktake, actorID uint) (*model.Stocktake, error)
+	Count(id uint, form model.FormStocktakeCount, actorID uint) (*model.Stocktake, error)
+	Commit(id, actorID uint) (*model.Stocktake, error)
+	Cancel(id uint) (*model.Stocktake, error)
+	Report(id uint) ([]model.StocktakeReportExcel, error)
+}
+
+type serviceStocktake struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+	// lowStock is the low-stock level of products without a reorder
+	// point.
+	lowStock int
+}
+
+func NewStocktakeService(repo *repository.AllRepository, log *zap.Logger, lowStock int) ServiceStocktake {
+	return &serviceStocktake{
+		Repo:     repo,
+		Log:      log,
+		lowStock: lowStock,
+	}
+}
+
+func (s *serviceStocktake) GetAll(status string, page, limit int) ([]model.Stocktake, int, int, error) {
+	return s.Repo.Stocktake.FindStocktakes(status, page, limit)
+}
+
+func (s *serviceStocktake) Get(id uint) (*model.Stocktake, error) {
+	return s.Repo.Stocktake.FindStocktake(id)
+}
+
+func (s *serviceStocktake) Open(form model.FormStocktake, actorID uint) (*model.Stocktake, error) {
+	stocktake := model.Stocktake{Note: strings.TrimSpace(form.Note), OpenedBy: actorID}
+	if err := s.Repo.Stocktake.Open(&stocktake, form.CategoryIDs); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Opened stocktake", zap.Uint("stocktake", stocktake.ID), zap.Int("lines", len(stocktake.Lines)))
+	return &stocktake, nil
+}
+
+func (s *serviceStocktake) Count(id uint, form model.FormStocktakeCount, actorID uint) (*model.Stocktake, error) {
+	form.Device = strings.TrimSpace(form.Device)
+	return s.Repo.Stocktake.SaveCounts(id, form, actorID)
+}
+
+// Commit posts the stocktake's adjustments, then checks the adjusted
+// products against their reorder points.
+func (s *serviceStocktake) Commit(id, actorID uint) (*model.Stocktake, error) {
+	stocktake, err := s.Repo.Stocktake.Commit(id, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := []uint{}
+	for _, line := range stocktake.Lines {
+		if line.AdjustedQty != 0 {
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	if len(productIDs) > 0 {
+		if _, err := s.Repo.Stock.EvaluateAlerts(productIDs, s.lowStock); err != nil {
+			s.Log.Error("Failed to evaluate stock alerts", zap.Uint("stocktake", id), zap.Error(err))
+		}
+	}
+	s.Log.Info("Committed stocktake", zap.Uint("stocktake", id), zap.Int("adjusted", len(productIDs)))
+	return stocktake, nil
+}
+
+func (s *serviceStocktake) Cancel(id uint) (*model.Stocktake, error) {
+	return s.Repo.Stocktake.Cancel(id)
+}
+
+// Report lists the stocktake's lines with their variances for the Excel
+// export.
+func (s *serviceStocktake) Report(id uint) ([]model.StocktakeReportExcel, error) {
+	stocktake, err := s.Repo.Stocktake.FindStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+	report := []model.StocktakeReportExcel{}
+	for i, line := range stocktake.Lines {
+		report = append(report, model.StocktakeReportExcel{
+			No:            i + 1,
+			ItemId:        line.ItemID,
+			ProductName:   line.ProductName,
+			SystemQty:     line.SystemQty,
+			Counted:       line.Counted,
+			CountedQty:    line.CountedQty,
+			Variance:      line.Variance,
+			UnitCost:      line.UnitCost,
+			VarianceValue: line.VarianceValue,
+			AdjustedQty:   line.AdjustedQty,
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,64 @@
+package supplierservice
+
+import (
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type ServiceSupplier interface {
+	GetAll(search string, page, limit int) ([]model.Supplier, int, int, error)
+	Get(id uint) (*model.Supplier, error)
+	Create(form model.FormSupplier) (*model.Supplier, error)
+	Update(id uint, form model.FormSupplier) (*model.Supplier, error)
+}
+
+type serviceSupplier struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+}
+
+func NewSupplierService(repo *repository.AllRepository, log *zap.Logger) ServiceSupplier {
+	return &serviceSupplier{
+		Repo: repo,
+		Log:  log,
+	}
+}
+
+func (s *serviceSupplier) GetAll(search string, page, limit int) ([]model.Supplier, int, int, error) {
+	return s.Repo.Supplier.FindSuppliers(search, page, limit)
+}
+
+func (s *serviceSupplier) Get(id uint) (*model.Supplier, error) {
+	return s.Repo.Supplier.FindSupplier(id)
+}
+
+func (s *serviceSupplier) Create(form model.FormSupplier) (*model.Supplier, error) {
+	supplier := supplierFromForm(form)
+	if err := s.Repo.Supplier.Create(&supplier); err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (s *serviceSupplier) Update(id uint, form model.FormSupplier) (*model.Supplier, error) {
+	supplier := supplierFromForm(form)
+	supplier.ID = id
+	if err := s.Repo.Supplier.Update(&supplier); err != nil {
+		return nil, err
+	}
+	return s.Repo.Supplier.FindSupplier(id)
+}
+
+func supplierFromForm(form model.FormSupplier) model.Supplier {
+	return model.Supplier{
+		Name:         strings.TrimSpace(form.Name),
+		ContactName:  strings.TrimSpace(form.ContactName),
+		PhoneNumber:  model.NormalizePhone(form.PhoneNumber),
+		Email:        strings.ToLower(strings.TrimSpace(form.Email)),
+		Address:      strings.TrimSpace(form.Address),
+		LeadTimeDays: form.LeadTimeDays,
+	}
+}
@@ -0,0 +1,297 @@
+package waitlistservice
+
+import (
+	"errors"
+	"math"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ServiceWaitlist interface {
+	GetQueue() ([]model.Waitlist, error)
+	Quote(pax int) (*model.WaitQuote, error)
+	Add(entry *model.Waitlist) error
+	Call(entry *model.Waitlist) error
+	Seat(entry *model.Waitlist, form model.FormWaitlistSeat) error
+	Remove(entry *model.Waitlist) error
+}
+
+var (
+	ErrInvalidTransition = errors.New(" Invalid Status Transition")
+	ErrTableReserved     = errors.New(" Table Is Reserved")
+)
+
+type serviceWaitlist struct {
+	Repo *repository.AllRepository
+	Log  *zap.Logger
+	// buffer is the turnover time kept free around a reservation.
+	buffer time.Duration
+	// historyDays is how many days of orders the dining time is averaged
+	// over.
+	historyDays int
+}
+
+func NewWaitlistService(repo *repository.AllRepository, log *zap.Logger, buffer time.Duration, historyDays int) ServiceWaitlist {
+	return &serviceWaitlist{
+		Repo:        repo,
+		Log:         log,
+		buffer:      buffer,
+		historyDays: historyDays,
+	}
+}
+
+func (s *serviceWaitlist) GetQueue() ([]model.Waitlist, error) {
+	return s.Repo.Waitlist.FindQueue()
+}
+
+// Quote estimates how long a party of pax guests joining now would wait,
+// behind everyone already in the queue.
+func (s *serviceWaitlist) Quote(pax int) (*model.WaitQuote, error) {
+	if pax <= 0 {
+		return nil, errors.New(" Bad Request")
+	}
+	queue, err := s.Repo.Waitlist.FindQueue()
+	if err != nil {
+		return nil, err
+	}
+	return s.estimate(pax, queue)
+}
+
+func (s *serviceWaitlist) Add(entry *model.Waitlist) error {
+	queue, err := s.Repo.Waitlist.FindQueue()
+	if err != nil {
+		return err
+	}
+	quote, err := s.estimate(entry.Pax, queue)
+	if err != nil {
+		return err
+	}
+	entry.ID = 0
+	entry.Status = model.WaitlistWaiting
+	entry.QuotedWait = quote.Minutes
+	entry.TableID, entry.OrderID = 0, 0
+	entry.CalledAt, entry.SeatedAt = nil, nil
+	if err := s.Repo.Waitlist.Insert(entry); err != nil {
+		return err
+	}
+	s.notify(*entry, "joined the waitlist")
+	return nil
+}
+
+// Call tells a waiting party their table is nearly ready.
+func (s *serviceWaitlist) Call(entry *model.Waitlist) error {
+	if err := s.Repo.Waitlist.FindEntry(entry); err != nil {
+		return err
+	}
+	if entry.Status != model.WaitlistWaiting {
+		s.Log.Error("Failed to call waitlist party", zap.Uint("id", entry.ID), zap.String("status", entry.Status))
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	entry.Status = model.WaitlistCalled
+	entry.CalledAt = &now
+	if err := s.Repo.Waitlist.UpdateStatus(entry); err != nil {
+		return err
+	}
+	s.notify(*entry, "was called")
+	return nil
+}
+
+// Seat gives the party the requested table, or the smallest free table that
+// fits it, and opens a draft order for it.
+func (s *serviceWaitlist) Seat(entry *model.Waitlist, form model.FormWaitlistSeat) error {
+	if err := s.Repo.Waitlist.FindEntry(entry); err != nil {
+		return err
+	}
+	if !entry.InQueue() {
+		s.Log.Error("Failed to seat waitlist party", zap.Uint("id", entry.ID), zap.String("status", entry.Status))
+		return ErrInvalidTransition
+	}
+	entry.TableID = form.TableID
+	if entry.TableID != 0 {
+		held, err := s.heldTables(entry.Pax)
+		if err != nil {
+			return err
+		}
+		if held[entry.TableID] {
+			s.Log.Error("Failed to seat waitlist party at a reserved table", zap.Uint("id", entry.ID), zap.Uint("table", entry.TableID))
+			return ErrTableReserved
+		}
+	} else {
+		tableID, err := s.allocateTable(entry.Pax)
+		if err != nil {
+			return err
+		}
+		entry.TableID = tableID
+	}
+
+	customer := model.Customer{Name: entry.Fullname, PhoneNumber: entry.PhoneNumber}
+	customer.Normalize()
+	if customer.Identifiable() {
+		if err := s.Repo.Customer.FindOrCreate(&customer); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	order := model.Order{
+		TableID:      entry.TableID,
+		CustomerName: entry.Fullname,
+		CustomerID:   customer.ID,
+		Status:       "Draft",
+		Tax:          12,
+	}
+	entry.Status = model.WaitlistSeated
+	entry.SeatedAt = &now
+	if err := s.Repo.Waitlist.Seat(entry, &order); err != nil {
+		return err
+	}
+	s.notify(*entry, "was seated")
+	return nil
+}
+
+func (s *serviceWaitlist) Remove(entry *model.Waitlist) error {
+	if err := s.Repo.Waitlist.FindEntry(entry); err != nil {
+		return err
+	}
+	if !entry.InQueue() {
+		s.Log.Error("Failed to remove waitlist party", zap.Uint("id", entry.ID), zap.String("status", entry.Status))
+		return ErrInvalidTransition
+	}
+	entry.Status = model.WaitlistRemoved
+	if err := s.Repo.Waitlist.UpdateStatus(entry); err != nil {
+		return err
+	}
+	s.notify(*entry, "was removed from the waitlist")
+	return nil
+}
+
+// notify records a waitlist change as a notification. A failure is logged
+// and does not undo the change.
+func (s *serviceWaitlist) notify(entry model.Waitlist, change string) {
+	if err := s.Repo.Notif.Create(model.NotifWaitlist(entry, change)); err != nil {
+		s.Log.Error("Failed to notify waitlist change", zap.Uint("id", entry.ID), zap.Error(err))
+	}
+}
+
+// estimate works out when each table that fits the party is expected to be
+// free, from the time its guests sat down and the average dining duration.
+// The parties ahead that fit those tables take them in turn, so the quote is
+// the release time of the next table left once they have been seated.
+func (s *serviceWaitlist) estimate(pax int, ahead []model.Waitlist) (*model.WaitQuote, error) {
+	occupancy, err := s.Repo.Waitlist.FindOccupancy()
+	if err != nil {
+		return nil, err
+	}
+	dining, err := s.diningMinutes(pax)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	releases := []float64{}
+	largest := 0
+	for _, table := range occupancy {
+		if table.Capacity < pax {
+			continue
+		}
+		largest = max(largest, table.Capacity)
+		if !table.IsBook {
+			releases = append(releases, 0)
+			continue
+		}
+		seated := 0.0
+		if table.SeatedAt != nil {
+			seated = now.Sub(*table.SeatedAt).Minutes()
+		}
+		releases = append(releases, math.Max(dining-seated, 0))
+	}
+	if len(releases) == 0 {
+		s.Log.Error("No table fits waitlist party", zap.Int("pax", pax))
+		return nil, errors.New(" No Table Fits Party")
+	}
+	sort.Float64s(releases)
+
+	competing := 0
+	for _, party := range ahead {
+		if party.Pax <= largest {
+			competing++
+		}
+	}
+	rounds := competing / len(releases)
+	wait := releases[competing%len(releases)] + float64(rounds)*dining
+	return &model.WaitQuote{
+		Pax:          pax,
+		PartiesAhead: len(ahead),
+		Minutes:      int(math.Ceil(wait)),
+	}, nil
+}
+
+// diningMinutes is the average dining duration over the last historyDays
+// days of orders, or the default reservation length
+// for the party when there is no history yet.
+func (s *serviceWaitlist) diningMinutes(pax int) (float64, error) {
+	since := time.Now().AddDate(0, 0, -s.historyDays)
+	average, err := s.Repo.Waitlist.AverageDiningMinutes(since)
+	if err != nil {
+		return 0, err
+	}
+	if average <= 0 {
+		average = float64(model.DefaultReservationDuration(pax))
+	}
+	return average, nil
+}
+
+// heldTables returns the tables a reservation holds, its buffer included,
+// at some point between now and when a party of pax is expected
+// to leave. Reservations from the evening before that run past midnight
+// count too.
+func (s *serviceWaitlist) heldTables(pax int) (map[uint]bool, error) {
+	dining, err := s.diningMinutes(pax)
+	if err != nil {
+		return nil, err
+	}
+	now := model.WallClockNow()
+	leave := now.Add(time.Duration(dining) * time.Minute)
+	reservations, err := s.Repo.Reservation.FindHolding(now, leave, s.buffer)
+	if err != nil {
+		return nil, err
+	}
+	held := map[uint]bool{}
+	for _, reservation := range reservations {
+		held[reservation.TableNumber] = true
+	}
+	return held, nil
+}
+
+// allocateTable picks the smallest free table that seats the party and is
+// not held by a reservation before the party is expected to leave.
+func (s *serviceWaitlist) allocateTable(pax int) (uint, error) {
+	occupancy, err := s.Repo.Waitlist.FindOccupancy()
+	if err != nil {
+		return 0, err
+	}
+	held, err := s.heldTables(pax)
+	if err != nil {
+		return 0, err
+	}
+
+	var best *model.TableOccupancy
+	for i, table := range occupancy {
+		if table.IsBook || table.Capacity < pax || held[table.ID] {
+			continue
+		}
+		if best == nil || table.Capacity < best.Capacity {
+			best = &occupancy[i]
+		}
+	}
+	if best == nil {
+		s.Log.Error("No free table for waitlist party", zap.Int("pax", pax))
+		return 0, errors.New(" No Free Table")
+	}
+	return best.ID, nil
+}
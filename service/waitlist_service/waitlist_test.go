@@ -0,0 +1,35 @@
+package waitlistservice_test
+
+import (
+	"project_pos_app/helper"
+	"project_pos_app/model"
+	"project_pos_app/repository"
+	waitlistservice "project_pos_app/service/waitlist_service"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSeat(t *testing.T) {
+	t.Run("A party is not seated at a table a reservation is about to hold", func(t *testing.T) {
+		db, mock := helper.SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+		service := waitlistservice.NewWaitlistService(repository.NewAllRepo(db, zap.NewNop(), model.LoyaltySettings{}), zap.NewNop(), 15*time.Minute, 30)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "waitlists" WHERE "waitlists"."id" = $1`)).
+			WithArgs(5, 5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "pax", "status"}).AddRow(5, 2, model.WaitlistWaiting))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(AVG(EXTRACT(EPOCH FROM (updated_at - created_at)) / 60), 0) FROM "orders"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"average"}).AddRow(60.0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reservations" WHERE status NOT IN`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "table_number", "status"}).AddRow(9, 3, model.ReservationConfirmed))
+
+		err := service.Seat(&model.Waitlist{ID: 5}, model.FormWaitlistSeat{TableID: 3})
+
+		assert.ErrorIs(t, err, waitlistservice.ErrTableReserved)
+	})
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"project_pos_app/model"
+)
+
+// CDN posts objects to the upload API the application used before it had
+// its own storage. The API picks the URL itself and has no delete.
+type CDN struct {
+	URL string
+}
+
+func NewCDN(url string) *CDN {
+	return &CDN{URL: url}
+}
+
+func (c *CDN) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	payload := &bytes.Buffer{}
+	writer := multipart.NewWriter(payload)
+	part, err := writer.CreateFormFile("image", path.Base(key))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, body); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, payload)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	var result model.CdnResponse
+	decodeErr := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&result)
+	if response.StatusCode/100 != 2 {
+		return "", fmt.Errorf("cdn upload: %s %s", response.Status, result.Message)
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("cdn upload: %w", decodeErr)
+	}
+	if !result.Success || result.Data.Url == "" {
+		return "", fmt.Errorf("cdn upload: %s", result.Message)
+	}
+	return result.Data.Url, nil
+}
+
+// Delete does nothing: objects on the CDN outlive the records using them.
+func (c *CDN) Delete(ctx context.Context, key string) error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalPath is where the router serves the local backend's directory.
+const LocalPath = "/media"
+
+// Local keeps objects as files under Dir. They are served by the
+// application itself under /media unless PublicURL points elsewhere.
+type Local struct {
+	Dir       string
+	PublicURL string
+}
+
+func NewLocal(dir, publicURL string) *Local {
+	if publicURL == "" {
+		publicURL = LocalPath
+	}
+	return &Local{
+		Dir:       dir,
+		PublicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(l.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so a failed upload never leaves a
+	// truncated image behind at the final path.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return l.PublicURL + "/" + key, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(l.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
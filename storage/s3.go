@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"project_pos_app/config"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 keeps objects in a bucket of any S3-compatible service such as AWS
+// S3, MinIO or Cloudflare R2. Requests use path-style addressing and are
+// signed with Signature Version 4.
+type S3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is where the bucket is read from, usually a CDN in front
+	// of it. It defaults to the bucket's own URL.
+	PublicURL string
+}
+
+func NewS3(cfg config.S3, publicURL string) *S3 {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + cfg.Bucket
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		PublicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	// The payload is hashed into the signature, so it is read up front.
+	// Images are small enough for that.
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := s.request(ctx, http.MethodPut, key, payload, contentType)
+	if err != nil {
+		return "", err
+	}
+	if err := s.do(request); err != nil {
+		return "", err
+	}
+	return s.PublicURL + "/" + EscapePath(key), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	request, err := s.request(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	return s.do(request)
+}
+
+func (s *S3) request(ctx context.Context, method, key string, payload []byte, contentType string) (*http.Request, error) {
+	url := s.Endpoint + "/" + EscapePath(s.Bucket+"/"+key)
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = int64(len(payload))
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	Sign(request, payload, s.Region, s.AccessKey, s.SecretKey, time.Now())
+	return request, nil
+}
+
+func (s *S3) do(request *http.Request) error {
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s %s", request.Method, request.URL.Path, response.Status, strings.TrimSpace(string(message)))
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
+
+const (
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	amzDate       = "20060102T150405Z"
+)
+
+// Sign adds Signature Version 4 headers for the s3 service to request,
+// signing its host, method, path, query and payload.
+func Sign(request *http.Request, payload []byte, region, accessKey, secretKey string, now time.Time) {
+	now = now.UTC()
+	payloadHash := sha256Hex(payload)
+	request.Header.Set("X-Amz-Date", now.Format(amzDate))
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(request)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		EscapePath(request.URL.Path),
+		canonicalQuery(request),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	day := now.Format("20060102")
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		now.Format(amzDate),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders signs the host and every x-amz-* and content-type
+// header present.
+func canonicalHeaders(request *http.Request) (string, string) {
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-type" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func canonicalQuery(request *http.Request) string {
+	query := request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, escape(key, false)+"="+escape(value, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// EscapePath percent-encodes everything in p but the unreserved characters
+// and slashes, as SigV4 expects.
+func EscapePath(p string) string {
+	return escape(p, true)
+}
+
+func escape(s string, keepSlash bool) string {
+	var escaped strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', keepSlash && b == '/':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
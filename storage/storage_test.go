@@ -0,0 +1,166 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"project_pos_app/config"
+	"project_pos_app/storage"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// minio is a stand-in for an S3-compatible server: it checks each request's
+// signature by signing a copy the same way, then keeps objects in memory.
+type minio struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	secret  string
+}
+
+func (m *minio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(payload)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		http.Error(w, "<Error><Code>XAmzContentSHA256Mismatch</Code></Error>", http.StatusBadRequest)
+		return
+	}
+
+	signedAt, _ := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	check := httptest.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		check.Header.Set("Content-Type", contentType)
+	}
+	storage.Sign(check, payload, "us-east-1", "minioadmin", m.secret, signedAt)
+	if check.Header.Get("Authorization") != r.Header.Get("Authorization") {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		m.objects[r.URL.Path] = payload
+	case http.MethodDelete:
+		delete(m.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3(t *testing.T) {
+	server := &minio{objects: map[string][]byte{}, secret: "minioadmin"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	store, err := storage.New(config.Storage{
+		Driver:    "s3",
+		PublicURL: "https://cdn.example.com/",
+		S3: config.S3{
+			Endpoint:  ts.URL,
+			Bucket:    "pos-media",
+			AccessKey: "minioadmin",
+			SecretKey: "minioadmin",
+		},
+	})
+	assert.NoError(t, err)
+
+	t.Run("Put stores the object and returns its public URL", func(t *testing.T) {
+		url, err := store.Put(context.Background(), "products/a b.png", strings.NewReader("png"), 3, "image/png")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://cdn.example.com/products/a%20b.png", url)
+		assert.Equal(t, []byte("png"), server.objects["/pos-media/products/a b.png"])
+	})
+
+	t.Run("Delete removes the object", func(t *testing.T) {
+		assert.NoError(t, store.Delete(context.Background(), "products/a b.png"))
+		assert.NotContains(t, server.objects, "/pos-media/products/a b.png")
+	})
+
+	t.Run("Rejected requests return an error", func(t *testing.T) {
+		server.secret = "rotated"
+		_, err := store.Put(context.Background(), "products/b.png", strings.NewReader("png"), 3, "image/png")
+		assert.ErrorContains(t, err, "403 Forbidden")
+		assert.ErrorContains(t, err, "SignatureDoesNotMatch")
+		server.secret = "minioadmin"
+	})
+
+	t.Run("Keys escaping the bucket are rejected", func(t *testing.T) {
+		_, err := store.Put(context.Background(), "../other/x.png", strings.NewReader("png"), 3, "image/png")
+		assert.ErrorIs(t, err, storage.ErrInvalidKey)
+	})
+
+	t.Run("PutAll keeps the order of the objects", func(t *testing.T) {
+		key, err := storage.NewKey("products")
+		assert.NoError(t, err)
+		urls, err := storage.PutAll(context.Background(), store, []storage.Object{
+			{Key: key + "-first.png", Data: []byte("1"), ContentType: "image/png"},
+			{Key: key + "-second.png", Data: []byte("2"), ContentType: "image/png"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"https://cdn.example.com/" + key + "-first.png",
+			"https://cdn.example.com/" + key + "-second.png",
+		}, urls)
+		assert.Equal(t, []byte("1"), server.objects["/pos-media/"+key+"-first.png"])
+	})
+
+	t.Run("PutAll removes the stored objects when one fails", func(t *testing.T) {
+		_, err := storage.PutAll(context.Background(), store, []storage.Object{
+			{Key: "products/kept.png", Data: []byte("1")},
+			{Key: "../escape.png", Data: []byte("2")},
+		})
+		assert.ErrorIs(t, err, storage.ErrInvalidKey)
+		assert.NotContains(t, server.objects, "/pos-media/products/kept.png")
+	})
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.New(config.Storage{Dir: dir})
+	assert.NoError(t, err)
+
+	url, err := store.Put(context.Background(), "categories/icon.png", strings.NewReader("icon"), 4, "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/categories/icon.png", url)
+	content, err := os.ReadFile(filepath.Join(dir, "categories", "icon.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "icon", string(content))
+
+	assert.NoError(t, store.Delete(context.Background(), "categories/icon.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "categories", "icon.png"))
+	assert.NoError(t, store.Delete(context.Background(), "categories/icon.png"))
+}
+
+func TestCDN(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := r.FormFile("image"); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"success":false,"message":"image is required"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"url":"https://cdn.example.com/x.png"}}`))
+	}))
+	defer ts.Close()
+
+	url, err := storage.NewCDN(ts.URL).Put(context.Background(), "products/x.png", strings.NewReader("png"), 3, "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/x.png", url)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	_, err = storage.NewCDN(down.URL).Put(context.Background(), "products/x.png", strings.NewReader("png"), 3, "image/png")
+	assert.ErrorContains(t, err, "502 Bad Gateway")
+}